@@ -0,0 +1,352 @@
+package zlmedia
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+)
+
+// AuthAction 鉴权的操作类型
+type AuthAction string
+
+// 鉴权的操作类型
+const (
+	AuthActionPublish AuthAction = "publish" // 推流
+	AuthActionPlay    AuthAction = "play"    // 播放
+)
+
+// 签名校验错误
+var (
+	ErrSignatureMissing = errors.New("缺少签名参数")
+	ErrSignatureExpired = errors.New("签名已过期")
+	ErrSignatureInvalid = errors.New("签名错误")
+)
+
+// AuthRequest 鉴权请求
+type AuthRequest struct {
+	Action AuthAction // 操作类型
+	Key    StreamKey  // 推流或播放的流
+	Schema string     // 协议，例如rtsp、rtmp
+	Params url.Values // 推流或播放url中的参数
+	IP     string     // 客户端ip
+}
+
+// AuthDecision 鉴权结果
+type AuthDecision struct {
+	Allow   bool            // 是否允许
+	Msg     string          // 不允许时的错误提示
+	Options *PublishOptions // 允许推流时该流的转协议和录制选项，为nil时使用默认值，仅对推流有效
+}
+
+// Allow 允许的鉴权结果
+func Allow() *AuthDecision {
+	return &AuthDecision{Allow: true}
+}
+
+// Deny 不允许的鉴权结果
+func Deny(msg string) *AuthDecision {
+	return &AuthDecision{Msg: msg}
+}
+
+// Authorizer 推流和播放鉴权接口
+// 返回error表示鉴权过程出错，ZLMediaKit将拒绝该请求，返回nil结果视为不允许
+type Authorizer interface {
+	Authorize(ctx context.Context, req *AuthRequest) (*AuthDecision, error)
+}
+
+// AuthorizerFunc 函数形式的Authorizer
+type AuthorizerFunc func(ctx context.Context, req *AuthRequest) (*AuthDecision, error)
+
+// Authorize 实现Authorizer接口
+func (f AuthorizerFunc) Authorize(ctx context.Context, req *AuthRequest) (*AuthDecision, error) {
+	return f(ctx, req)
+}
+
+// ChainAuthorizer 依次执行多个Authorizer，全部允许时才允许
+// 推流选项取第一个返回了Options的Authorizer，返回nil结果的Authorizer视为不允许
+type ChainAuthorizer []Authorizer
+
+// Authorize 实现Authorizer接口
+func (c ChainAuthorizer) Authorize(ctx context.Context, req *AuthRequest) (*AuthDecision, error) {
+	result := Allow()
+	for _, authorizer := range c {
+		decision, err := authorizer.Authorize(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if decision == nil {
+			return Deny("鉴权结果为空"), nil
+		}
+		if !decision.Allow {
+			return decision, nil
+		}
+		if result.Options == nil {
+			result.Options = decision.Options
+		}
+	}
+	return result, nil
+}
+
+// StaticKeyAuthorizer 静态密钥鉴权，url参数Param的值需要在对应操作的密钥列表中
+type StaticKeyAuthorizer struct {
+	Param       string   // 密钥所在的url参数名，默认为key
+	PublishKeys []string // 允许推流的密钥
+	PlayKeys    []string // 允许播放的密钥
+}
+
+// Authorize 实现Authorizer接口
+func (a *StaticKeyAuthorizer) Authorize(ctx context.Context, req *AuthRequest) (*AuthDecision, error) {
+	param := a.Param
+	if param == "" {
+		param = "key"
+	}
+
+	keys := a.PlayKeys
+	if req.Action == AuthActionPublish {
+		keys = a.PublishKeys
+	}
+
+	value := req.Params.Get(param)
+	if value == "" {
+		return Deny(fmt.Sprintf("缺少参数%s", param)), nil
+	}
+	for _, key := range keys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(value)) == 1 {
+			return Allow(), nil
+		}
+	}
+	return Deny("密钥错误"), nil
+}
+
+// SignedURLAuthorizer 签名url鉴权，使用URLSigner校验url参数中的签名和有效期
+type SignedURLAuthorizer struct {
+	Signer *URLSigner
+}
+
+// Authorize 实现Authorizer接口
+func (a *SignedURLAuthorizer) Authorize(ctx context.Context, req *AuthRequest) (*AuthDecision, error) {
+	if err := a.Signer.Verify(req.Action, req.Key, req.Params); err != nil {
+		return Deny(err.Error()), nil
+	}
+	return Allow(), nil
+}
+
+// ACLRule 访问控制规则
+// VHost、App、Stream支持path.Match通配符，为空时匹配任意值
+type ACLRule struct {
+	Actions []AuthAction    // 适用的操作，为空时适用于所有操作
+	VHost   string          // 虚拟主机
+	App     string          // 应用名
+	Stream  string          // 流id
+	Allow   bool            // 匹配时是否允许
+	Msg     string          // 不允许时的错误提示
+	Options *PublishOptions // 匹配且允许推流时该流的转协议和录制选项
+}
+
+// match 判断规则是否匹配请求
+func (r *ACLRule) match(req *AuthRequest) bool {
+	if len(r.Actions) > 0 {
+		found := false
+		for _, action := range r.Actions {
+			if action == req.Action {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// 依次匹配vhost、app和stream，每一项的通配符只与对应的值比较
+	for _, field := range [...]struct{ pattern, value string }{
+		{r.VHost, req.Key.NormalizedVHost()},
+		{r.App, req.Key.App},
+		{r.Stream, req.Key.Stream},
+	} {
+		if field.pattern == "" {
+			continue
+		}
+		if ok, _ := path.Match(field.pattern, field.value); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ACLAuthorizer 按应用和流的访问控制规则鉴权，第一个匹配的规则生效
+type ACLAuthorizer struct {
+	Rules        []ACLRule // 访问控制规则，按顺序匹配
+	DefaultAllow bool      // 没有规则匹配时是否允许
+}
+
+// Authorize 实现Authorizer接口
+func (a *ACLAuthorizer) Authorize(ctx context.Context, req *AuthRequest) (*AuthDecision, error) {
+	for i := range a.Rules {
+		rule := &a.Rules[i]
+		if !rule.match(req) {
+			continue
+		}
+		if !rule.Allow {
+			msg := rule.Msg
+			if msg == "" {
+				msg = "没有权限"
+			}
+			return Deny(msg), nil
+		}
+		return &AuthDecision{Allow: true, Options: rule.Options}, nil
+	}
+
+	if a.DefaultAllow {
+		return Allow(), nil
+	}
+	return Deny("没有权限"), nil
+}
+
+// URLSigner 推流和播放url签名器
+// 签名内容为操作类型、vhost/app/stream和过期时间，使用HMAC-SHA256计算，
+// 签名和过期时间(unix时间戳)分别放在url参数sign和expires中
+type URLSigner struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewURLSigner 创建url签名器
+func NewURLSigner(secret string) *URLSigner {
+	return &URLSigner{secret: []byte(secret), now: time.Now}
+}
+
+// signature 计算签名
+func (s *URLSigner) signature(action AuthAction, key StreamKey, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%d", action, key.NormalizedVHost(), key.App, key.Stream, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign 生成签名参数，有效期为ttl
+func (s *URLSigner) Sign(action AuthAction, key StreamKey, ttl time.Duration) url.Values {
+	expires := s.now().Add(ttl).Unix()
+	return url.Values{
+		"expires": {strconv.FormatInt(expires, 10)},
+		"sign":    {s.signature(action, key, expires)},
+	}
+}
+
+// SignURL 为推流或播放url添加签名参数，保留url中已有的参数
+func (s *URLSigner) SignURL(action AuthAction, key StreamKey, rawURL string, ttl time.Duration) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("解析url失败: %w", err)
+	}
+
+	query := u.Query()
+	for name, values := range s.Sign(action, key, ttl) {
+		query[name] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Verify 校验url参数中的签名和有效期
+func (s *URLSigner) Verify(action AuthAction, key StreamKey, params url.Values) error {
+	sign, expiresStr := params.Get("sign"), params.Get("expires")
+	if sign == "" || expiresStr == "" {
+		return ErrSignatureMissing
+	}
+
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	if !hmac.Equal([]byte(sign), []byte(s.signature(action, key, expires))) {
+		return ErrSignatureInvalid
+	}
+	if s.now().Unix() > expires {
+		return ErrSignatureExpired
+	}
+	return nil
+}
+
+// AuthHookHandler 在on_publish和on_play事件中执行鉴权的HookHandler
+// 鉴权通过后再调用内嵌的HookHandler，其它事件直接交给内嵌的HookHandler处理
+type AuthHookHandler struct {
+	HookHandler
+	Publish Authorizer // 推流鉴权，为nil时允许所有推流
+	Play    Authorizer // 播放鉴权，为nil时允许所有播放
+}
+
+// NewAuthHookHandler 创建鉴权HookHandler，next为nil时使用NopHookHandler
+func NewAuthHookHandler(next HookHandler, publish, play Authorizer) *AuthHookHandler {
+	if next == nil {
+		next = NopHookHandler{}
+	}
+	return &AuthHookHandler{HookHandler: next, Publish: publish, Play: play}
+}
+
+// authorize 执行鉴权，authorizer为nil时允许，authorizer返回nil结果时不允许
+func authorize(ctx context.Context, authorizer Authorizer, req *AuthRequest) (*AuthDecision, error) {
+	if authorizer == nil {
+		return Allow(), nil
+	}
+	decision, err := authorizer.Authorize(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("鉴权失败: %w", err)
+	}
+	if decision == nil {
+		return Deny("鉴权结果为空"), nil
+	}
+	return decision, nil
+}
+
+// newAuthRequest 根据hook事件创建鉴权请求
+func newAuthRequest(action AuthAction, media HookMedia, peer HookPeer) *AuthRequest {
+	params, _ := url.ParseQuery(media.Params)
+	return &AuthRequest{
+		Action: action,
+		Key:    media.Key(),
+		Schema: media.Schema,
+		Params: params,
+		IP:     peer.IP,
+	}
+}
+
+// OnPublish 推流鉴权，允许时使用鉴权结果中的推流选项
+func (h *AuthHookHandler) OnPublish(ctx context.Context, hook *OnPublishHook) (*OnPublishResult, error) {
+	decision, err := authorize(ctx, h.Publish, newAuthRequest(AuthActionPublish, hook.HookMedia, hook.HookPeer))
+	if err != nil {
+		return nil, err
+	}
+	if !decision.Allow {
+		return &OnPublishResult{Code: CodeAuthFailed, Msg: decision.Msg}, nil
+	}
+
+	result, err := h.HookHandler.OnPublish(ctx, hook)
+	if err != nil || result == nil || result.Code != CodeSuccess {
+		return result, err
+	}
+	if decision.Options != nil {
+		result.PublishOptions = *decision.Options
+	}
+	return result, nil
+}
+
+// OnPlay 播放鉴权
+func (h *AuthHookHandler) OnPlay(ctx context.Context, hook *OnPlayHook) (*HookResult, error) {
+	decision, err := authorize(ctx, h.Play, newAuthRequest(AuthActionPlay, hook.HookMedia, hook.HookPeer))
+	if err != nil {
+		return nil, err
+	}
+	if !decision.Allow {
+		return &HookResult{Code: CodeAuthFailed, Msg: decision.Msg}, nil
+	}
+
+	return h.HookHandler.OnPlay(ctx, hook)
+}
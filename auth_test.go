@@ -0,0 +1,158 @@
+package zlmedia_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+func TestACLAuthorizer(t *testing.T) {
+	acl := &zlmedia.ACLAuthorizer{
+		Rules: []zlmedia.ACLRule{
+			{App: "live", Stream: "live", Allow: true},
+			{Actions: []zlmedia.AuthAction{zlmedia.AuthActionPlay}, App: "live", Allow: true},
+			{App: "private", Msg: "私有应用"},
+			{VHost: "*.example.com", App: "cam*", Allow: true},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		action  zlmedia.AuthAction
+		key     zlmedia.StreamKey
+		allow   bool
+		wantMsg string
+	}{
+		{"相同通配符都需要匹配", zlmedia.AuthActionPublish, zlmedia.StreamKey{App: "live", Stream: "live"}, true, ""},
+		{"相同通配符不能互相覆盖", zlmedia.AuthActionPublish, zlmedia.StreamKey{App: "live", Stream: "other"}, false, "没有权限"},
+		{"相同通配符不能被流id绕过", zlmedia.AuthActionPublish, zlmedia.StreamKey{App: "other", Stream: "live"}, false, "没有权限"},
+		{"按操作匹配", zlmedia.AuthActionPlay, zlmedia.StreamKey{App: "live", Stream: "other"}, true, ""},
+		{"拒绝规则的提示", zlmedia.AuthActionPlay, zlmedia.StreamKey{App: "private", Stream: "a"}, false, "私有应用"},
+		{"通配符匹配", zlmedia.AuthActionPublish, zlmedia.StreamKey{VHost: "a.example.com", App: "cam1", Stream: "a"}, true, ""},
+		{"默认虚拟主机不匹配通配符", zlmedia.AuthActionPublish, zlmedia.StreamKey{App: "cam1", Stream: "a"}, false, "没有权限"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := acl.Authorize(context.Background(), &zlmedia.AuthRequest{Action: tt.action, Key: tt.key})
+			if err != nil {
+				t.Fatalf("Authorize() error = %v", err)
+			}
+			if decision.Allow != tt.allow || decision.Msg != tt.wantMsg {
+				t.Errorf("Authorize() = %+v, want allow=%v msg=%q", decision, tt.allow, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestChainAuthorizer(t *testing.T) {
+	nilDecision := zlmedia.AuthorizerFunc(func(ctx context.Context, req *zlmedia.AuthRequest) (*zlmedia.AuthDecision, error) {
+		return nil, nil
+	})
+	failed := zlmedia.AuthorizerFunc(func(ctx context.Context, req *zlmedia.AuthRequest) (*zlmedia.AuthDecision, error) {
+		return nil, errors.New("backend down")
+	})
+	allow := zlmedia.AuthorizerFunc(func(ctx context.Context, req *zlmedia.AuthRequest) (*zlmedia.AuthDecision, error) {
+		return zlmedia.Allow(), nil
+	})
+	deny := zlmedia.AuthorizerFunc(func(ctx context.Context, req *zlmedia.AuthRequest) (*zlmedia.AuthDecision, error) {
+		return zlmedia.Deny("denied"), nil
+	})
+
+	tests := []struct {
+		name    string
+		chain   zlmedia.ChainAuthorizer
+		allow   bool
+		wantErr bool
+	}{
+		{"空链允许", nil, true, false},
+		{"全部允许", zlmedia.ChainAuthorizer{allow, allow}, true, false},
+		{"任一拒绝", zlmedia.ChainAuthorizer{allow, deny}, false, false},
+		{"nil结果视为拒绝", zlmedia.ChainAuthorizer{allow, nilDecision}, false, false},
+		{"错误中断", zlmedia.ChainAuthorizer{failed, allow}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := tt.chain.Authorize(context.Background(), &zlmedia.AuthRequest{Action: zlmedia.AuthActionPlay})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && decision.Allow != tt.allow {
+				t.Errorf("Authorize() allow = %v, want %v", decision.Allow, tt.allow)
+			}
+		})
+	}
+}
+
+func TestURLSigner(t *testing.T) {
+	signer := zlmedia.NewURLSigner("secret")
+	key := zlmedia.StreamKey{App: "live", Stream: "test"}
+	valid := signer.Sign(zlmedia.AuthActionPlay, key, time.Minute)
+
+	tests := []struct {
+		name   string
+		action zlmedia.AuthAction
+		key    zlmedia.StreamKey
+		params url.Values
+		want   error
+	}{
+		{"有效签名", zlmedia.AuthActionPlay, key, valid, nil},
+		{"缺少签名", zlmedia.AuthActionPlay, key, url.Values{}, zlmedia.ErrSignatureMissing},
+		{"操作不一致", zlmedia.AuthActionPublish, key, valid, zlmedia.ErrSignatureInvalid},
+		{"流不一致", zlmedia.AuthActionPlay, zlmedia.StreamKey{App: "live", Stream: "other"}, valid, zlmedia.ErrSignatureInvalid},
+		{"已过期", zlmedia.AuthActionPlay, key, signer.Sign(zlmedia.AuthActionPlay, key, -time.Minute), zlmedia.ErrSignatureExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := signer.Verify(tt.action, tt.key, tt.params); !errors.Is(err, tt.want) {
+				t.Errorf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthHookHandler(t *testing.T) {
+	nilDecision := zlmedia.AuthorizerFunc(func(ctx context.Context, req *zlmedia.AuthRequest) (*zlmedia.AuthDecision, error) {
+		return nil, nil
+	})
+	enableMp4 := true
+	publish := &zlmedia.ACLAuthorizer{Rules: []zlmedia.ACLRule{
+		{App: "live", Allow: true, Options: &zlmedia.PublishOptions{EnableMp4: &enableMp4}},
+	}}
+	server := httptest.NewServer(zlmedia.NewHookServer(zlmedia.NewAuthHookHandler(nil, publish, nilDecision)))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		hook string
+		body string
+		want string
+	}{
+		{"允许推流并返回选项", "on_publish", `{"app":"live","stream":"a"}`, `{"code":0,"enable_mp4":true}`},
+		{"拒绝推流", "on_publish", `{"app":"other","stream":"a"}`, `{"code":-100,"msg":"没有权限"}`},
+		{"nil结果拒绝播放", "on_play", `{"app":"live","stream":"a"}`, `{"code":-100,"msg":"鉴权结果为空"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(server.URL+"/index/hook/"+tt.hook, "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			got, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.TrimSpace(string(got)) != tt.want {
+				t.Errorf("%s = %s, want %s", tt.hook, got, tt.want)
+			}
+		})
+	}
+}
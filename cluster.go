@@ -0,0 +1,276 @@
+package zlmedia
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ClusterConfig 集群配置
+type ClusterConfig struct {
+	Strategy     Strategy      // 节点选择策略，默认为LeastLoadStrategy
+	PollInterval time.Duration // 负载轮询间隔，默认为5秒
+	StaleAfter   time.Duration // 负载数据超过该时间未更新则视为节点不可用，默认为3倍轮询间隔
+}
+
+// NodeLoad 节点负载信息
+type NodeLoad struct {
+	NodeID      string       // 节点ID
+	Threads     []ThreadLoad // 网络线程负载
+	WorkThreads []ThreadLoad // 后台线程负载
+	Statistic   Statistic    // 主要对象个数
+	UpdatedAt   time.Time    // 最近一次成功轮询的时间
+	Err         error        // 最近一次轮询的错误，为nil表示轮询成功
+}
+
+// Score 节点负载评分，越低表示越空闲
+// 评分为网络线程与后台线程的平均负载之和，线程平均延时每10毫秒折算为1点负载
+func (l NodeLoad) Score() float64 {
+	var load, delay float64
+	var count int
+	for _, threads := range [][]ThreadLoad{l.Threads, l.WorkThreads} {
+		if len(threads) == 0 {
+			continue
+		}
+		var sumLoad, sumDelay int
+		for _, t := range threads {
+			sumLoad += t.Load
+			sumDelay += t.Delay
+		}
+		load += float64(sumLoad) / float64(len(threads))
+		delay += float64(sumDelay) / float64(len(threads))
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return load + delay/float64(count)/10
+}
+
+// Cluster ZLMediaKit集群
+// 定期轮询注册表中每个节点的负载，并根据策略为新的流选择节点
+type Cluster struct {
+	registry *Registry
+	config   ClusterConfig
+
+	mu    sync.RWMutex
+	loads map[string]NodeLoad
+
+	warmMu sync.Mutex // 保证冷启动时只有一个PickNode同步轮询
+}
+
+// NewCluster 创建集群
+func NewCluster(registry *Registry, config ClusterConfig) *Cluster {
+	if config.Strategy == nil {
+		config.Strategy = LeastLoadStrategy{}
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 5 * time.Second
+	}
+	if config.StaleAfter <= 0 {
+		config.StaleAfter = 3 * config.PollInterval
+	}
+
+	return &Cluster{
+		registry: registry,
+		config:   config,
+		loads:    make(map[string]NodeLoad),
+	}
+}
+
+// Registry 获取集群使用的节点注册表
+func (c *Cluster) Registry() *Registry {
+	return c.registry
+}
+
+// Run 立即轮询一次所有节点的负载，之后按PollInterval定期轮询，直到ctx结束
+func (c *Cluster) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		c.Refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh 并发轮询所有节点的负载
+// 已从注册表中删除的节点会同时被移出负载表
+func (c *Cluster) Refresh(ctx context.Context) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	loads := make(map[string]NodeLoad)
+
+	c.registry.Range(func(nodeID string, client *Client) bool {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			load := pollNodeLoad(ctx, nodeID, client)
+
+			mu.Lock()
+			loads[nodeID] = load
+			mu.Unlock()
+		}()
+		return true
+	})
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for nodeID, load := range loads {
+		if load.Err != nil {
+			// 轮询失败时保留上一次的负载数据，是否可用由UpdatedAt判断
+			prev := c.loads[nodeID]
+			prev.NodeID = nodeID
+			prev.Err = load.Err
+			load = prev
+		}
+		c.loads[nodeID] = load
+	}
+	for nodeID := range c.loads {
+		if _, ok := loads[nodeID]; !ok {
+			delete(c.loads, nodeID)
+		}
+	}
+}
+
+// pollNodeLoad 轮询单个节点的负载
+func pollNodeLoad(ctx context.Context, nodeID string, client *Client) NodeLoad {
+	load := NodeLoad{NodeID: nodeID}
+	serverAPI := NewServerAPI(client)
+
+	threads, err := serverAPI.GetThreadsLoad(ctx, &GetThreadsLoadRequest{})
+	if err != nil {
+		load.Err = err
+		return load
+	}
+	workThreads, err := serverAPI.GetWorkThreadsLoad(ctx, &GetWorkThreadsLoadRequest{})
+	if err != nil {
+		load.Err = err
+		return load
+	}
+	statistic, err := serverAPI.GetStatistic(ctx, &GetStatisticRequest{})
+	if err != nil {
+		load.Err = err
+		return load
+	}
+
+	load.Threads = threads.Data
+	load.WorkThreads = workThreads.Data
+	load.Statistic = statistic.Data
+	load.UpdatedAt = time.Now()
+	return load
+}
+
+// Loads 获取所有节点最近一次的负载信息，按节点ID排序
+func (c *Cluster) Loads() []NodeLoad {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	loads := make([]NodeLoad, 0, len(c.loads))
+	for _, nodeID := range c.registry.NodeIDs() {
+		if load, ok := c.loads[nodeID]; ok {
+			loads = append(loads, load)
+		}
+	}
+	return loads
+}
+
+// healthyLoads 获取所有健康节点的负载信息，按节点ID排序
+func (c *Cluster) healthyLoads() []NodeLoad {
+	now := time.Now()
+	loads := c.Loads()
+	healthy := loads[:0]
+	for _, load := range loads {
+		if load.Err == nil && now.Sub(load.UpdatedAt) <= c.config.StaleAfter {
+			healthy = append(healthy, load)
+		}
+	}
+	return healthy
+}
+
+// hasLoads 是否已经获取过负载数据
+func (c *Cluster) hasLoads() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.loads) > 0
+}
+
+// warmUp 尚未获取过任何负载数据时同步轮询一次
+// 并发调用时只有一个调用方轮询，其余调用方等待并复用轮询结果
+func (c *Cluster) warmUp(ctx context.Context) {
+	if c.hasLoads() {
+		return
+	}
+
+	c.warmMu.Lock()
+	defer c.warmMu.Unlock()
+
+	if !c.hasLoads() {
+		c.Refresh(ctx)
+	}
+}
+
+// PickNode 根据策略为流选择一个健康的节点
+// 尚未获取过任何负载数据时会先同步轮询一次，并发的冷启动调用只轮询一次
+// 返回: 节点ID和对应的客户端
+func (c *Cluster) PickNode(ctx context.Context, key StreamKey) (string, *Client, error) {
+	c.warmUp(ctx)
+
+	nodes := c.healthyLoads()
+	if len(nodes) == 0 {
+		return "", nil, ErrNoAvailableNode
+	}
+
+	nodeID, err := c.config.Strategy.Pick(key, nodes)
+	if err != nil {
+		return "", nil, fmt.Errorf("选择节点失败: %w", err)
+	}
+
+	client, ok := c.registry.Get(nodeID)
+	if !ok {
+		return "", nil, fmt.Errorf("节点%s不存在", nodeID)
+	}
+	return nodeID, client, nil
+}
+
+// AddStreamProxy 选择节点并在该节点上添加拉流代理
+// 返回: 选中的节点ID和添加结果
+func (c *Cluster) AddStreamProxy(ctx context.Context, req *AddStreamProxyRequest) (string, *Response[ProxyKey], error) {
+	key := StreamKey{VHost: req.VHost, App: req.App, Stream: req.Stream}
+	nodeID, client, err := c.PickNode(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	resp, err := NewProxyAPI(client).AddStreamProxy(ctx, req)
+	if err != nil {
+		return nodeID, resp, fmt.Errorf("节点%s: %w", nodeID, err)
+	}
+	return nodeID, resp, nil
+}
+
+// OpenRtpServer 选择节点并在该节点上创建RTP接收端口
+// RTP流固定注册在rtp应用下，以StreamID参与节点选择
+// 返回: 选中的节点ID和创建结果
+func (c *Cluster) OpenRtpServer(ctx context.Context, req *OpenRtpServerRequest) (string, *OpenRtpServerResponse, error) {
+	key := StreamKey{VHost: DefaultVHost, App: "rtp", Stream: req.StreamID}
+	nodeID, client, err := c.PickNode(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	resp, err := NewRTPAPI(client).OpenRtpServer(ctx, req)
+	if err != nil {
+		return nodeID, resp, fmt.Errorf("节点%s: %w", nodeID, err)
+	}
+	return nodeID, resp, nil
+}
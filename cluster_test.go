@@ -0,0 +1,67 @@
+package zlmedia_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+func TestClusterPickNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodes    int
+		down     map[int]bool // 不可用的节点序号
+		callers  int          // 并发冷启动的调用方数量
+		wantNode string
+		wantErr  error
+	}{
+		{"并发冷启动只轮询一次", 1, nil, 10, "node0", nil},
+		{"跳过不可用的节点", 2, map[int]bool{0: true}, 4, "node1", nil},
+		{"没有节点", 0, nil, 4, "", zlmedia.ErrNoAvailableNode},
+		{"所有节点不可用", 1, map[int]bool{0: true}, 4, "", zlmedia.ErrNoAvailableNode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := zlmedia.NewRegistry()
+			servers := make([]*zlmtest.Server, tt.nodes)
+			for i := range servers {
+				servers[i] = zlmtest.NewServer()
+				defer servers[i].Close()
+				if tt.down[i] {
+					servers[i].InjectFault("/index/api/getThreadsLoad", zlmtest.Fault{Code: zlmedia.CodeException, Msg: "down"})
+				} else {
+					// 轮询变慢，使所有调用方都在冷启动期间到达
+					servers[i].InjectFault("/index/api/getThreadsLoad", zlmtest.Fault{Delay: 20 * time.Millisecond, Times: 1})
+				}
+				if err := registry.Add("node"+string(rune('0'+i)), servers[i].Client()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			cluster := zlmedia.NewCluster(registry, zlmedia.ClusterConfig{PollInterval: time.Hour})
+
+			var wg sync.WaitGroup
+			for range tt.callers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					nodeID, _, err := cluster.PickNode(context.Background(), zlmedia.StreamKey{App: "live", Stream: "test"})
+					if !errors.Is(err, tt.wantErr) || nodeID != tt.wantNode {
+						t.Errorf("PickNode() = %q, %v, want %q, %v", nodeID, err, tt.wantNode, tt.wantErr)
+					}
+				}()
+			}
+			wg.Wait()
+
+			for i, server := range servers {
+				if got := server.RequestCount("/index/api/getThreadsLoad"); got != 1 {
+					t.Errorf("node%d getThreadsLoad请求次数 = %d, want 1", i, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// configEntry 配置项
+type configEntry struct {
+	Key   string `json:"key"`   // 配置项，格式为"section.key"
+	Value string `json:"value"` // 配置值
+}
+
+// configCommands 服务器配置命令
+var configCommands = map[string]*command{
+	"get": {
+		usage:   "[前缀...]",
+		summary: "获取服务器配置，可以按配置项前缀筛选，例如hls.或protocol.enable_",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "config", "get")
+			if err := parseFlags(fs, args, -1); err != nil {
+				return err
+			}
+
+			config, err := zlmedia.NewServerAPI(a.client).GetTypedServerConfig(ctx)
+			if config == nil {
+				return err
+			}
+			if err != nil {
+				// 解析失败的配置项以原始值输出
+				fmt.Fprintf(a.stderr, "警告: %v\n", err)
+			}
+			values := config.ToMap()
+
+			var entries []configEntry
+			for _, key := range sortedNames(values) {
+				if matchPrefix(key, fs.Args()) {
+					entries = append(entries, configEntry{Key: key, Value: values[key]})
+				}
+			}
+			return printList(a.out, entries, []column[configEntry]{
+				{"KEY", func(e configEntry) string { return e.Key }},
+				{"VALUE", func(e configEntry) string { return e.Value }},
+			})
+		},
+	},
+	"set": {
+		usage:   "<section.key=value>...",
+		summary: "修改服务器配置，只发送有变化的配置项，并报告哪些配置项已生效",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "config", "set")
+			if err := parseFlags(fs, args, -1); err != nil {
+				return err
+			}
+			if fs.NArg() == 0 {
+				fs.Usage()
+				return usageError{fmt.Errorf("需要至少一个section.key=value参数")}
+			}
+
+			changes := make(map[string]string, fs.NArg())
+			for _, arg := range fs.Args() {
+				key, value, ok := strings.Cut(arg, "=")
+				if !ok || !strings.Contains(key, ".") {
+					return usageError{fmt.Errorf("参数%q需要为section.key=value格式", arg)}
+				}
+				changes[key] = value
+			}
+			if _, err := zlmedia.ParseServerConfig(changes); err != nil {
+				return usageError{err}
+			}
+
+			// 服务器配置中解析失败的配置项以原始值保存在Extra中，不影响修改其它配置项
+			serverAPI := zlmedia.NewServerAPI(a.client)
+			current, err := serverAPI.GetTypedServerConfig(ctx)
+			if current == nil {
+				return err
+			}
+			values := current.ToMap()
+			for key, value := range changes {
+				values[key] = value
+			}
+			desired, _ := zlmedia.ParseServerConfig(values)
+
+			result, err := serverAPI.ApplyConfig(ctx, desired)
+			if err != nil {
+				return err
+			}
+			if err := printObject(a.out, result); err != nil {
+				return err
+			}
+			if len(result.Rejected) > 0 {
+				return fmt.Errorf("%d个配置项未生效", len(result.Rejected))
+			}
+			return nil
+		},
+	},
+}
+
+// matchPrefix 判断配置项是否匹配任意一个前缀，没有前缀时全部匹配
+func matchPrefix(key string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
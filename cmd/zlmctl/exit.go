@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// 退出码，ZLMediaKit的错误代码映射为3~7，便于在脚本中区分
+const (
+	exitOK          = 0 // 成功
+	exitFailed      = 1 // 其它错误，包括ZLMediaKit返回的-1
+	exitUsage       = 2 // 命令行用法或配置文件错误
+	exitAuthFailed  = 3 // ZLMediaKit返回-100，鉴权失败
+	exitInvalidArgs = 4 // ZLMediaKit返回-300，参数不合法
+	exitNotFound    = 5 // ZLMediaKit返回-500，资源不存在
+	exitException   = 6 // ZLMediaKit返回-400，代码抛异常
+	exitSqlFailed   = 7 // ZLMediaKit返回-200，sql执行失败
+	exitUnavailable = 8 // 无法连接ZLMediaKit或返回非2xx的HTTP状态码
+)
+
+// usageError 命令行用法错误
+type usageError struct {
+	err error
+}
+
+// Error 实现error接口
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap 支持errors.Is和errors.As
+func (e usageError) Unwrap() error {
+	return e.err
+}
+
+// exitCode 根据错误获取退出码
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var usageErr usageError
+	if errors.As(err, &usageErr) {
+		return exitUsage
+	}
+
+	var apiErr *zlmedia.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.HTTPStatus != 0 {
+			return exitUnavailable
+		}
+		switch apiErr.Code {
+		case zlmedia.CodeAuthFailed:
+			return exitAuthFailed
+		case zlmedia.CodeInvalidArgs:
+			return exitInvalidArgs
+		case zlmedia.CodeNotFound:
+			return exitNotFound
+		case zlmedia.CodeException:
+			return exitException
+		case zlmedia.CodeSqlFailed:
+			return exitSqlFailed
+		default:
+			return exitFailed
+		}
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return exitUnavailable
+	}
+	return exitFailed
+}
@@ -0,0 +1,274 @@
+// zlmctl ZLMediaKit命令行工具
+// 通过配置文件中的节点配置连接ZLMediaKit，避免在命令行中拼接带secret的url
+//
+// 用法:
+//
+//	zlmctl [全局选项] <模块> <命令> [选项] [参数]
+//
+// 示例:
+//
+//	zlmctl media ls --app live
+//	zlmctl -p prod proxy add --app camera --stream door --url rtsp://192.168.1.10/stream1
+//	zlmctl -o yaml config get hls.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// command 子命令
+type command struct {
+	usage   string                                                   // 参数说明
+	summary string                                                   // 命令说明
+	run     func(ctx context.Context, app *app, args []string) error // 执行命令，args为命令名之后的参数
+}
+
+// modules 所有模块及其子命令
+var modules map[string]map[string]*command
+
+func init() {
+	// 在init中注册，避免命令通过newFlagSet引用modules造成初始化循环
+	modules = map[string]map[string]*command{
+		"server":  serverCommands,
+		"media":   mediaCommands,
+		"proxy":   proxyCommands,
+		"rtp":     rtpCommands,
+		"record":  recordCommands,
+		"session": sessionCommands,
+		"config":  configCommands,
+	}
+}
+
+// app 命令执行环境
+type app struct {
+	client *zlmedia.Client
+	out    *output
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run 执行命令行，返回退出码
+func run(args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("zlmctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	configPath := global.String("c", defaultConfigPath(), "配置文件路径，也可以通过环境变量ZLMCTL_CONFIG设置")
+	profileName := global.String("p", os.Getenv("ZLMCTL_PROFILE"), "使用的节点配置，默认为配置文件中的default")
+	baseURL := global.String("url", "", "ZLMediaKit API的基础URL，覆盖节点配置")
+	format := global.String("o", "table", "输出格式: table、json或yaml")
+	timeout := global.Duration("timeout", 0, "请求超时时间，覆盖节点配置")
+	global.Usage = func() { printUsage(stderr, global) }
+
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	out, err := newOutput(stdout, *format)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	rest := global.Args()
+	if len(rest) == 0 {
+		printUsage(stderr, global)
+		return exitUsage
+	}
+	commands, ok := modules[rest[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "未知的模块: %s\n\n", rest[0])
+		printUsage(stderr, global)
+		return exitUsage
+	}
+	if len(rest) < 2 {
+		printModuleUsage(stderr, rest[0], commands)
+		return exitUsage
+	}
+	cmd, ok := commands[rest[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "未知的命令: %s %s\n\n", rest[0], rest[1])
+		printModuleUsage(stderr, rest[0], commands)
+		return exitUsage
+	}
+
+	profile, err := loadProfile(*configPath, *profileName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if *baseURL != "" {
+		profile.URL = *baseURL
+	}
+	if *timeout > 0 {
+		profile.Timeout = duration(*timeout)
+	}
+	config, err := profile.clientConfig()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	a := &app{client: zlmedia.NewClient(config), out: out, stderr: stderr}
+	if err := cmd.run(ctx, a, rest[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fmt.Fprintf(stderr, "%s %s: %v\n", rest[0], rest[1], err)
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+// printUsage 打印总体用法
+func printUsage(w io.Writer, global *flag.FlagSet) {
+	fmt.Fprintln(w, "用法: zlmctl [全局选项] <模块> <命令> [选项] [参数]")
+	fmt.Fprintln(w, "\n模块:")
+	for _, name := range sortedNames(modules) {
+		fmt.Fprintf(w, "  %-8s", name)
+		for _, cmd := range sortedNames(modules[name]) {
+			fmt.Fprintf(w, " %s", cmd)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "\n全局选项:")
+	global.PrintDefaults()
+}
+
+// printModuleUsage 打印模块的所有命令
+func printModuleUsage(w io.Writer, module string, commands map[string]*command) {
+	fmt.Fprintf(w, "用法: zlmctl %s <命令> [选项] [参数]\n\n命令:\n", module)
+	for _, name := range sortedNames(commands) {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+// newFlagSet 创建子命令的选项解析器
+func newFlagSet(a *app, module, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(module+" "+name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	cmd := modules[module][name]
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "用法: zlmctl %s %s %s\n%s\n", module, name, cmd.usage, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags 解析子命令的选项，并检查位置参数的个数，positional<0时不检查
+func parseFlags(fs *flag.FlagSet, args []string, positional int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err}
+	}
+	if positional >= 0 && fs.NArg() != positional {
+		fs.Usage()
+		return usageError{fmt.Errorf("需要%d个参数，实际为%d个", positional, fs.NArg())}
+	}
+	return nil
+}
+
+// requireFlags 检查必填的字符串选项
+func requireFlags(values map[string]string) error {
+	for _, name := range sortedNames(values) {
+		if values[name] == "" {
+			return usageError{fmt.Errorf("缺少选项--%s", name)}
+		}
+	}
+	return nil
+}
+
+// optionalBool 将未设置的布尔选项转换为nil
+type optionalBool struct {
+	value *bool
+}
+
+// String 实现flag.Value
+func (b *optionalBool) String() string {
+	if b.value == nil {
+		return ""
+	}
+	return fmt.Sprint(*b.value)
+}
+
+// Set 实现flag.Value
+func (b *optionalBool) Set(s string) error {
+	var v bool
+	if _, err := fmt.Sscan(s, &v); err != nil {
+		return fmt.Errorf("无效的布尔值%q", s)
+	}
+	b.value = &v
+	return nil
+}
+
+// IsBoolFlag 允许以--flag的形式设置为true
+func (b *optionalBool) IsBoolFlag() bool {
+	return true
+}
+
+// optionalInt 将未设置的整数选项转换为nil
+type optionalInt struct {
+	value *int
+}
+
+// String 实现flag.Value
+func (i *optionalInt) String() string {
+	if i.value == nil {
+		return ""
+	}
+	return fmt.Sprint(*i.value)
+}
+
+// Set 实现flag.Value
+func (i *optionalInt) Set(s string) error {
+	var v int
+	if _, err := fmt.Sscan(s, &v); err != nil {
+		return fmt.Errorf("无效的整数%q", s)
+	}
+	i.value = &v
+	return nil
+}
+
+// sortedNames 获取map的key并排序
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatTime 格式化unix时间戳
+func formatTime(stamp int64) string {
+	if stamp <= 0 {
+		return "-"
+	}
+	return time.Unix(stamp, 0).Format("2006-01-02 15:04:05")
+}
+
+// addStreamFlags 添加--vhost、--app和--stream选项
+func addStreamFlags(fs *flag.FlagSet, key *zlmedia.StreamKey) {
+	fs.StringVar(&key.VHost, "vhost", zlmedia.DefaultVHost, "虚拟主机")
+	fs.StringVar(&key.App, "app", "", "应用名，例如live")
+	fs.StringVar(&key.Stream, "stream", "", "流id，例如test")
+}
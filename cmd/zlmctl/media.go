@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// mediaCommands 流媒体管理命令
+var mediaCommands = map[string]*command{
+	"ls": {
+		usage:   "[--schema rtmp] [--vhost __defaultVhost__] [--app live] [--stream test]",
+		summary: "获取流列表",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "media", "ls")
+			req := &zlmedia.GetMediaListRequest{}
+			fs.StringVar(&req.Schema, "schema", "", "筛选协议，例如rtsp或rtmp")
+			fs.StringVar(&req.VHost, "vhost", "", "筛选虚拟主机")
+			fs.StringVar(&req.App, "app", "", "筛选应用名")
+			fs.StringVar(&req.Stream, "stream", "", "筛选流id")
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+
+			resp, err := zlmedia.NewMediaAPI(a.client).GetMediaList(ctx, req)
+			if err != nil {
+				return err
+			}
+			return printList(a.out, resp.Data, []column[zlmedia.MediaInfo]{
+				{"SCHEMA", func(m zlmedia.MediaInfo) string { return m.Schema }},
+				{"VHOST", func(m zlmedia.MediaInfo) string { return m.VHost }},
+				{"APP", func(m zlmedia.MediaInfo) string { return m.App }},
+				{"STREAM", func(m zlmedia.MediaInfo) string { return m.Stream }},
+				{"ORIGIN", func(m zlmedia.MediaInfo) string { return m.OriginTypeStr }},
+				{"READERS", func(m zlmedia.MediaInfo) string { return strconv.Itoa(m.ReaderCount) }},
+				{"TOTAL_READERS", func(m zlmedia.MediaInfo) string { return strconv.Itoa(m.TotalReaderCount) }},
+				{"BYTES_SPEED", func(m zlmedia.MediaInfo) string { return strconv.FormatInt(m.BytesSpeed, 10) }},
+				{"ALIVE_SECONDS", func(m zlmedia.MediaInfo) string { return strconv.FormatInt(m.AliveSecond, 10) }},
+				{"CREATED", func(m zlmedia.MediaInfo) string { return formatTime(m.CreateStamp) }},
+			})
+		},
+	},
+	"close": {
+		usage:   "--app live [--stream test] [--schema rtmp] [--vhost __defaultVhost__] [--force]",
+		summary: "关闭符合条件的流，至少需要指定应用名",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "media", "close")
+			req := &zlmedia.CloseStreamsRequest{}
+			force := &optionalBool{}
+			fs.StringVar(&req.Schema, "schema", "", "协议，为空时关闭所有协议")
+			fs.StringVar(&req.VHost, "vhost", zlmedia.DefaultVHost, "虚拟主机")
+			fs.StringVar(&req.App, "app", "", "应用名")
+			fs.StringVar(&req.Stream, "stream", "", "流id，为空时关闭应用下的所有流")
+			fs.Var(force, "force", "有人观看时是否也关闭")
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+			if err := requireFlags(map[string]string{"app": req.App}); err != nil {
+				return err
+			}
+			req.Force = force.value
+
+			resp, err := zlmedia.NewMediaAPI(a.client).CloseStreams(ctx, req)
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp)
+		},
+	},
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 输出格式
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// output 按输出格式打印命令结果
+type output struct {
+	w      io.Writer
+	format string
+}
+
+// newOutput 创建输出
+func newOutput(w io.Writer, format string) (*output, error) {
+	switch format {
+	case formatTable, formatJSON, formatYAML:
+		return &output{w: w, format: format}, nil
+	default:
+		return nil, fmt.Errorf("不支持的输出格式%s，可选table、json或yaml", format)
+	}
+}
+
+// column 表格的列
+type column[T any] struct {
+	header string
+	value  func(item T) string
+}
+
+// printList 打印列表，表格格式按列输出，json和yaml格式输出完整的列表
+func printList[T any](o *output, items []T, columns []column[T]) error {
+	if o.format != formatTable {
+		if items == nil {
+			items = []T{}
+		}
+		return o.encode(items)
+	}
+
+	tw := tabwriter.NewWriter(o.w, 0, 4, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, item := range items {
+		values := make([]string, len(columns))
+		for i, c := range columns {
+			values[i] = c.value(item)
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+// printObject 打印单个结果，表格格式按字段输出为两列
+func printObject(o *output, v interface{}) error {
+	if o.format != formatTable {
+		return o.encode(v)
+	}
+
+	generic, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	fields, ok := generic.(map[string]interface{})
+	if !ok {
+		_, err := fmt.Fprintln(o.w, formatCell(generic))
+		return err
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tw := tabwriter.NewWriter(o.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tVALUE")
+	for _, key := range keys {
+		fmt.Fprintf(tw, "%s\t%s\n", key, formatCell(fields[key]))
+	}
+	return tw.Flush()
+}
+
+// encode 以json或yaml格式输出
+func (o *output) encode(v interface{}) error {
+	if o.format == formatYAML {
+		return o.encodeYAML(v)
+	}
+	encoder := json.NewEncoder(o.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// encodeYAML 以yaml格式输出
+// 值先经过JSON编码再解码为通用结构，因此字段名与JSON输出一致，对象的key按名称排序
+func (o *output) encodeYAML(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("序列化输出失败: %w", err)
+	}
+	var generic interface{}
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return fmt.Errorf("序列化输出失败: %w", err)
+	}
+
+	encoder := yaml.NewEncoder(o.w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(generic); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// toGeneric 将值转换为由map、slice和标量组成的通用结构，数字保留为json.Number
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("序列化输出失败: %w", err)
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, fmt.Errorf("序列化输出失败: %w", err)
+	}
+	return generic, nil
+}
+
+// formatCell 格式化表格单元格，对象和数组输出为紧凑的JSON
+func formatCell(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		if value == "" {
+			return "-"
+		}
+		return value
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(value)
+		return string(data)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOutputEncode(t *testing.T) {
+	type media struct {
+		App     string            `json:"app"`
+		Stream  string            `json:"stream"`
+		Readers int64             `json:"readers"`
+		Online  bool              `json:"online"`
+		Tracks  []string          `json:"tracks"`
+		Params  map[string]string `json:"params,omitempty"`
+	}
+	value := media{App: "live", Stream: "0123", Readers: 9007199254740993, Online: true, Tracks: []string{"H264", "yes: no"}}
+
+	tests := []struct {
+		name   string
+		format string
+		value  interface{}
+		want   string
+	}{
+		{"json", formatJSON, value, "{\n  \"app\": \"live\",\n  \"stream\": \"0123\",\n  \"readers\": 9007199254740993,\n  \"online\": true,\n  \"tracks\": [\n    \"H264\",\n    \"yes: no\"\n  ]\n}\n"},
+		{"yaml", formatYAML, value, "app: live\nonline: true\nreaders: 9007199254740993\nstream: \"0123\"\ntracks:\n  - H264\n  - 'yes: no'\n"},
+		{"yaml空列表", formatYAML, []media{}, "[]\n"},
+		{"yaml标量", formatYAML, "on", "\"on\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			o, err := newOutput(&buf, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.encode(tt.value); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("encode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
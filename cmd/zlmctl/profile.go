@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// configFile 配置文件，JSON格式
+//
+//	{
+//	  "default": "local",
+//	  "profiles": {
+//	    "local": {"url": "http://127.0.0.1:80", "secret_env": "ZLM_SECRET"},
+//	    "prod":  {"url": "http://10.0.0.2:80", "secret": "035c73f7-bb6b-4889-a715-d9eb2d1925cc", "timeout": "30s"}
+//	  }
+//	}
+type configFile struct {
+	Default  string              `json:"default"`  // 未指定节点配置时使用的配置名
+	Profiles map[string]*profile `json:"profiles"` // 节点配置
+}
+
+// profile 节点配置
+type profile struct {
+	URL       string   `json:"url"`                  // ZLMediaKit API的基础URL
+	Secret    string   `json:"secret,omitempty"`     // API操作密钥
+	SecretEnv string   `json:"secret_env,omitempty"` // 从该环境变量读取API操作密钥，优先于Secret
+	Timeout   duration `json:"timeout,omitempty"`    // 请求超时时间，例如10s
+}
+
+// duration 支持在JSON中以"10s"格式表示的时间
+type duration time.Duration
+
+// UnmarshalJSON 实现json.Unmarshaler
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("时间需要为字符串，例如\"10s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// defaultConfigPath 默认的配置文件路径，优先使用环境变量ZLMCTL_CONFIG
+func defaultConfigPath() string {
+	if path := os.Getenv("ZLMCTL_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "zlmctl", "config.json")
+}
+
+// loadProfile 从配置文件中读取节点配置
+// 配置文件不存在且未指定节点配置时返回空配置，由--url和环境变量ZLM_SECRET补充
+func loadProfile(path, name string) (*profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && name == "" {
+			return &profile{}, nil
+		}
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	var file configFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析配置文件%s失败: %w", path, err)
+	}
+	if len(file.Profiles) == 0 {
+		return nil, fmt.Errorf("配置文件%s中没有节点配置，需要在profiles中添加", path)
+	}
+
+	if name == "" {
+		name = file.Default
+	}
+	if name == "" {
+		if len(file.Profiles) != 1 {
+			return nil, fmt.Errorf("配置文件%s中有多个节点配置，需要通过-p或default指定", path)
+		}
+		for only := range file.Profiles {
+			name = only
+		}
+	}
+
+	p, ok := file.Profiles[name]
+	if !ok || p == nil {
+		return nil, fmt.Errorf("配置文件%s中不存在节点配置%s", path, name)
+	}
+	return p, nil
+}
+
+// clientConfig 将节点配置转换为客户端配置
+// 节点配置中没有密钥时使用环境变量ZLM_SECRET
+func (p *profile) clientConfig() (zlmedia.Config, error) {
+	if p.URL == "" {
+		return zlmedia.Config{}, fmt.Errorf("未指定ZLMediaKit地址，请在配置文件中设置或使用--url")
+	}
+
+	secret := p.Secret
+	if p.SecretEnv != "" {
+		secret = os.Getenv(p.SecretEnv)
+	}
+	if secret == "" {
+		secret = os.Getenv("ZLM_SECRET")
+	}
+
+	return zlmedia.Config{
+		BaseURL: p.URL,
+		Secret:  secret,
+		Timeout: time.Duration(p.Timeout),
+	}, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string // 为空代表配置文件不存在
+		profile string
+		wantURL string
+		wantErr string
+	}{
+		{"配置文件不存在", "", "", "", ""},
+		{"配置文件不存在且指定了节点配置", "", "prod", "", "读取配置文件失败"},
+		{"没有节点配置", `{}`, "", "", "没有节点配置"},
+		{"节点配置为空", `{"default":"local","profiles":{}}`, "", "", "没有节点配置"},
+		{"只有一个节点配置", `{"profiles":{"local":{"url":"http://a"}}}`, "", "http://a", ""},
+		{"使用默认节点配置", `{"default":"prod","profiles":{"local":{"url":"http://a"},"prod":{"url":"http://b"}}}`, "", "http://b", ""},
+		{"指定节点配置", `{"default":"prod","profiles":{"local":{"url":"http://a"},"prod":{"url":"http://b"}}}`, "local", "http://a", ""},
+		{"多个节点配置未指定", `{"profiles":{"local":{"url":"http://a"},"prod":{"url":"http://b"}}}`, "", "", "有多个节点配置"},
+		{"节点配置不存在", `{"profiles":{"local":{"url":"http://a"}}}`, "prod", "", "不存在节点配置prod"},
+		{"超时格式错误", `{"profiles":{"local":{"url":"http://a","timeout":10}}}`, "", "", "解析配置文件"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if tt.config != "" {
+				if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			p, err := loadProfile(path, tt.profile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadProfile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.URL != tt.wantURL {
+				t.Errorf("URL = %q, want %q", p.URL, tt.wantURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// proxyCommands 代理管理命令
+var proxyCommands = map[string]*command{
+	"ls": {
+		usage:   "",
+		summary: "获取拉流代理列表",
+		run: func(ctx context.Context, a *app, args []string) error {
+			if err := parseFlags(newFlagSet(a, "proxy", "ls"), args, 0); err != nil {
+				return err
+			}
+			resp, err := zlmedia.NewProxyAPI(a.client).ListStreamProxy(ctx, &zlmedia.ListStreamProxyRequest{})
+			if err != nil {
+				return err
+			}
+			return printList(a.out, resp.Data, []column[zlmedia.ProxyInfo]{
+				{"KEY", func(p zlmedia.ProxyInfo) string { return p.Key }},
+				{"URL", func(p zlmedia.ProxyInfo) string { return p.URL }},
+				{"STATUS", func(p zlmedia.ProxyInfo) string { return strconv.Itoa(p.Status) }},
+				{"LIVE_SECONDS", func(p zlmedia.ProxyInfo) string { return strconv.FormatInt(p.LiveSecs, 10) }},
+				{"REPULL", func(p zlmedia.ProxyInfo) string { return strconv.Itoa(p.RePullCount) }},
+				{"READERS", func(p zlmedia.ProxyInfo) string { return strconv.Itoa(p.TotalReaderCount) }},
+			})
+		},
+	},
+	"add": {
+		usage:   "--app live --stream test --url rtsp://... [--vhost __defaultVhost__] [--rtp-type 0] [--retry 0] [--hls] [--mp4] ...",
+		summary: "添加拉流代理",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "proxy", "add")
+			req := &zlmedia.AddStreamProxyRequest{}
+			key := zlmedia.StreamKey{}
+			addStreamFlags(fs, &key)
+			fs.StringVar(&req.URL, "url", "", "拉流地址")
+			rtpType, retry := &optionalInt{}, &optionalInt{}
+			fs.Var(rtpType, "rtp-type", "rtsp拉流方式，0：tcp，1：udp，2：组播")
+			fs.Var(retry, "retry", "拉流重试次数，<=0时无限重试")
+			timeout := fs.Float64("timeout-sec", 0, "拉流超时时间，单位秒")
+			hls, mp4, rtsp, rtmp := &optionalBool{}, &optionalBool{}, &optionalBool{}, &optionalBool{}
+			ts, fmp4, audio, autoClose := &optionalBool{}, &optionalBool{}, &optionalBool{}, &optionalBool{}
+			fs.Var(hls, "hls", "是否转hls-ts")
+			fs.Var(mp4, "mp4", "是否mp4录制")
+			fs.Var(rtsp, "rtsp", "是否转协议为rtsp/webrtc")
+			fs.Var(rtmp, "rtmp", "是否转协议为rtmp/flv")
+			fs.Var(ts, "ts", "是否转协议为http-ts/ws-ts")
+			fs.Var(fmp4, "fmp4", "是否转协议为http-fmp4/ws-fmp4")
+			fs.Var(audio, "audio", "转协议是否开启音频")
+			fs.Var(autoClose, "auto-close", "无人观看时是否直接关闭")
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+			if err := requireFlags(map[string]string{"app": key.App, "stream": key.Stream, "url": req.URL}); err != nil {
+				return err
+			}
+
+			req.VHost, req.App, req.Stream = key.VHost, key.App, key.Stream
+			req.RtpType, req.RetryCount = rtpType.value, retry.value
+			if *timeout > 0 {
+				req.TimeoutSec = timeout
+			}
+			req.EnableHLS, req.EnableMp4, req.EnableRtsp, req.EnableRtmp = hls.value, mp4.value, rtsp.value, rtmp.value
+			req.EnableTS, req.EnableFmp4, req.EnableAudio, req.AutoClose = ts.value, fmp4.value, audio.value, autoClose.value
+
+			resp, err := zlmedia.NewProxyAPI(a.client).AddStreamProxy(ctx, req)
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp.Data)
+		},
+	},
+	"del": {
+		usage:   "<key>",
+		summary: "关闭拉流代理",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "proxy", "del")
+			if err := parseFlags(fs, args, 1); err != nil {
+				return err
+			}
+			resp, err := zlmedia.NewProxyAPI(a.client).DelStreamProxy(ctx, &zlmedia.DelStreamProxyRequest{Key: fs.Arg(0)})
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp.Data)
+		},
+	},
+	"push-ls": {
+		usage:   "",
+		summary: "获取推流代理列表",
+		run: func(ctx context.Context, a *app, args []string) error {
+			if err := parseFlags(newFlagSet(a, "proxy", "push-ls"), args, 0); err != nil {
+				return err
+			}
+			resp, err := zlmedia.NewProxyAPI(a.client).ListStreamPusherProxy(ctx, &zlmedia.ListStreamPusherProxyRequest{})
+			if err != nil {
+				return err
+			}
+			return printList(a.out, resp.Data, []column[zlmedia.PusherProxyInfo]{
+				{"KEY", func(p zlmedia.PusherProxyInfo) string { return p.Key }},
+				{"URL", func(p zlmedia.PusherProxyInfo) string { return p.URL }},
+				{"STATUS", func(p zlmedia.PusherProxyInfo) string { return strconv.Itoa(p.Status) }},
+				{"LIVE_SECONDS", func(p zlmedia.PusherProxyInfo) string { return strconv.FormatInt(p.LiveSecs, 10) }},
+				{"REPUBLISH", func(p zlmedia.PusherProxyInfo) string { return strconv.Itoa(p.RePublishCount) }},
+			})
+		},
+	},
+	"push-add": {
+		usage:   "--schema rtmp --app live --stream test --dst rtmp://... [--vhost __defaultVhost__] [--rtp-type 0] [--retry 0]",
+		summary: "添加推流代理，将已注册的流推送到指定地址",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "proxy", "push-add")
+			req := &zlmedia.AddStreamPusherProxyRequest{}
+			key := zlmedia.StreamKey{}
+			addStreamFlags(fs, &key)
+			fs.StringVar(&req.Schema, "schema", "", "推流协议，支持rtsp、rtmp")
+			fs.StringVar(&req.DstURL, "dst", "", "推流地址，需要与schema协议一致")
+			rtpType, retry := &optionalInt{}, &optionalInt{}
+			fs.Var(rtpType, "rtp-type", "rtsp推流方式，0：tcp，1：udp")
+			fs.Var(retry, "retry", "推流重试次数，<=0时无限重试")
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+			if err := requireFlags(map[string]string{"schema": req.Schema, "app": key.App, "stream": key.Stream, "dst": req.DstURL}); err != nil {
+				return err
+			}
+
+			req.VHost, req.App, req.Stream = key.VHost, key.App, key.Stream
+			req.RtpType, req.RetryCount = rtpType.value, retry.value
+
+			resp, err := zlmedia.NewProxyAPI(a.client).AddStreamPusherProxy(ctx, req)
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp.Data)
+		},
+	},
+}
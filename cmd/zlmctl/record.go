@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// recordType 录制类型选项，hls为0，mp4为1
+type recordType int
+
+// String 实现flag.Value
+func (t *recordType) String() string {
+	if *t == 0 {
+		return "hls"
+	}
+	return "mp4"
+}
+
+// Set 实现flag.Value
+func (t *recordType) Set(s string) error {
+	switch s {
+	case "hls", "0":
+		*t = 0
+	case "mp4", "1":
+		*t = 1
+	default:
+		return fmt.Errorf("录制类型需要为hls或mp4")
+	}
+	return nil
+}
+
+// addRecordFlags 添加录制命令共用的--type、--vhost、--app和--stream选项
+func addRecordFlags(fs *flag.FlagSet, key *zlmedia.StreamKey) *recordType {
+	typ := recordType(1)
+	fs.Var(&typ, "type", "录制类型，hls或mp4")
+	addStreamFlags(fs, key)
+	return &typ
+}
+
+// recordCommands 录制管理命令
+var recordCommands = map[string]*command{
+	"start": {
+		usage:   "--app live --stream test [--type mp4] [--vhost __defaultVhost__] [--path /data/record] [--max-second 3600]",
+		summary: "开始录制",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "record", "start")
+			key := zlmedia.StreamKey{}
+			typ := addRecordFlags(fs, &key)
+			customizedPath := fs.String("path", "", "录制文件保存根目录，为空时使用默认目录")
+			maxSecond := &optionalInt{}
+			fs.Var(maxSecond, "max-second", "mp4录制切片大小，单位秒")
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+			if err := requireFlags(map[string]string{"app": key.App, "stream": key.Stream}); err != nil {
+				return err
+			}
+
+			resp, err := zlmedia.NewRecordAPI(a.client).StartRecord(ctx, &zlmedia.StartRecordRequest{
+				Type:           int(*typ),
+				VHost:          key.VHost,
+				App:            key.App,
+				Stream:         key.Stream,
+				CustomizedPath: *customizedPath,
+				MaxSecond:      maxSecond.value,
+			})
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp)
+		},
+	},
+	"stop": {
+		usage:   "--app live --stream test [--type mp4] [--vhost __defaultVhost__]",
+		summary: "停止录制",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "record", "stop")
+			key := zlmedia.StreamKey{}
+			typ := addRecordFlags(fs, &key)
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+			if err := requireFlags(map[string]string{"app": key.App, "stream": key.Stream}); err != nil {
+				return err
+			}
+
+			resp, err := zlmedia.NewRecordAPI(a.client).StopRecord(ctx, &zlmedia.StopRecordRequest{
+				Type:   int(*typ),
+				VHost:  key.VHost,
+				App:    key.App,
+				Stream: key.Stream,
+			})
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp)
+		},
+	},
+	"status": {
+		usage:   "--app live --stream test [--type mp4] [--vhost __defaultVhost__]",
+		summary: "判断是否正在录制",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "record", "status")
+			key := zlmedia.StreamKey{}
+			typ := addRecordFlags(fs, &key)
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+			if err := requireFlags(map[string]string{"app": key.App, "stream": key.Stream}); err != nil {
+				return err
+			}
+
+			resp, err := zlmedia.NewRecordAPI(a.client).IsRecording(ctx, &zlmedia.IsRecordingRequest{
+				Type:   int(*typ),
+				VHost:  key.VHost,
+				App:    key.App,
+				Stream: key.Stream,
+			})
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp)
+		},
+	},
+	"files": {
+		usage:   "--app live --stream test [--period 2020-02-01] [--vhost __defaultVhost__]",
+		summary: "获取录制日期列表，指定日期时获取该日期的mp4文件列表",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "record", "files")
+			key := zlmedia.StreamKey{}
+			addStreamFlags(fs, &key)
+			period := fs.String("period", "", "录制日期，格式为2020-02-01")
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+			if err := requireFlags(map[string]string{"app": key.App, "stream": key.Stream}); err != nil {
+				return err
+			}
+
+			resp, err := zlmedia.NewRecordAPI(a.client).GetMp4RecordFile(ctx, &zlmedia.GetMp4RecordFileRequest{
+				VHost:  key.VHost,
+				App:    key.App,
+				Stream: key.Stream,
+				Period: *period,
+			})
+			if err != nil {
+				return err
+			}
+			if a.out.format != formatTable {
+				return printObject(a.out, resp.Data)
+			}
+			return printList(a.out, resp.Data.Paths, []column[string]{
+				{"PATH", func(p string) string { return path.Join(resp.Data.RootPath, p) }},
+			})
+		},
+	},
+	"rm": {
+		usage:   "--app live --stream test --period 2020-02-01 [--vhost __defaultVhost__]",
+		summary: "删除录制文件夹，指定完整日期时删除该日期的录制文件",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "record", "rm")
+			key := zlmedia.StreamKey{}
+			addStreamFlags(fs, &key)
+			period := fs.String("period", "", "录制日期，格式为2020-02-01")
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+			if err := requireFlags(map[string]string{"app": key.App, "stream": key.Stream, "period": *period}); err != nil {
+				return err
+			}
+
+			resp, err := zlmedia.NewRecordAPI(a.client).DeleteRecordDirectory(ctx, &zlmedia.DeleteRecordDirectoryRequest{
+				VHost:  key.VHost,
+				App:    key.App,
+				Stream: key.Stream,
+				Period: *period,
+			})
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp)
+		},
+	},
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// rtpCommands RTP管理命令
+var rtpCommands = map[string]*command{
+	"ls": {
+		usage:   "",
+		summary: "获取RTP接收端口列表",
+		run: func(ctx context.Context, a *app, args []string) error {
+			if err := parseFlags(newFlagSet(a, "rtp", "ls"), args, 0); err != nil {
+				return err
+			}
+			resp, err := zlmedia.NewRTPAPI(a.client).ListRtpServer(ctx, &zlmedia.ListRtpServerRequest{})
+			if err != nil {
+				return err
+			}
+			return printList(a.out, resp.Data, []column[zlmedia.RtpServerInfo]{
+				{"STREAM_ID", func(r zlmedia.RtpServerInfo) string { return r.StreamID }},
+				{"PORT", func(r zlmedia.RtpServerInfo) string { return strconv.Itoa(r.Port) }},
+			})
+		},
+	},
+	"open": {
+		usage:   "--stream-id 34020000001320000001 [--port 0] [--tcp 0] [--reuse-port 1] [--ssrc-filter 0]",
+		summary: "创建GB28181 RTP接收端口",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "rtp", "open")
+			req := &zlmedia.OpenRtpServerRequest{}
+			fs.StringVar(&req.StreamID, "stream-id", "", "该端口绑定的流id")
+			fs.IntVar(&req.Port, "port", 0, "接收端口，0则为随机端口")
+			tcp, reusePort, ssrcFilter := &optionalInt{}, &optionalInt{}, &optionalInt{}
+			fs.Var(tcp, "tcp", "tcp模式，0为不开启，1为被动模式，2为主动模式")
+			fs.Var(reusePort, "reuse-port", "是否重用端口，1为重用，0为不重用")
+			fs.Var(ssrcFilter, "ssrc-filter", "是否开启ssrc过滤，1为开启，0为关闭")
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+			if err := requireFlags(map[string]string{"stream-id": req.StreamID}); err != nil {
+				return err
+			}
+			req.EnableTcp, req.ReUsePort, req.SsrcFilter = tcp.value, reusePort.value, ssrcFilter.value
+
+			resp, err := zlmedia.NewRTPAPI(a.client).OpenRtpServer(ctx, req)
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp)
+		},
+	},
+	"close": {
+		usage:   "<stream_id>",
+		summary: "关闭GB28181 RTP接收端口",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "rtp", "close")
+			if err := parseFlags(fs, args, 1); err != nil {
+				return err
+			}
+			resp, err := zlmedia.NewRTPAPI(a.client).CloseRtpServer(ctx, &zlmedia.CloseRtpServerRequest{StreamID: fs.Arg(0)})
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp)
+		},
+	},
+	"info": {
+		usage:   "<stream_id>",
+		summary: "获取RTP推流信息",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "rtp", "info")
+			if err := parseFlags(fs, args, 1); err != nil {
+				return err
+			}
+			resp, err := zlmedia.NewRTPAPI(a.client).GetRtpInfo(ctx, &zlmedia.GetRtpInfoRequest{StreamID: fs.Arg(0)})
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp)
+		},
+	},
+	"send": {
+		usage:   "--app live --stream test --ssrc 1 --dst-url 10.0.0.2 --dst-port 10000 [--vhost __defaultVhost__] [--udp 1] [--src-port 0]",
+		summary: "开始GB28181 ps-rtp推流",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "rtp", "send")
+			req := &zlmedia.StartSendRtpRequest{}
+			key := zlmedia.StreamKey{}
+			addStreamFlags(fs, &key)
+			fs.StringVar(&req.Ssrc, "ssrc", "", "rtp推流的ssrc")
+			fs.StringVar(&req.DstURL, "dst-url", "", "目标ip或域名")
+			fs.IntVar(&req.DstPort, "dst-port", 0, "目标端口")
+			udp, srcPort := &optionalInt{}, &optionalInt{}
+			fs.Var(udp, "udp", "是否为udp模式，1为udp，0为tcp")
+			fs.Var(srcPort, "src-port", "使用的本地端口，0则为随机端口")
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+			if err := requireFlags(map[string]string{"app": key.App, "stream": key.Stream, "ssrc": req.Ssrc, "dst-url": req.DstURL}); err != nil {
+				return err
+			}
+			req.VHost, req.App, req.Stream = key.VHost, key.App, key.Stream
+			req.IsUdp, req.SrcPort = udp.value, srcPort.value
+
+			resp, err := zlmedia.NewRTPAPI(a.client).StartSendRtp(ctx, req)
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp)
+		},
+	},
+	"stop-send": {
+		usage:   "--app live --stream test --ssrc 1 [--vhost __defaultVhost__]",
+		summary: "停止GB28181 ps-rtp推流",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "rtp", "stop-send")
+			req := &zlmedia.StopSendRtpRequest{}
+			key := zlmedia.StreamKey{}
+			addStreamFlags(fs, &key)
+			fs.StringVar(&req.Ssrc, "ssrc", "", "rtp推流的ssrc")
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+			if err := requireFlags(map[string]string{"app": key.App, "stream": key.Stream, "ssrc": req.Ssrc}); err != nil {
+				return err
+			}
+			req.VHost, req.App, req.Stream = key.VHost, key.App, key.Stream
+
+			resp, err := zlmedia.NewRTPAPI(a.client).StopSendRtp(ctx, req)
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp)
+		},
+	},
+}
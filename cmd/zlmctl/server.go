@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"strconv"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// threadLoad 带线程序号的线程负载
+type threadLoad struct {
+	Thread int `json:"thread"` // 线程序号
+	zlmedia.ThreadLoad
+}
+
+// serverCommands 服务器管理命令
+var serverCommands = map[string]*command{
+	"apis": {
+		usage:   "",
+		summary: "获取API列表",
+		run: func(ctx context.Context, a *app, args []string) error {
+			if err := parseFlags(newFlagSet(a, "server", "apis"), args, 0); err != nil {
+				return err
+			}
+			resp, err := zlmedia.NewServerAPI(a.client).GetApiList(ctx, &zlmedia.GetApiListRequest{})
+			if err != nil {
+				return err
+			}
+			return printList(a.out, resp.Data, []column[string]{
+				{"API", func(api string) string { return api }},
+			})
+		},
+	},
+	"stats": {
+		usage:   "",
+		summary: "获取主要对象个数",
+		run: func(ctx context.Context, a *app, args []string) error {
+			if err := parseFlags(newFlagSet(a, "server", "stats"), args, 0); err != nil {
+				return err
+			}
+			resp, err := zlmedia.NewServerAPI(a.client).GetStatistic(ctx, &zlmedia.GetStatisticRequest{})
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp.Data)
+		},
+	},
+	"threads": {
+		usage:   "[--work]",
+		summary: "获取网络线程或后台线程负载",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "server", "threads")
+			work := fs.Bool("work", false, "获取后台线程负载")
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+
+			serverAPI := zlmedia.NewServerAPI(a.client)
+			var resp *zlmedia.Response[[]zlmedia.ThreadLoad]
+			var err error
+			if *work {
+				resp, err = serverAPI.GetWorkThreadsLoad(ctx, &zlmedia.GetWorkThreadsLoadRequest{})
+			} else {
+				resp, err = serverAPI.GetThreadsLoad(ctx, &zlmedia.GetThreadsLoadRequest{})
+			}
+			if err != nil {
+				return err
+			}
+
+			threads := make([]threadLoad, len(resp.Data))
+			for i, load := range resp.Data {
+				threads[i] = threadLoad{Thread: i, ThreadLoad: load}
+			}
+			return printList(a.out, threads, []column[threadLoad]{
+				{"THREAD", func(t threadLoad) string { return strconv.Itoa(t.Thread) }},
+				{"LOAD", func(t threadLoad) string { return strconv.Itoa(t.Load) }},
+				{"DELAY_MS", func(t threadLoad) string { return strconv.Itoa(t.Delay) }},
+			})
+		},
+	},
+	"restart": {
+		usage:   "",
+		summary: "重启服务器，会中断所有正在进行的流",
+		run: func(ctx context.Context, a *app, args []string) error {
+			if err := parseFlags(newFlagSet(a, "server", "restart"), args, 0); err != nil {
+				return err
+			}
+			resp, err := zlmedia.NewServerAPI(a.client).RestartServer(ctx, &zlmedia.RestartServerRequest{})
+			if err != nil {
+				return err
+			}
+			return printObject(a.out, resp)
+		},
+	},
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// sessionCommands 会话管理命令
+var sessionCommands = map[string]*command{
+	"ls": {
+		usage:   "[--local-port 554] [--peer-ip 10.0.0.3]",
+		summary: "获取tcp会话列表",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "session", "ls")
+			req := &zlmedia.GetAllSessionRequest{}
+			localPort := &optionalInt{}
+			fs.Var(localPort, "local-port", "筛选本机端口，例如rtsp的554")
+			fs.StringVar(&req.PeerIP, "peer-ip", "", "筛选客户端ip")
+			if err := parseFlags(fs, args, 0); err != nil {
+				return err
+			}
+			req.LocalPort = localPort.value
+
+			resp, err := zlmedia.NewSessionAPI(a.client).GetAllSession(ctx, req)
+			if err != nil {
+				return err
+			}
+			return printList(a.out, resp.Data, []column[zlmedia.SessionInfo]{
+				{"ID", func(s zlmedia.SessionInfo) string { return s.ID }},
+				{"LOCAL", func(s zlmedia.SessionInfo) string { return s.LocalIP + ":" + strconv.Itoa(s.LocalPort) }},
+				{"PEER", func(s zlmedia.SessionInfo) string { return s.PeerIP + ":" + strconv.Itoa(s.PeerPort) }},
+				{"TYPE", func(s zlmedia.SessionInfo) string { return s.TypeID }},
+			})
+		},
+	},
+	"kick": {
+		usage:   "<id> | --local-port 554 | --peer-ip 10.0.0.3",
+		summary: "断开指定id的tcp会话，或批量断开符合条件的tcp会话",
+		run: func(ctx context.Context, a *app, args []string) error {
+			fs := newFlagSet(a, "session", "kick")
+			localPort := &optionalInt{}
+			fs.Var(localPort, "local-port", "批量断开本机端口上的会话")
+			peerIP := fs.String("peer-ip", "", "批量断开客户端ip的会话")
+			if err := parseFlags(fs, args, -1); err != nil {
+				return err
+			}
+
+			sessionAPI := zlmedia.NewSessionAPI(a.client)
+			batch := localPort.value != nil || *peerIP != ""
+			switch {
+			case batch && fs.NArg() == 0:
+				resp, err := sessionAPI.KickSessions(ctx, &zlmedia.KickSessionsRequest{LocalPort: localPort.value, PeerIP: *peerIP})
+				if err != nil {
+					return err
+				}
+				return printObject(a.out, resp)
+			case !batch && fs.NArg() == 1:
+				resp, err := sessionAPI.KickSession(ctx, &zlmedia.KickSessionRequest{ID: fs.Arg(0)})
+				if err != nil {
+					return err
+				}
+				return printObject(a.out, resp)
+			default:
+				fs.Usage()
+				return usageError{fmt.Errorf("需要指定会话id，或者--local-port、--peer-ip中的至少一个")}
+			}
+		},
+	},
+}
@@ -0,0 +1,459 @@
+package zlmedia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ServerConfig ZLMediaKit服务器配置，按config.ini的section分组
+// 字段通过ini标签与"section.key"格式的配置项对应，布尔值以"1"/"0"表示；
+// 没有对应字段的配置项保存在Extra中，修改配置时应基于GetTypedServerConfig的结果修改，避免零值覆盖原有配置；
+// 服务器没有返回的配置项在ToMap中省略，除非对应字段被修改为非零值
+type ServerConfig struct {
+	API      APIConfig         `ini:"api"`
+	General  GeneralConfig     `ini:"general"`
+	HLS      HLSConfig         `ini:"hls"`
+	Hook     HookConfig        `ini:"hook"`
+	HTTP     HTTPConfig        `ini:"http"`
+	Protocol ProtocolConfig    `ini:"protocol"`
+	Record   RecordConfig      `ini:"record"`
+	RTC      RTCConfig         `ini:"rtc"`
+	RTMP     RTMPConfig        `ini:"rtmp"`
+	RTP      RTPConfig         `ini:"rtp"`
+	RTPProxy RTPProxyConfig    `ini:"rtp_proxy"`
+	RTSP     RTSPConfig        `ini:"rtsp"`
+	Shell    ShellConfig       `ini:"shell"`
+	SRT      SRTConfig         `ini:"srt"`
+	Extra    map[string]string `ini:"-"` // 没有对应字段的配置项，键为"section.key"格式
+
+	present map[string]bool // 解析时存在且有对应字段的配置项
+}
+
+// APIConfig api配置
+type APIConfig struct {
+	APIDebug     bool   `ini:"apiDebug"`     // 是否调试http api，启用调试后会打印每次http请求的内容和回复
+	Secret       string `ini:"secret"`       // api接口鉴权密钥
+	SnapRoot     string `ini:"snapRoot"`     // 截图保存路径根目录
+	DefaultSnap  string `ini:"defaultSnap"`  // 截图失败时返回的默认图片
+	DownloadRoot string `ini:"downloadRoot"` // downloadFile接口允许下载的根目录
+}
+
+// GeneralConfig general配置
+type GeneralConfig struct {
+	EnableVhost             bool   `ini:"enableVhost"`             // 是否启用虚拟主机
+	FlowThreshold           int    `ini:"flowThreshold"`           // 触发on_flow_report事件的流量阈值，单位KB
+	MaxStreamWaitMS         int    `ini:"maxStreamWaitMS"`         // 播放最多等待时间，单位毫秒
+	StreamNoneReaderDelayMS int    `ini:"streamNoneReaderDelayMS"` // 无人观看时触发on_stream_none_reader事件的延时，单位毫秒
+	ResetWhenRePlay         bool   `ini:"resetWhenRePlay"`         // 拉流代理断流重连后是否清空播放器
+	MergeWriteMS            int    `ini:"mergeWriteMS"`            // 合并写缓存大小，单位毫秒
+	MediaServerID           string `ini:"mediaServerId"`           // 服务器唯一id，用于触发hook时区别是哪台服务器
+	WaitTrackReadyMS        int    `ini:"wait_track_ready_ms"`     // 等待track ready的最大时间，单位毫秒
+	WaitAddTrackMS          int    `ini:"wait_add_track_ms"`       // 等待添加track的最大时间，单位毫秒
+	UnreadyFrameCache       int    `ini:"unready_frame_cache"`     // track未就绪时缓存的最大帧数
+}
+
+// HLSConfig hls配置
+type HLSConfig struct {
+	FileBufSize       int  `ini:"fileBufSize"`       // hls写文件的buf大小
+	SegDur            int  `ini:"segDur"`            // hls最大切片时间，单位秒
+	SegNum            int  `ini:"segNum"`            // m3u8索引中的切片个数，0为全部保留
+	SegDelay          int  `ini:"segDelay"`          // m3u8索引中延迟的切片个数
+	SegRetain         int  `ini:"segRetain"`         // 移除切片后在磁盘上保留的切片个数
+	BroadcastRecordTs bool `ini:"broadcastRecordTs"` // 是否广播hls切片完成通知(on_record_ts)
+	DeleteDelaySec    int  `ini:"deleteDelaySec"`    // 直播hls文件删除延时，单位秒
+	SegKeep           bool `ini:"segKeep"`           // 是否保留hls文件
+	FastRegister      bool `ini:"fastRegister"`      // 是否在生成第一个切片前注册hls流
+}
+
+// HookConfig hook配置
+type HookConfig struct {
+	Enable               bool   `ini:"enable"`                 // 是否启用hook事件
+	OnFlowReport         string `ini:"on_flow_report"`         // 播放器或推流器断开时的流量汇报事件
+	OnHttpAccess         string `ini:"on_http_access"`         // 访问http文件服务器的鉴权事件
+	OnPlay               string `ini:"on_play"`                // 播放鉴权事件
+	OnPublish            string `ini:"on_publish"`             // 推流鉴权事件
+	OnRecordMp4          string `ini:"on_record_mp4"`          // mp4录制完成事件
+	OnRecordTs           string `ini:"on_record_ts"`           // hls切片完成事件
+	OnRtspAuth           string `ini:"on_rtsp_auth"`           // rtsp鉴权事件
+	OnRtspRealm          string `ini:"on_rtsp_realm"`          // rtsp是否启用专用鉴权事件
+	OnShellLogin         string `ini:"on_shell_login"`         // telnet调试鉴权事件
+	OnStreamChanged      string `ini:"on_stream_changed"`      // 流注册或注销事件
+	OnStreamNoneReader   string `ini:"on_stream_none_reader"`  // 流无人观看事件
+	OnStreamNotFound     string `ini:"on_stream_not_found"`    // 播放时未找到流事件
+	OnServerStarted      string `ini:"on_server_started"`      // 服务器启动事件
+	OnServerExited       string `ini:"on_server_exited"`       // 服务器退出事件
+	OnServerKeepalive    string `ini:"on_server_keepalive"`    // 服务器心跳事件
+	OnSendRtpStopped     string `ini:"on_send_rtp_stopped"`    // 发送rtp停止事件
+	OnRtpServerTimeout   string `ini:"on_rtp_server_timeout"`  // rtp服务器超时未收到数据事件
+	TimeoutSec           int    `ini:"timeoutSec"`             // hook请求超时时间，单位秒
+	AliveInterval        string `ini:"alive_interval"`         // 服务器心跳间隔，单位秒，可以为小数
+	Retry                int    `ini:"retry"`                  // hook失败重试次数
+	RetryDelay           string `ini:"retry_delay"`            // hook失败重试延时，单位秒，可以为小数
+	StreamChangedSchemas string `ini:"stream_changed_schemas"` // 触发on_stream_changed事件的协议，以/分隔，为空时所有协议都触发
+}
+
+// HTTPConfig http配置
+type HTTPConfig struct {
+	CharSet           string `ini:"charSet"`             // http返回的字符编码
+	KeepAliveSecond   int    `ini:"keepAliveSecond"`     // http连接超时时间，单位秒
+	MaxReqSize        int    `ini:"maxReqSize"`          // http请求体最大字节数
+	NotFound          string `ini:"notFound"`            // 404网页内容
+	Port              int    `ini:"port"`                // http服务器监听端口
+	RootPath          string `ini:"rootPath"`            // http文件服务器根目录
+	SendBufSize       int    `ini:"sendBufSize"`         // http文件服务器读文件缓存大小
+	SSLPort           int    `ini:"sslport"`             // https服务器监听端口
+	DirMenu           bool   `ini:"dirMenu"`             // 是否显示文件夹菜单
+	VirtualPath       string `ini:"virtualPath"`         // 虚拟目录
+	ForbidCacheSuffix string `ini:"forbidCacheSuffix"`   // 禁止缓存的文件后缀
+	ForwardedIPHeader string `ini:"forwarded_ip_header"` // 反向代理时获取客户端ip的header
+	AllowCrossDomains bool   `ini:"allow_cross_domains"` // 是否允许跨域
+	AllowIPRange      string `ini:"allow_ip_range"`      // 允许访问的ip范围
+}
+
+// ProtocolConfig protocol配置，推流和拉流的默认转协议选项
+type ProtocolConfig struct {
+	ModifyStamp    int    `ini:"modify_stamp"`     // 转协议时是否修改时间戳
+	EnableAudio    bool   `ini:"enable_audio"`     // 转协议时是否开启音频
+	AddMuteAudio   bool   `ini:"add_mute_audio"`   // 无音频时是否添加静音aac音频
+	AutoClose      bool   `ini:"auto_close"`       // 无人观看时是否直接关闭
+	ContinuePushMS int    `ini:"continue_push_ms"` // 推流断开后等待重连的时间，单位毫秒
+	PacedSenderMS  int    `ini:"paced_sender_ms"`  // 平滑发送定时器间隔，单位毫秒，0为关闭
+	EnableHLS      bool   `ini:"enable_hls"`       // 是否转换成hls-mpegts协议
+	EnableHLSFmp4  bool   `ini:"enable_hls_fmp4"`  // 是否转换成hls-fmp4协议
+	EnableMp4      bool   `ini:"enable_mp4"`       // 是否开启mp4录制
+	EnableRtsp     bool   `ini:"enable_rtsp"`      // 是否转rtsp协议
+	EnableRtmp     bool   `ini:"enable_rtmp"`      // 是否转rtmp/flv协议
+	EnableTS       bool   `ini:"enable_ts"`        // 是否转http-ts/ws-ts协议
+	EnableFmp4     bool   `ini:"enable_fmp4"`      // 是否转http-fmp4/ws-fmp4协议
+	Mp4AsPlayer    bool   `ini:"mp4_as_player"`    // mp4录制是否当作观看者参与播放人数计数
+	Mp4MaxSecond   int    `ini:"mp4_max_second"`   // mp4录制切片大小，单位秒
+	Mp4SavePath    string `ini:"mp4_save_path"`    // mp4录制保存根目录
+	HlsSavePath    string `ini:"hls_save_path"`    // hls录制保存根目录
+	HlsDemand      bool   `ini:"hls_demand"`       // hls协议是否按需生成
+	RtspDemand     bool   `ini:"rtsp_demand"`      // rtsp协议是否按需生成
+	RtmpDemand     bool   `ini:"rtmp_demand"`      // rtmp协议是否按需生成
+	TSDemand       bool   `ini:"ts_demand"`        // ts协议是否按需生成
+	Fmp4Demand     bool   `ini:"fmp4_demand"`      // fmp4协议是否按需生成
+}
+
+// RecordConfig record配置
+type RecordConfig struct {
+	AppName     string `ini:"appName"`     // mp4录制或mp4点播的应用名
+	FileBufSize int    `ini:"fileBufSize"` // mp4录制写文件缓存大小
+	SampleMS    int    `ini:"sampleMS"`    // mp4点播每次流化数据量，单位毫秒
+	FastStart   bool   `ini:"fastStart"`   // mp4录制完成后是否进行二次关键帧索引写入头部
+	FileRepeat  bool   `ini:"fileRepeat"`  // mp4点播是否循环播放文件
+	EnableFmp4  bool   `ini:"enableFmp4"`  // mp4录制是否使用fmp4格式
+}
+
+// RTCConfig rtc配置
+type RTCConfig struct {
+	TimeoutSec      int    `ini:"timeoutSec"`      // rtc播放推流、播放超时时间，单位秒
+	ExternIP        string `ini:"externIP"`        // 本机对rtc客户端的可见ip，多个以逗号分隔
+	Port            int    `ini:"port"`            // rtc udp服务器监听端口
+	TCPPort         int    `ini:"tcpPort"`         // rtc tcp服务器监听端口
+	RembBitRate     int    `ini:"rembBitRate"`     // 设置remb比特率，非0时关闭twcc并开启remb
+	PreferredCodecA string `ini:"preferredCodecA"` // rtc支持的音频codec类型，在前面的优先级更高
+	PreferredCodecV string `ini:"preferredCodecV"` // rtc支持的视频codec类型，在前面的优先级更高
+}
+
+// RTMPConfig rtmp配置
+type RTMPConfig struct {
+	HandshakeSecond int  `ini:"handshakeSecond"` // rtmp握手超时时间，单位秒
+	KeepAliveSecond int  `ini:"keepAliveSecond"` // rtmp超时时间，单位秒
+	Port            int  `ini:"port"`            // rtmp服务器监听端口
+	SSLPort         int  `ini:"sslport"`         // rtmps服务器监听端口
+	DirectProxy     bool `ini:"directProxy"`     // rtmp是否直接代理模式
+	Enhanced        bool `ini:"enhanced"`        // 是否启用enhanced-rtmp
+}
+
+// RTPConfig rtp配置
+type RTPConfig struct {
+	AudioMtuSize int  `ini:"audioMtuSize"` // 音频mtu大小
+	VideoMtuSize int  `ini:"videoMtuSize"` // 视频mtu大小
+	RtpMaxSize   int  `ini:"rtpMaxSize"`   // rtp包最大长度限制，单位KB
+	LowLatency   bool `ini:"lowLatency"`   // rtp打包时是否低延迟开启
+	H264StapA    bool `ini:"h264_stap_a"`  // h264 rtp打包时是否采用stap-a模式
+}
+
+// RTPProxyConfig rtp_proxy配置，即GB28181 rtp收流配置
+type RTPProxyConfig struct {
+	DumpDir             string `ini:"dumpDir"`                // 导出调试数据的目录
+	Port                int    `ini:"port"`                   // 单端口收流的udp和tcp端口
+	TimeoutSec          int    `ini:"timeoutSec"`             // rtp超时时间，单位秒
+	PortRange           string `ini:"port_range"`             // 随机端口范围，例如30000-35000
+	H264PT              int    `ini:"h264_pt"`                // rtp h264负载的pt
+	H265PT              int    `ini:"h265_pt"`                // rtp h265负载的pt
+	PSPT                int    `ini:"ps_pt"`                  // rtp ps负载的pt
+	OpusPT              int    `ini:"opus_pt"`                // rtp opus负载的pt
+	GopCache            bool   `ini:"gop_cache"`              // 是否开启gop缓存
+	RtpG711DurMS        int    `ini:"rtp_g711_dur_ms"`        // 国标发送g711 rtp打包时每个包的时长，单位毫秒
+	UDPRecvSocketBuffer int    `ini:"udp_recv_socket_buffer"` // udp接收数据socket buffer大小
+}
+
+// RTSPConfig rtsp配置
+type RTSPConfig struct {
+	AuthBasic        bool `ini:"authBasic"`        // rtsp专有鉴权是否以base64方式
+	DirectProxy      bool `ini:"directProxy"`      // rtsp拉流和推流代理是否直接代理模式
+	HandshakeSecond  int  `ini:"handshakeSecond"`  // rtsp握手超时时间，单位秒
+	KeepAliveSecond  int  `ini:"keepAliveSecond"`  // rtsp超时时间，单位秒
+	Port             int  `ini:"port"`             // rtsp服务器监听端口
+	SSLPort          int  `ini:"sslport"`          // rtsps服务器监听端口
+	LowLatency       bool `ini:"lowLatency"`       // rtsp转发是否使用低延迟模式
+	RtpTransportType int  `ini:"rtpTransportType"` // 强制协商rtp传输方式，-1为不限制，0为tcp，1为udp，2为组播
+}
+
+// ShellConfig shell配置
+type ShellConfig struct {
+	MaxReqSize int `ini:"maxReqSize"` // 调试telnet服务器接受最大bufffer大小
+	Port       int `ini:"port"`       // 调试telnet服务器监听端口
+}
+
+// SRTConfig srt配置
+type SRTConfig struct {
+	TimeoutSec int    `ini:"timeoutSec"` // srt播放推流、播放超时时间，单位秒
+	Port       int    `ini:"port"`       // srt udp服务器监听端口
+	LatencyMul int    `ini:"latencyMul"` // srt延时倍数
+	PktBufSize int    `ini:"pktBufSize"` // 包缓存的大小
+	PassPhrase string `ini:"passPhrase"` // srt加密密码
+}
+
+// ParseServerConfig 将"section.key"格式的配置项解析为ServerConfig
+// 没有对应字段的配置项保存在Extra中；逐项解析，值无法解析的配置项以原始值保存在Extra中，
+// 此时仍返回解析得到的配置，同时返回包含所有失败配置项的错误
+func ParseServerConfig(values map[string]string) (*ServerConfig, error) {
+	config := &ServerConfig{Extra: make(map[string]string), present: make(map[string]bool)}
+	fields := config.fields()
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		value := values[key]
+		field, ok := fields[key]
+		if !ok {
+			config.Extra[key] = value
+			continue
+		}
+		if err := setConfigField(field, value); err != nil {
+			config.Extra[key] = value
+			errs = append(errs, fmt.Errorf("解析配置项%s失败: %w", key, err))
+			continue
+		}
+		config.present[key] = true
+	}
+	return config, errors.Join(errs...)
+}
+
+// ToMap 将配置转换为"section.key"格式的配置项
+// 有对应字段的配置项只在解析时存在或字段为非零值时输出，避免输出服务器没有返回的配置项
+func (c *ServerConfig) ToMap() map[string]string {
+	values := make(map[string]string, len(c.Extra))
+	for key, value := range c.Extra {
+		values[key] = value
+	}
+	for key, field := range c.fields() {
+		if c.present[key] || !field.IsZero() {
+			values[key] = formatConfigField(field)
+		}
+	}
+	return values
+}
+
+// Clone 深拷贝配置
+func (c *ServerConfig) Clone() *ServerConfig {
+	cloned := *c
+	cloned.Extra = make(map[string]string, len(c.Extra))
+	for key, value := range c.Extra {
+		cloned.Extra[key] = value
+	}
+	cloned.present = make(map[string]bool, len(c.present))
+	for key := range c.present {
+		cloned.present[key] = true
+	}
+	return &cloned
+}
+
+// fields 获取"section.key"到字段的映射，返回的字段可寻址
+func (c *ServerConfig) fields() map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	config := reflect.ValueOf(c).Elem()
+	for i := 0; i < config.NumField(); i++ {
+		section := config.Type().Field(i).Tag.Get("ini")
+		if section == "" || section == "-" {
+			continue
+		}
+		sectionValue := config.Field(i)
+		for j := 0; j < sectionValue.NumField(); j++ {
+			key := sectionValue.Type().Field(j).Tag.Get("ini")
+			fields[section+"."+key] = sectionValue.Field(j)
+		}
+	}
+	return fields
+}
+
+// setConfigField 将配置值写入字段
+func setConfigField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		if strings.TrimSpace(value) == "" {
+			field.SetBool(false)
+			return nil
+		}
+		b, ok := parseConfigBool(value)
+		if !ok {
+			return fmt.Errorf("无效的布尔值%q", value)
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		if strings.TrimSpace(value) == "" {
+			field.SetInt(0)
+			return nil
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	}
+	return nil
+}
+
+// parseConfigBool 解析布尔配置值
+// 返回: 配置值不是布尔值的写法时返回false
+func parseConfigBool(value string) (b bool, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "yes", "on":
+		return true, true
+	case "0", "false", "no", "off":
+		return false, true
+	}
+	return false, false
+}
+
+// sameConfigValue 判断两个配置值是否相同，都是布尔值的写法时按布尔值比较
+func sameConfigValue(a, b string) bool {
+	if a == b {
+		return true
+	}
+	x, okA := parseConfigBool(a)
+	y, okB := parseConfigBool(b)
+	return okA && okB && x == y
+}
+
+// formatConfigField 将字段格式化为配置值
+func formatConfigField(field reflect.Value) string {
+	switch field.Kind() {
+	case reflect.Bool:
+		if field.Bool() {
+			return "1"
+		}
+		return "0"
+	case reflect.Int:
+		return strconv.FormatInt(field.Int(), 10)
+	default:
+		return field.String()
+	}
+}
+
+// ConfigChange 配置项变更
+type ConfigChange struct {
+	Key string `json:"key"` // 配置项，格式为"section.key"
+	Old string `json:"old"` // 当前值，配置项不存在时为空
+	New string `json:"new"` // 期望值
+}
+
+// Diff 比较当前配置与期望配置，返回需要修改的配置项，按配置项排序
+// 只在当前配置中存在的配置项不会被视为删除；布尔值的不同写法(例如"true"和"1")视为相同
+func Diff(current, desired *ServerConfig) []ConfigChange {
+	currentValues := current.ToMap()
+	desiredValues := desired.ToMap()
+
+	var changes []ConfigChange
+	for key, value := range desiredValues {
+		if old, ok := currentValues[key]; !ok || !sameConfigValue(old, value) {
+			changes = append(changes, ConfigChange{Key: key, Old: currentValues[key], New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// GetTypedServerConfig 获取服务器配置并解析为ServerConfig
+// 返回: 服务器配置，部分配置项解析失败时同时返回配置和ParseServerConfig的错误
+func (s *ServerAPI) GetTypedServerConfig(ctx context.Context) (*ServerConfig, error) {
+	resp, err := s.GetServerConfig(ctx, &GetServerConfigRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("获取服务器配置失败: 响应中没有配置")
+	}
+
+	return ParseServerConfig(resp.Data[0])
+}
+
+// ApplyConfigResult 应用配置的结果
+type ApplyConfigResult struct {
+	Changes  []ConfigChange `json:"changes"`  // 发送给ZLMediaKit的配置项变更
+	Accepted []ConfigChange `json:"accepted"` // 应用后已生效的配置项
+	Rejected []ConfigChange `json:"rejected"` // 应用后未生效的配置项，例如ZLMediaKit不支持的配置项
+	Changed  int            `json:"changed"`  // ZLMediaKit响应中的变更个数
+}
+
+// ApplyConfig 将服务器配置修改为期望配置
+// 先获取当前配置并与期望配置比较，只发送有变化的配置项，
+// 再重新获取配置以确认哪些配置项已被ZLMediaKit接受
+// 参数:
+//   - desired: 期望的配置，应基于GetTypedServerConfig的结果修改
+//
+// 返回: 应用结果，没有需要修改的配置项时Changes为空
+func (s *ServerAPI) ApplyConfig(ctx context.Context, desired *ServerConfig) (*ApplyConfigResult, error) {
+	// 解析失败的配置项以原始值保存在Extra中，仍然可以比较
+	current, err := s.GetTypedServerConfig(ctx)
+	if current == nil {
+		return nil, fmt.Errorf("应用服务器配置失败: %w", err)
+	}
+
+	result := &ApplyConfigResult{Changes: Diff(current, desired)}
+	if len(result.Changes) == 0 {
+		return result, nil
+	}
+
+	values := make(map[string]string, len(result.Changes))
+	for _, change := range result.Changes {
+		values[change.Key] = change.New
+	}
+	resp, err := s.SetServerConfig(ctx, &SetServerConfigRequest{Config: values})
+	if err != nil {
+		return result, fmt.Errorf("应用服务器配置失败: %w", err)
+	}
+	result.Changed = resp.Changed
+
+	applied, err := s.GetTypedServerConfig(ctx)
+	if applied == nil {
+		return result, fmt.Errorf("确认服务器配置失败: %w", err)
+	}
+	appliedValues := applied.ToMap()
+	for _, change := range result.Changes {
+		if value, ok := appliedValues[change.Key]; ok && sameConfigValue(value, change.New) {
+			result.Accepted = append(result.Accepted, change)
+		} else {
+			result.Rejected = append(result.Rejected, change)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,192 @@
+package zlmedia_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+func TestParseServerConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  map[string]string
+		want    map[string]string // 期望的ToMap结果
+		wantErr bool
+	}{
+		{"字段和Extra", map[string]string{"hls.segDur": "4", "protocol.enable_mp4": "1", "api.secret": "s", "unknown.key": "v"},
+			map[string]string{"hls.segDur": "4", "protocol.enable_mp4": "1", "api.secret": "s", "unknown.key": "v"}, false},
+		{"布尔值的其它写法", map[string]string{"protocol.enable_mp4": "true", "protocol.enable_hls": " off "},
+			map[string]string{"protocol.enable_mp4": "1", "protocol.enable_hls": "0"}, false},
+		{"空值", map[string]string{"hls.segDur": "", "protocol.enable_mp4": ""},
+			map[string]string{"hls.segDur": "0", "protocol.enable_mp4": "0"}, false},
+		{"无效的布尔值", map[string]string{"protocol.enable_mp4": "maybe", "hls.segDur": "4"},
+			map[string]string{"protocol.enable_mp4": "maybe", "hls.segDur": "4"}, true},
+		{"无效的整数", map[string]string{"hls.segDur": "4s", "hls.segNum": "x", "protocol.enable_mp4": "1"},
+			map[string]string{"hls.segDur": "4s", "hls.segNum": "x", "protocol.enable_mp4": "1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := zlmedia.ParseServerConfig(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseServerConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			// 解析失败时仍返回配置，无效的值原样保留
+			if config == nil {
+				t.Fatal("ParseServerConfig() = nil")
+			}
+			got := config.ToMap()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToMap() = %v, want %v", got, tt.want)
+			}
+			// 重新解析ToMap的结果得到相同的配置
+			again, _ := zlmedia.ParseServerConfig(got)
+			if changes := zlmedia.Diff(config, again); len(changes) != 0 {
+				t.Errorf("Diff(config, ParseServerConfig(ToMap())) = %v, want none", changes)
+			}
+		})
+	}
+}
+
+func TestServerConfigToMap(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		modify func(c *zlmedia.ServerConfig)
+		want   map[string]string
+	}{
+		{"省略服务器没有返回的配置项", map[string]string{"hls.segDur": "2"}, func(c *zlmedia.ServerConfig) {},
+			map[string]string{"hls.segDur": "2"}},
+		{"输出设置为非零值的配置项", map[string]string{"hls.segDur": "2"}, func(c *zlmedia.ServerConfig) { c.HLS.SegNum = 3 },
+			map[string]string{"hls.segDur": "2", "hls.segNum": "3"}},
+		{"输出设置为零值的已有配置项", map[string]string{"hls.segDur": "2"}, func(c *zlmedia.ServerConfig) { c.HLS.SegDur = 0 },
+			map[string]string{"hls.segDur": "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := zlmedia.ParseServerConfig(tt.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(config)
+			if got := config.ToMap(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToMap() = %v, want %v", got, tt.want)
+			}
+			if got := config.Clone().ToMap(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Clone().ToMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	current, err := zlmedia.ParseServerConfig(map[string]string{
+		"hls.segDur":            "2",
+		"protocol.enable_mp4":   "0",
+		"general.mediaServerId": "node1",
+		"unknown.a":             "1",
+		"unknown.b":             "2",
+		"unknown.flag":          "1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *zlmedia.ServerConfig)
+		want   string
+	}{
+		{"没有变化", func(c *zlmedia.ServerConfig) {}, "[]"},
+		{"整数", func(c *zlmedia.ServerConfig) { c.HLS.SegDur = 4 }, "[{hls.segDur 2 4}]"},
+		{"布尔值", func(c *zlmedia.ServerConfig) { c.Protocol.EnableMp4 = true }, "[{protocol.enable_mp4 0 1}]"},
+		{"字符串", func(c *zlmedia.ServerConfig) { c.General.MediaServerID = "node2" }, "[{general.mediaServerId node1 node2}]"},
+		{"Extra修改", func(c *zlmedia.ServerConfig) { c.Extra["unknown.a"] = "3" }, "[{unknown.a 1 3}]"},
+		{"Extra新增", func(c *zlmedia.ServerConfig) { c.Extra["unknown.c"] = "3" }, "[{unknown.c  3}]"},
+		{"Extra布尔值的不同写法", func(c *zlmedia.ServerConfig) { c.Extra["unknown.flag"] = "true" }, "[]"},
+		{"Extra布尔值修改", func(c *zlmedia.ServerConfig) { c.Extra["unknown.flag"] = "off" }, "[{unknown.flag 1 off}]"},
+		{"服务器没有返回的配置项", func(c *zlmedia.ServerConfig) { c.HLS.SegNum = 3 }, "[{hls.segNum  3}]"},
+		{"Extra删除不视为变化", func(c *zlmedia.ServerConfig) { delete(c.Extra, "unknown.b") }, "[]"},
+		{"按配置项排序", func(c *zlmedia.ServerConfig) {
+			c.Protocol.EnableMp4 = true
+			c.HLS.SegDur = 4
+		}, "[{hls.segDur 2 4} {protocol.enable_mp4 0 1}]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired := current.Clone()
+			tt.modify(desired)
+			if got := fmt.Sprint(zlmedia.Diff(current, desired)); got != tt.want {
+				t.Errorf("Diff() = %s, want %s", got, tt.want)
+			}
+			// Clone为深拷贝，修改desired不影响current
+			if current.Extra["unknown.a"] != "1" || len(current.Extra) != 3 {
+				t.Fatalf("修改Clone的结果影响了原配置: %v", current.Extra)
+			}
+		})
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		server       map[string]string // 预先设置的服务器配置，可以为nil
+		modify       func(c *zlmedia.ServerConfig)
+		wantChanges  int
+		wantAccepted int
+		wantRejected int
+	}{
+		{"没有变化", nil, func(c *zlmedia.ServerConfig) {}, 0, 0, 0},
+		{"修改已有配置项", nil, func(c *zlmedia.ServerConfig) {
+			c.HLS.SegDur = 4
+			c.Protocol.EnableMp4 = true
+		}, 2, 2, 0},
+		{"ZLMediaKit不支持的配置项", nil, func(c *zlmedia.ServerConfig) {
+			c.HLS.SegDur = 4
+			c.HLS.SegNum = 5
+		}, 2, 1, 1},
+		{"服务器返回无效的值", map[string]string{"hls.segDur": "abc"}, func(c *zlmedia.ServerConfig) {
+			c.Protocol.EnableMp4 = true
+		}, 1, 1, 0},
+		{"修改服务器返回的无效值", map[string]string{"hls.segDur": "abc"}, func(c *zlmedia.ServerConfig) {
+			c.HLS.SegDur = 4
+		}, 1, 1, 0},
+		{"布尔值的其它写法", map[string]string{"protocol.enable_mp4": "true"}, func(c *zlmedia.ServerConfig) {
+			c.Protocol.EnableMp4 = true
+		}, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm := zlmtest.NewServer()
+			defer zlm.Close()
+			serverAPI := zlmedia.NewServerAPI(zlm.Client())
+			ctx := context.Background()
+
+			if tt.server != nil {
+				if _, err := serverAPI.SetServerConfig(ctx, &zlmedia.SetServerConfigRequest{Config: tt.server}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// 服务器返回无效的值时仍返回配置，无效的值保存在Extra中
+			desired, err := serverAPI.GetTypedServerConfig(ctx)
+			if desired == nil {
+				t.Fatal(err)
+			}
+			tt.modify(desired)
+			result, err := serverAPI.ApplyConfig(ctx, desired)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Changes) != tt.wantChanges || len(result.Accepted) != tt.wantAccepted || len(result.Rejected) != tt.wantRejected {
+				t.Errorf("ApplyConfig() = %+v, want %d changes, %d accepted, %d rejected", result, tt.wantChanges, tt.wantAccepted, tt.wantRejected)
+			}
+			if result.Changed != tt.wantAccepted {
+				t.Errorf("Changed = %d, want %d", result.Changed, tt.wantAccepted)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package zlmedia
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCron cron表达式格式错误
+var ErrInvalidCron = errors.New("cron表达式格式错误")
+
+// cronField cron表达式字段的取值范围和名称
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names []string // 从min开始的名称，例如月份JAN、星期SUN
+}
+
+// cron表达式的5个字段：分 时 日 月 星期
+var cronFields = [5]cronField{
+	{name: "分钟", min: 0, max: 59},
+	{name: "小时", min: 0, max: 23},
+	{name: "日期", min: 1, max: 31},
+	{name: "月份", min: 1, max: 12, names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{name: "星期", min: 0, max: 7, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+}
+
+// CronSchedule 解析后的cron表达式
+// 使用标准的5字段格式"分 时 日 月 星期"，支持*、逗号列表、a-b范围和/n步长，月份和星期可以使用英文缩写，
+// 星期的0和7都表示周日；日期和星期都有限制时满足其中之一即匹配
+type CronSchedule struct {
+	expr   string
+	fields [5]uint64 // 每个字段允许的取值位图
+	anyDay bool      // 日期字段为*
+	anyDow bool      // 星期字段为*
+}
+
+// ParseCron 解析cron表达式
+// 参数:
+//   - expr: 5字段的cron表达式，例如"0 9 * * MON-FRI"
+//
+// 返回: 解析后的cron表达式
+func ParseCron(expr string) (*CronSchedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("%w: %q需要5个字段", ErrInvalidCron, expr)
+	}
+
+	c := &CronSchedule{expr: expr, anyDay: parts[2] == "*", anyDow: parts[4] == "*"}
+	for i, part := range parts {
+		bits, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q的%s字段%v", ErrInvalidCron, expr, cronFields[i].name, err)
+		}
+		c.fields[i] = bits
+	}
+	// 星期的7与0都表示周日
+	if c.fields[4]&(1<<7) != 0 {
+		c.fields[4] |= 1
+	}
+	return c, nil
+}
+
+// parseCronField 解析cron表达式的一个字段
+func parseCronField(part string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("步长%q无效", stepPart)
+			}
+			step = n
+		}
+
+		low, high := field.min, field.max
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = field.value(lowPart); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = field.value(highPart); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = field.max
+			}
+			if low > high {
+				return 0, fmt.Errorf("范围%q无效", rangePart)
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value 解析字段的取值，支持数字和英文缩写
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("取值%q超出范围%d-%d", s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String 获取原始的cron表达式
+func (c *CronSchedule) String() string {
+	return c.expr
+}
+
+// Matches 时间所在的分钟是否匹配cron表达式，使用t的时区
+func (c *CronSchedule) Matches(t time.Time) bool {
+	return c.fields[0]&(1<<t.Minute()) != 0 && c.fields[1]&(1<<t.Hour()) != 0 &&
+		c.fields[3]&(1<<int(t.Month())) != 0 && c.dayMatches(t)
+}
+
+// Next 获取t之后第一个匹配的时间，使用t的时区，4年内没有匹配时返回零值
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for end := t.AddDate(4, 0, 0); t.Before(end); {
+		switch {
+		case c.fields[3]&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.fields[1]&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.fields[0]&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches 日期和星期字段是否匹配
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	dayMatch := c.fields[2]&(1<<t.Day()) != 0
+	dowMatch := c.fields[4]&(1<<int(t.Weekday())) != 0
+	if c.anyDay || c.anyDow {
+		return dayMatch && dowMatch
+	}
+	return dayMatch || dowMatch
+}
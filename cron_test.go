@@ -0,0 +1,94 @@
+package zlmedia_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"* * * * *", false},
+		{"0 9 * * MON-FRI", false},
+		{"*/15 0-6,22,23 1,15 JAN-jun 7", false},
+		{"5-55/10 * * * sun", false},
+		{"* * * *", true},
+		{"* * * * * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"* * 0 * *", true},
+		{"* * * 13 *", true},
+		{"* * * * 8", true},
+		{"*/0 * * * *", true},
+		{"5-1 * * * *", true},
+		{"* * * FOO *", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cron, err := zlmedia.ParseCron(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, zlmedia.ErrInvalidCron) {
+				t.Errorf("ParseCron() error = %v, want ErrInvalidCron", err)
+			}
+			if err == nil && cron.String() != tt.expr {
+				t.Errorf("String() = %q, want %q", cron.String(), tt.expr)
+			}
+		})
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	// 2026-10-16为周五
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from string
+		want string // 为空代表4年内没有匹配
+	}{
+		{"下一分钟", "* * * * *", "2026-10-16 10:30", "2026-10-16 10:31"},
+		{"工作日跳过周末", "0 9 * * MON-FRI", "2026-10-16 09:00", "2026-10-19 09:00"},
+		{"步长", "*/15 * * * *", "2026-10-16 10:31", "2026-10-16 10:45"},
+		{"跨年", "0 0 1 JAN *", "2026-10-16 10:30", "2027-01-01 00:00"},
+		{"星期7为周日", "30 8 * * 7", "2026-10-16 10:30", "2026-10-18 08:30"},
+		{"日期或星期满足其一", "0 0 20 * MON", "2026-10-16 10:30", "2026-10-19 00:00"},
+		{"跳过没有31日的月份", "0 0 31 * *", "2026-10-31 00:00", "2026-12-31 00:00"},
+		{"闰年", "0 0 29 2 *", "2026-10-16 10:30", "2028-02-29 00:00"},
+		{"不存在的日期", "0 0 30 2 *", "2026-10-16 10:30", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := zlmedia.ParseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next := cron.Next(at(tt.from))
+			if tt.want == "" {
+				if !next.IsZero() {
+					t.Errorf("Next() = %v, want zero", next)
+				}
+				return
+			}
+			if want := at(tt.want); !next.Equal(want) {
+				t.Errorf("Next() = %v, want %v", next, want)
+			}
+			if !cron.Matches(next) {
+				t.Errorf("Matches(%v) = false", next)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package zlmedia
+
+import "fmt"
+
+// ZLMediaKit API返回的错误代码
+const (
+	CodeSuccess     = 0    // 执行成功
+	CodeOtherFailed = -1   // 业务代码执行失败，例如流已存在
+	CodeAuthFailed  = -100 // 鉴权失败
+	CodeSqlFailed   = -200 // sql执行失败
+	CodeInvalidArgs = -300 // 参数不合法
+	CodeException   = -400 // 代码抛异常
+	CodeNotFound    = -500 // 未找到，例如流不存在
+)
+
+// ZLMediaKit错误代码对应的哨兵错误，可配合errors.Is使用
+var (
+	ErrOtherFailed = &APIError{Code: CodeOtherFailed, Msg: "业务代码执行失败"}
+	ErrAuthFailed  = &APIError{Code: CodeAuthFailed, Msg: "鉴权失败"}
+	ErrSqlFailed   = &APIError{Code: CodeSqlFailed, Msg: "sql执行失败"}
+	ErrInvalidArgs = &APIError{Code: CodeInvalidArgs, Msg: "参数不合法"}
+	ErrException   = &APIError{Code: CodeException, Msg: "代码抛异常"}
+	ErrNotFound    = &APIError{Code: CodeNotFound, Msg: "未找到"}
+)
+
+// APIError ZLMediaKit API错误
+// ZLMediaKit返回非0错误代码，或HTTP状态码不是2xx时返回该错误
+type APIError struct {
+	Code       int    // ZLMediaKit返回的错误代码，HTTP请求失败时为0
+	Msg        string // ZLMediaKit返回的错误消息，HTTP请求失败时为响应内容
+	Endpoint   string // 请求的接口路径，例如/index/api/addStreamProxy
+	HTTPStatus int    // HTTP状态码，仅在HTTP状态码不是2xx时设置
+}
+
+// Error 实现error接口
+func (e *APIError) Error() string {
+	if e.HTTPStatus != 0 {
+		return fmt.Sprintf("API请求失败，接口: %s，状态码: %d，响应: %s", e.Endpoint, e.HTTPStatus, e.Msg)
+	}
+	if e.Endpoint != "" {
+		return fmt.Sprintf("API返回错误，接口: %s，代码: %d，消息: %s", e.Endpoint, e.Code, e.Msg)
+	}
+	return fmt.Sprintf("API返回错误，代码: %d，消息: %s", e.Code, e.Msg)
+}
+
+// Is 支持errors.Is判断
+// target为*APIError时比较错误代码，target中非零的Endpoint和HTTPStatus也参与比较
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok {
+		return false
+	}
+	if t.Code != e.Code {
+		return false
+	}
+	if t.Endpoint != "" && t.Endpoint != e.Endpoint {
+		return false
+	}
+	if t.HTTPStatus != 0 && t.HTTPStatus != e.HTTPStatus {
+		return false
+	}
+	return true
+}
@@ -4,24 +4,29 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
 )
 
 func main() {
-	// 初始化ZLMediaKit客户端
+	// 创建ZLMediaKit客户端并注册为节点
 	config := zlmedia.Config{
 		BaseURL: "http://127.0.0.1:80",
 		Secret:  "035c73f7-bb6b-4889-a715-d9eb2d1925cc",
-		Timeout: 30,
+		Timeout: 30 * time.Second,
 	}
 
-	_ = zlmedia.InitClient(config)
+	registry := zlmedia.NewRegistry()
+	if err := registry.Add("node-1", zlmedia.NewClient(config)); err != nil {
+		log.Fatalf("注册节点失败: %v", err)
+	}
+	client, _ := registry.Get("node-1")
 	ctx := context.Background()
 
 	// 示例1: 获取API列表
 	fmt.Println("=== 获取API列表 ===")
-	serverAPI := zlmedia.GetServerAPI()
+	serverAPI := zlmedia.NewServerAPI(client)
 	apiListResp, err := serverAPI.GetApiList(ctx, &zlmedia.GetApiListRequest{})
 	if err != nil {
 		log.Printf("获取API列表失败: %v", err)
@@ -31,7 +36,7 @@ func main() {
 
 	// 示例2: 获取流列表
 	fmt.Println("\n=== 获取流列表 ===")
-	mediaAPI := zlmedia.GetMediaAPI()
+	mediaAPI := zlmedia.NewMediaAPI(client)
 	mediaListResp, err := mediaAPI.GetMediaList(ctx, &zlmedia.GetMediaListRequest{
 		Schema: "rtmp",
 		VHost:  "__defaultVhost__",
@@ -45,7 +50,7 @@ func main() {
 
 	// 示例3: 添加拉流代理
 	fmt.Println("\n=== 添加拉流代理 ===")
-	proxyAPI := zlmedia.GetProxyAPI()
+	proxyAPI := zlmedia.NewProxyAPI(client)
 	enableHLS := true
 	enableMp4 := false
 	addProxyResp, err := proxyAPI.AddStreamProxy(ctx, &zlmedia.AddStreamProxyRequest{
@@ -64,7 +69,7 @@ func main() {
 
 	// 示例4: 开始录制
 	fmt.Println("\n=== 开始录制 ===")
-	recordAPI := zlmedia.GetRecordAPI()
+	recordAPI := zlmedia.NewRecordAPI(client)
 	startRecordResp, err := recordAPI.StartRecord(ctx, &zlmedia.StartRecordRequest{
 		Type:   1, // mp4录制
 		VHost:  "__defaultVhost__",
@@ -79,7 +84,7 @@ func main() {
 
 	// 示例5: 创建RTP接收端口
 	fmt.Println("\n=== 创建RTP接收端口 ===")
-	rtpAPI := zlmedia.GetRTPAPI()
+	rtpAPI := zlmedia.NewRTPAPI(client)
 	enableTcp := 0
 	openRtpResp, err := rtpAPI.OpenRtpServer(ctx, &zlmedia.OpenRtpServerRequest{
 		Port:      0, // 随机端口
@@ -94,7 +99,7 @@ func main() {
 
 	// 示例6: 获取会话列表
 	fmt.Println("\n=== 获取会话列表 ===")
-	sessionAPI := zlmedia.GetSessionAPI()
+	sessionAPI := zlmedia.NewSessionAPI(client)
 	sessionListResp, err := sessionAPI.GetAllSession(ctx, &zlmedia.GetAllSessionRequest{})
 	if err != nil {
 		log.Printf("获取会话列表失败: %v", err)
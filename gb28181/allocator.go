@@ -0,0 +1,302 @@
+package gb28181
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// 分配器相关错误
+var (
+	ErrStreamAllocated = errors.New("流id已分配RTP接收端口")
+	ErrSSRCExhausted   = errors.New("ssrc已用尽")
+	ErrPortExhausted   = errors.New("RTP接收端口已用尽")
+	ErrReleased        = errors.New("分配记录在创建RTP接收端口期间已被释放")
+)
+
+// maxSSRCSeq ssrc序号的最大值，序号为4位
+const maxSSRCSeq = 9999
+
+// AllocatorConfig ssrc和RTP接收端口分配器配置
+type AllocatorConfig struct {
+	Domain        string // SIP域，10位，ssrc的第2到6位取自SIP域的第4到8位
+	PortMin       int    // RTP接收端口范围的最小值，PortMin和PortMax都为0时使用ZLMediaKit分配的随机端口
+	PortMax       int    // RTP接收端口范围的最大值
+	MediaServerID string // 只处理该节点的hook事件，为空时处理所有事件
+}
+
+// OpenRequest 分配并创建RTP接收端口的请求参数
+type OpenRequest struct {
+	StreamID string // ZLMediaKit中的流id
+	Playback bool   // 是否为历史回放，回放的ssrc以1开头，实时点播以0开头
+	TCP      bool   // 是否使用TCP接收RTP
+}
+
+// Allocation 已分配的ssrc和RTP接收端口
+type Allocation struct {
+	StreamID  string    // ZLMediaKit中的流id
+	SSRC      string    // 10位ssrc
+	Port      int       // RTP接收端口，使用随机端口时为ZLMediaKit实际分配的端口
+	Playback  bool      // 是否为历史回放
+	TCP       bool      // 是否使用TCP接收RTP
+	CreatedAt time.Time // 分配时间
+}
+
+// Allocator GB28181的ssrc和RTP接收端口分配器
+// 生成符合GB28181的10位ssrc：1位实时点播(0)或历史回放(1)标识 + SIP域第4到8位 + 4位序号，
+// 通过OpenRtpServer创建接收端口并记录实际使用的端口，在CloseRtpServer或on_rtp_server_timeout后释放；
+// 同一进程中的多个服务共用一个分配器即可避免ssrc和端口冲突
+type Allocator struct {
+	rtp    *zlmedia.RTPAPI
+	config AllocatorConfig
+
+	mu       sync.Mutex
+	streams  map[string]*Allocation
+	ssrcs    map[string]string // ssrc -> 流id
+	ports    map[int]string    // 端口 -> 流id
+	pending  map[string]bool   // 正在调用OpenRtpServer的流id
+	seq      [2]int            // 实时点播和历史回放的上一个序号
+	lastPort int
+}
+
+// NewAllocator 创建ssrc和RTP接收端口分配器
+func NewAllocator(client *zlmedia.Client, config AllocatorConfig) *Allocator {
+	return &Allocator{
+		rtp:      zlmedia.NewRTPAPI(client),
+		config:   config,
+		streams:  make(map[string]*Allocation),
+		ssrcs:    make(map[string]string),
+		ports:    make(map[int]string),
+		pending:  make(map[string]bool),
+		lastPort: config.PortMin - 1,
+	}
+}
+
+// Open 分配ssrc和端口，并调用OpenRtpServer创建RTP接收端口
+// 参数:
+//   - req: 请求参数，StreamID必须设置
+//
+// 返回: 分配结果，创建失败时释放分配的ssrc和端口；
+// 创建期间分配记录被Release释放时返回ErrReleased，已创建的端口由ZLMediaKit超时关闭
+func (a *Allocator) Open(ctx context.Context, req *OpenRequest) (*Allocation, error) {
+	allocation, err := a.reserve(req)
+	if err != nil {
+		return nil, err
+	}
+
+	enableTcp := 0
+	if req.TCP {
+		enableTcp = 1
+	}
+	resp, err := a.rtp.OpenRtpServer(ctx, &zlmedia.OpenRtpServerRequest{
+		Port:      allocation.Port,
+		EnableTcp: &enableTcp,
+		StreamID:  req.StreamID,
+		SSRC:      allocation.SSRC,
+	})
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// 创建期间被释放后，流id可能已经分配给新的请求，不能再修改记录
+	if a.streams[req.StreamID] != allocation {
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s", ErrReleased, req.StreamID)
+	}
+	delete(a.pending, req.StreamID)
+	if err != nil {
+		a.releaseLocked(req.StreamID)
+		return nil, err
+	}
+
+	// 随机端口以ZLMediaKit实际分配的端口为准
+	if allocation.Port != resp.Port {
+		delete(a.ports, allocation.Port)
+		allocation.Port = resp.Port
+	}
+	a.ports[allocation.Port] = req.StreamID
+	copied := *allocation
+	return &copied, nil
+}
+
+// reserve 预留流id、ssrc和端口
+func (a *Allocator) reserve(req *OpenRequest) (*Allocation, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.streams[req.StreamID]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrStreamAllocated, req.StreamID)
+	}
+	ssrc, err := a.nextSSRCLocked(req.Playback)
+	if err != nil {
+		return nil, err
+	}
+	port, err := a.nextPortLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	allocation := &Allocation{
+		StreamID:  req.StreamID,
+		SSRC:      ssrc,
+		Port:      port,
+		Playback:  req.Playback,
+		TCP:       req.TCP,
+		CreatedAt: time.Now(),
+	}
+	a.streams[req.StreamID] = allocation
+	a.ssrcs[ssrc] = req.StreamID
+	a.pending[req.StreamID] = true
+	if port != 0 {
+		a.ports[port] = req.StreamID
+	}
+	return allocation, nil
+}
+
+// nextSSRCLocked 生成未使用的ssrc
+func (a *Allocator) nextSSRCLocked(playback bool) (string, error) {
+	kind := 0
+	if playback {
+		kind = 1
+	}
+	segment := (a.config.Domain + "00000000")[3:8]
+
+	for range maxSSRCSeq {
+		a.seq[kind] = a.seq[kind]%maxSSRCSeq + 1
+		ssrc := fmt.Sprintf("%d%s%04d", kind, segment, a.seq[kind])
+		if _, used := a.ssrcs[ssrc]; !used {
+			return ssrc, nil
+		}
+	}
+	return "", ErrSSRCExhausted
+}
+
+// nextPortLocked 在端口范围内轮流选择未使用的端口，未配置端口范围时返回0
+func (a *Allocator) nextPortLocked() (int, error) {
+	if a.config.PortMin == 0 && a.config.PortMax == 0 {
+		return 0, nil
+	}
+
+	size := a.config.PortMax - a.config.PortMin + 1
+	for range max(size, 0) {
+		a.lastPort++
+		if a.lastPort > a.config.PortMax || a.lastPort < a.config.PortMin {
+			a.lastPort = a.config.PortMin
+		}
+		if _, used := a.ports[a.lastPort]; !used {
+			return a.lastPort, nil
+		}
+	}
+	return 0, ErrPortExhausted
+}
+
+// Close 调用CloseRtpServer关闭RTP接收端口并释放分配的ssrc和端口
+// ZLMediaKit中已不存在该端口或关闭失败时同样释放，关闭失败的端口由ZLMediaKit超时关闭
+func (a *Allocator) Close(ctx context.Context, streamID string) error {
+	_, err := a.rtp.CloseRtpServer(ctx, &zlmedia.CloseRtpServerRequest{StreamID: streamID})
+	a.Release(streamID)
+	return err
+}
+
+// Release 只释放分配的ssrc和端口，不调用CloseRtpServer，用于端口已被ZLMediaKit关闭的情况
+// 返回: 流id是否有分配记录
+func (a *Allocator) Release(streamID string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.releaseLocked(streamID)
+}
+
+// releaseLocked 释放流id的分配记录
+func (a *Allocator) releaseLocked(streamID string) bool {
+	allocation, ok := a.streams[streamID]
+	if !ok {
+		return false
+	}
+	delete(a.streams, streamID)
+	delete(a.pending, streamID)
+	delete(a.ssrcs, allocation.SSRC)
+	if a.ports[allocation.Port] == streamID {
+		delete(a.ports, allocation.Port)
+	}
+	return true
+}
+
+// Lookup 获取流id的分配记录
+func (a *Allocator) Lookup(streamID string) (Allocation, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	allocation, ok := a.streams[streamID]
+	if !ok || a.pending[streamID] {
+		return Allocation{}, false
+	}
+	return *allocation, true
+}
+
+// Allocations 获取所有已创建的RTP接收端口的分配记录，按流id排序
+func (a *Allocator) Allocations() []Allocation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	allocations := make([]Allocation, 0, len(a.streams))
+	for _, id := range sortedIDs(a.streams) {
+		if !a.pending[id] {
+			allocations = append(allocations, *a.streams[id])
+		}
+	}
+	return allocations
+}
+
+// releaseAll 释放所有分配记录
+func (a *Allocator) releaseAll() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for streamID := range a.streams {
+		if !a.pending[streamID] {
+			a.releaseLocked(streamID)
+		}
+	}
+}
+
+// HookHandler 创建在RTP接收端口被ZLMediaKit关闭后释放分配记录的HookHandler
+// on_rtp_server_timeout释放超时的端口，on_server_started释放所有端口，再调用next，next为nil时使用NopHookHandler
+func (a *Allocator) HookHandler(next zlmedia.HookHandler) zlmedia.HookHandler {
+	if next == nil {
+		next = zlmedia.NopHookHandler{}
+	}
+	return &allocatorHookHandler{HookHandler: next, allocator: a}
+}
+
+// allocatorHookHandler 释放分配记录的HookHandler
+type allocatorHookHandler struct {
+	zlmedia.HookHandler
+	allocator *Allocator
+}
+
+// OnRtpServerTimeout 端口长时间未收到数据已被ZLMediaKit关闭
+func (h *allocatorHookHandler) OnRtpServerTimeout(ctx context.Context, hook *zlmedia.OnRtpServerTimeoutHook) error {
+	if h.matches(hook.MediaServerID) {
+		h.allocator.Release(hook.StreamID)
+	}
+	return h.HookHandler.OnRtpServerTimeout(ctx, hook)
+}
+
+// OnServerStarted 服务器重启后所有RTP接收端口都已关闭
+func (h *allocatorHookHandler) OnServerStarted(ctx context.Context, hook *zlmedia.OnServerStartedHook) error {
+	if h.matches(hook.MediaServerID()) {
+		h.allocator.releaseAll()
+	}
+	return h.HookHandler.OnServerStarted(ctx, hook)
+}
+
+// matches 事件是否来自分配器对应的节点
+func (h *allocatorHookHandler) matches(mediaServerID string) bool {
+	serverID := h.allocator.config.MediaServerID
+	return serverID == "" || serverID == mediaServerID
+}
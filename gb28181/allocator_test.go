@@ -0,0 +1,167 @@
+package gb28181_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/gb28181"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+func TestAllocatorOpen(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	allocator := gb28181.NewAllocator(zlm.Client(), gb28181.AllocatorConfig{Domain: "3402000000", PortMin: 30000, PortMax: 30001})
+	ctx := context.Background()
+
+	// 按顺序执行，每一步在上一步的基础上分配
+	tests := []struct {
+		name     string
+		req      gb28181.OpenRequest
+		wantSSRC string
+		wantPort int
+		wantErr  error
+	}{
+		{"实时点播", gb28181.OpenRequest{StreamID: "a"}, "0200000001", 30000, nil},
+		{"历史回放", gb28181.OpenRequest{StreamID: "b", Playback: true}, "1200000001", 30001, nil},
+		{"流id重复", gb28181.OpenRequest{StreamID: "a"}, "", 0, gb28181.ErrStreamAllocated},
+		{"端口用尽", gb28181.OpenRequest{StreamID: "c"}, "", 0, gb28181.ErrPortExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocation, err := allocator.Open(ctx, &tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Open() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if allocation.SSRC != tt.wantSSRC || allocation.Port != tt.wantPort {
+				t.Errorf("Open() = %s:%d, want %s:%d", allocation.SSRC, allocation.Port, tt.wantSSRC, tt.wantPort)
+			}
+			if got := zlm.RtpServerSSRC(tt.req.StreamID); got != tt.wantSSRC {
+				t.Errorf("RtpServerSSRC(%q) = %q, want %q", tt.req.StreamID, got, tt.wantSSRC)
+			}
+		})
+	}
+	if got := len(zlm.RtpServers()); got != 2 {
+		t.Errorf("len(RtpServers()) = %d, want 2", got)
+	}
+}
+
+func TestAllocatorClose(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  func(zlm *zlmtest.Server)
+		wantErr bool
+	}{
+		{"关闭端口", nil, false},
+		{"端口已被ZLMediaKit关闭", func(zlm *zlmtest.Server) { zlm.Restart() }, false},
+		{"关闭失败", func(zlm *zlmtest.Server) {
+			zlm.InjectFault("/index/api/closeRtpServer", zlmtest.Fault{Code: zlmedia.CodeException, Msg: "boom"})
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm := zlmtest.NewServer()
+			defer zlm.Close()
+			allocator := gb28181.NewAllocator(zlm.Client(), gb28181.AllocatorConfig{PortMin: 30000, PortMax: 30000})
+			ctx := context.Background()
+			if _, err := allocator.Open(ctx, &gb28181.OpenRequest{StreamID: "a"}); err != nil {
+				t.Fatal(err)
+			}
+			if tt.before != nil {
+				tt.before(zlm)
+			}
+
+			if err := allocator.Close(ctx, "a"); (err != nil) != tt.wantErr {
+				t.Fatalf("Close() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			// 无论是否关闭成功，ssrc和端口都已释放
+			if _, ok := allocator.Lookup("a"); ok {
+				t.Error("Close()后仍有分配记录")
+			}
+			zlm.ClearFaults()
+			zlm.Restart()
+			if _, err := allocator.Open(ctx, &gb28181.OpenRequest{StreamID: "a"}); err != nil {
+				t.Errorf("重新分配失败: %v", err)
+			}
+		})
+	}
+}
+
+func TestAllocatorReleaseWhileOpening(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	allocator := gb28181.NewAllocator(zlm.Client(), gb28181.AllocatorConfig{PortMin: 30000, PortMax: 30001})
+	hooks := allocator.HookHandler(nil)
+	ctx := context.Background()
+
+	zlm.InjectFault("/index/api/openRtpServer", zlmtest.Fault{Delay: 50 * time.Millisecond, Times: 1})
+	done := make(chan error, 1)
+	go func() {
+		_, err := allocator.Open(ctx, &gb28181.OpenRequest{StreamID: "a"})
+		done <- err
+	}()
+	for zlm.RequestCount("/index/api/openRtpServer") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := hooks.OnRtpServerTimeout(ctx, &zlmedia.OnRtpServerTimeoutHook{StreamID: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; !errors.Is(err, gb28181.ErrReleased) {
+		t.Fatalf("Open() error = %v, want ErrReleased", err)
+	}
+	if got := allocator.Allocations(); len(got) != 0 {
+		t.Errorf("Allocations() = %+v, want none", got)
+	}
+
+	// 被释放的端口没有被记录，可以再次分配
+	zlm.Restart()
+	for _, streamID := range []string{"a", "b"} {
+		if _, err := allocator.Open(ctx, &gb28181.OpenRequest{StreamID: streamID}); err != nil {
+			t.Fatalf("Open(%s) error = %v", streamID, err)
+		}
+	}
+}
+
+func TestAllocatorHooks(t *testing.T) {
+	tests := []struct {
+		name string
+		hook func(ctx context.Context, hooks zlmedia.HookHandler) error
+		want int
+	}{
+		{"端口超时", func(ctx context.Context, hooks zlmedia.HookHandler) error {
+			return hooks.OnRtpServerTimeout(ctx, &zlmedia.OnRtpServerTimeoutHook{HookBase: zlmedia.HookBase{MediaServerID: "node1"}, StreamID: "a"})
+		}, 1},
+		{"其它节点的端口超时", func(ctx context.Context, hooks zlmedia.HookHandler) error {
+			return hooks.OnRtpServerTimeout(ctx, &zlmedia.OnRtpServerTimeoutHook{HookBase: zlmedia.HookBase{MediaServerID: "node2"}, StreamID: "a"})
+		}, 2},
+		{"服务器重启", func(ctx context.Context, hooks zlmedia.HookHandler) error {
+			return hooks.OnServerStarted(ctx, &zlmedia.OnServerStartedHook{Config: map[string]string{"general.mediaServerId": "node1"}})
+		}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm := zlmtest.NewServer()
+			defer zlm.Close()
+			allocator := gb28181.NewAllocator(zlm.Client(), gb28181.AllocatorConfig{MediaServerID: "node1"})
+			ctx := context.Background()
+			for _, streamID := range []string{"a", "b"} {
+				if _, err := allocator.Open(ctx, &gb28181.OpenRequest{StreamID: streamID}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := tt.hook(ctx, allocator.HookHandler(nil)); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(allocator.Allocations()); got != tt.want {
+				t.Errorf("len(Allocations()) = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package gb28181
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// manscdpContentType GB28181控制协议的消息体类型
+const manscdpContentType = "Application/MANSCDP+xml"
+
+// Channel 设备目录中的通道
+type Channel struct {
+	DeviceID     string  `xml:"DeviceID" json:"device_id"`        // 通道编码
+	Name         string  `xml:"Name" json:"name"`                 // 通道名称
+	Manufacturer string  `xml:"Manufacturer" json:"manufacturer"` // 厂商
+	Model        string  `xml:"Model" json:"model"`               // 型号
+	Owner        string  `xml:"Owner" json:"owner"`               // 归属
+	CivilCode    string  `xml:"CivilCode" json:"civil_code"`      // 行政区划
+	Address      string  `xml:"Address" json:"address"`           // 安装地址
+	Parental     int     `xml:"Parental" json:"parental"`         // 是否有子设备，1为有
+	ParentID     string  `xml:"ParentID" json:"parent_id"`        // 父设备编码
+	RegisterWay  int     `xml:"RegisterWay" json:"register_way"`  // 注册方式
+	Secrecy      int     `xml:"Secrecy" json:"secrecy"`           // 保密属性，0为不涉密
+	IPAddress    string  `xml:"IPAddress" json:"ip_address"`      // ip地址
+	Port         int     `xml:"Port" json:"port"`                 // 端口
+	Status       string  `xml:"Status" json:"status"`             // 状态，ON或OFF
+	Longitude    float64 `xml:"Longitude" json:"longitude"`       // 经度
+	Latitude     float64 `xml:"Latitude" json:"latitude"`         // 纬度
+}
+
+// manscdpMessage 设备发送的MANSCDP消息，只解析用到的字段
+type manscdpMessage struct {
+	XMLName    xml.Name
+	CmdType    string `xml:"CmdType"`
+	SN         int    `xml:"SN"`
+	DeviceID   string `xml:"DeviceID"`
+	SumNum     int    `xml:"SumNum"`
+	DeviceList struct {
+		Items []Channel `xml:"Item"`
+	} `xml:"DeviceList"`
+}
+
+// catalogKey 目录查询的标识
+type catalogKey struct {
+	deviceID string
+	sn       int
+}
+
+// catalogQuery 进行中的目录查询，设备可能分多条消息返回目录
+type catalogQuery struct {
+	channels map[string]Channel
+	order    []string
+	sumNum   int
+	done     chan struct{}
+}
+
+// QueryCatalog 查询设备目录
+// 发送Catalog查询并等待设备返回全部通道，结果会保存到设备信息中
+// 参数:
+//   - deviceID: 设备编码
+//
+// 返回: 通道列表
+func (s *Server) QueryCatalog(ctx context.Context, deviceID string) ([]Channel, error) {
+	d, err := s.onlineDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.sn++
+	key := catalogKey{deviceID: deviceID, sn: s.sn}
+	query := &catalogQuery{channels: make(map[string]Channel), sumNum: -1, done: make(chan struct{})}
+	s.catalogs[key] = query
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.catalogs, key)
+		s.mu.Unlock()
+	}()
+
+	req := s.newRequest(MethodMessage, d, deviceID)
+	req.Header.Set("Content-Type", manscdpContentType)
+	req.Body = fmt.Appendf(nil, `<?xml version="1.0" encoding="GB2312"?>`+"\r\n"+
+		"<Query>\r\n<CmdType>Catalog</CmdType>\r\n<SN>%d</SN>\r\n<DeviceID>%s</DeviceID>\r\n</Query>\r\n", key.sn, deviceID)
+	if _, err := s.request(ctx, d.conn, req); err != nil {
+		return nil, fmt.Errorf("发送目录查询失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.RequestTimeout)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("等待设备目录超时: %w", ctx.Err())
+	case <-query.done:
+	}
+
+	s.mu.Lock()
+	channels := make([]Channel, 0, len(query.order))
+	for _, id := range query.order {
+		channels = append(channels, query.channels[id])
+	}
+	if d, ok := s.devices[deviceID]; ok {
+		d.Channels = channels
+	}
+	s.mu.Unlock()
+
+	if s.config.OnCatalog != nil {
+		s.config.OnCatalog(deviceID, channels)
+	}
+	return append([]Channel(nil), channels...), nil
+}
+
+// handleMessage 处理设备发送的MESSAGE，包括心跳和目录响应
+func (s *Server) handleMessage(req *Message) {
+	msg, err := s.parseMANSCDP(req.Body)
+	if err != nil {
+		s.reply(req, 400, "Bad Request")
+		s.reportError(fmt.Errorf("解析MESSAGE消息体失败: %w", err))
+		return
+	}
+
+	deviceID := addressUser(req.Header.Get("From"))
+	switch {
+	case msg.XMLName.Local == "Notify" && msg.CmdType == "Keepalive":
+		if !s.keepalive(deviceID, req.source) {
+			// 设备未注册，回复404使设备重新注册
+			s.reply(req, 404, "Not Found")
+			return
+		}
+		s.reply(req, 200, "OK")
+	case msg.XMLName.Local == "Response" && msg.CmdType == "Catalog":
+		s.reply(req, 200, "OK")
+		s.addCatalogItems(deviceID, msg)
+	default:
+		s.reply(req, 200, "OK")
+	}
+}
+
+// addCatalogItems 将设备返回的目录加入进行中的查询，收齐SumNum个通道后结束查询
+// UDP重传的消息按通道编码去重
+func (s *Server) addCatalogItems(deviceID string, msg *manscdpMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query, ok := s.catalogs[catalogKey{deviceID: deviceID, sn: msg.SN}]
+	if !ok || query.sumNum == len(query.channels) {
+		return
+	}
+	query.sumNum = msg.SumNum
+	for _, channel := range msg.DeviceList.Items {
+		if _, ok := query.channels[channel.DeviceID]; !ok {
+			query.order = append(query.order, channel.DeviceID)
+		}
+		query.channels[channel.DeviceID] = channel
+	}
+	if len(query.channels) >= query.sumNum {
+		query.sumNum = len(query.channels)
+		close(query.done)
+	}
+}
+
+// parseMANSCDP 解析MANSCDP消息体
+func (s *Server) parseMANSCDP(body []byte) (*manscdpMessage, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = s.config.CharsetReader
+	if decoder.CharsetReader == nil {
+		decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	}
+
+	msg := &manscdpMessage{}
+	if err := decoder.Decode(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// sortedIDs 获取按编码排序的键
+func sortedIDs[V any](m map[string]V) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
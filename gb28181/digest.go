@@ -0,0 +1,122 @@
+package gb28181
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nonceTTL 摘要认证nonce的有效期
+const nonceTTL = 5 * time.Minute
+
+// digestAuth 摘要认证，见RFC 2617
+// nonce由时间戳和HMAC组成，服务端不需要保存已下发的nonce
+type digestAuth struct {
+	realm  string
+	secret []byte
+	now    func() time.Time
+}
+
+// newDigestAuth 创建摘要认证
+func newDigestAuth(realm string) *digestAuth {
+	return &digestAuth{realm: realm, secret: []byte(randomHex(32)), now: time.Now}
+}
+
+// challenge 生成WWW-Authenticate
+func (d *digestAuth) challenge() string {
+	return fmt.Sprintf(`Digest realm="%s",nonce="%s",algorithm=MD5`, d.realm, d.nonce(d.now()))
+}
+
+// nonce 生成指定时间的nonce
+func (d *digestAuth) nonce(t time.Time) string {
+	timestamp := strconv.FormatInt(t.Unix(), 16)
+	mac := hmac.New(sha256.New, d.secret)
+	mac.Write([]byte(timestamp))
+	return timestamp + hex.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// validNonce 检查nonce是否由本服务生成且未过期
+func (d *digestAuth) validNonce(nonce string) bool {
+	if len(nonce) <= 24 {
+		return false
+	}
+	seconds, err := strconv.ParseInt(nonce[:len(nonce)-24], 16, 64)
+	if err != nil {
+		return false
+	}
+	issued := time.Unix(seconds, 0)
+	if d.now().Sub(issued) > nonceTTL {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(d.nonce(issued)), []byte(nonce)) == 1
+}
+
+// verify 校验Authorization
+// 参数:
+//   - method: 请求方法，例如REGISTER
+//   - authorization: Authorization消息头
+//   - username: 期望的用户名，GB28181中为设备编码
+//   - password: 设备密码
+func (d *digestAuth) verify(method, authorization, username, password string) bool {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(authorization), " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return false
+	}
+	params := parseDigestParams(rest)
+	if params["username"] != username || params["realm"] != d.realm || !d.validNonce(params["nonce"]) {
+		return false
+	}
+	if algorithm := params["algorithm"]; algorithm != "" && !strings.EqualFold(algorithm, "MD5") {
+		return false
+	}
+
+	ha1 := md5Hex(username + ":" + d.realm + ":" + password)
+	ha2 := md5Hex(method + ":" + params["uri"])
+	var expected string
+	if qop := params["qop"]; qop != "" {
+		expected = md5Hex(strings.Join([]string{ha1, params["nonce"], params["nc"], params["cnonce"], qop, ha2}, ":"))
+	} else {
+		expected = md5Hex(ha1 + ":" + params["nonce"] + ":" + ha2)
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(params["response"]))) == 1
+}
+
+// parseDigestParams 解析摘要认证的参数，例如username="x",realm="y"
+func parseDigestParams(value string) map[string]string {
+	params := make(map[string]string)
+	for value != "" {
+		var key, paramValue string
+		key, value, _ = strings.Cut(value, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				paramValue, value = value[1:], ""
+			} else {
+				paramValue, value = value[1:end+1], value[end+2:]
+			}
+			_, value, _ = strings.Cut(value, ",")
+		} else {
+			paramValue, value, _ = strings.Cut(value, ",")
+			paramValue = strings.TrimSpace(paramValue)
+		}
+		if key != "" {
+			params[key] = paramValue
+		}
+	}
+	return params
+}
+
+// md5Hex 计算md5并返回十六进制字符串
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
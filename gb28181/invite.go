@@ -0,0 +1,337 @@
+package gb28181
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// rtpApp ZLMediaKit中openRtpServer创建的流所属的应用名
+const rtpApp = "rtp"
+
+// PlayOptions 实时点播参数
+type PlayOptions struct {
+	StreamID string // ZLMediaKit中的流id，默认为"设备编码_通道编码"
+	TCP      bool   // 设备是否使用TCP主动连接ZLMediaKit发送RTP，默认使用UDP
+}
+
+// Session 点播会话
+type Session struct {
+	ID        string            // 会话id，即INVITE的Call-ID
+	DeviceID  string            // 设备编码
+	ChannelID string            // 通道编码
+	SSRC      string            // 点播使用的ssrc
+	Port      int               // ZLMediaKit的RTP接收端口
+	TCP       bool              // 是否使用TCP传输RTP
+	Key       zlmedia.StreamKey // ZLMediaKit中的流
+	StartedAt time.Time         // 开始时间
+}
+
+// session 点播会话及其SIP对话
+type session struct {
+	Session
+	conn   *connection
+	target string // 设备的Contact，后续请求的请求URI
+	from   string // 本端的From，带tag
+	to     string // 设备的To，带tag
+	cseq   uint32
+}
+
+// Play 向设备发起实时点播
+// 先通过分配器分配ssrc并创建RTP接收端口，再发送INVITE，设备应答后发送ACK；
+// 点播失败时会关闭创建的RTP接收端口
+// 参数:
+//   - deviceID: 设备编码
+//   - channelID: 通道编码
+//   - options: 点播参数，可以为nil
+//
+// 返回: 点播会话，流在ZLMediaKit中为rtp/StreamID
+func (s *Server) Play(ctx context.Context, deviceID, channelID string, options *PlayOptions) (*Session, error) {
+	if options == nil {
+		options = &PlayOptions{}
+	}
+	d, err := s.onlineDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	streamID := options.StreamID
+	if streamID == "" {
+		streamID = deviceID + "_" + channelID
+	}
+	allocation, err := s.allocator.Open(ctx, &OpenRequest{StreamID: streamID, TCP: options.TCP})
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.invite(ctx, d, channelID, allocation.SSRC, allocation.Port, options.TCP)
+	if err != nil {
+		if closeErr := s.allocator.Close(context.WithoutCancel(ctx), streamID); closeErr != nil {
+			err = errors.Join(err, closeErr)
+		}
+		return nil, err
+	}
+	sess.Key = zlmedia.StreamKey{VHost: zlmedia.DefaultVHost, App: rtpApp, Stream: streamID}
+
+	s.mu.Lock()
+	s.sessions[sess.ID] = sess
+	s.mu.Unlock()
+	return sessionSnapshot(sess), nil
+}
+
+// invite 发送INVITE并在应答后发送ACK
+// 等待应答超时或ctx取消时在后台发送CANCEL；ACK发送失败或设备应答的ssrc与请求的不一致时发送BYE结束会话，
+// 分配的ssrc已通过openRtpServer设置为ZLMediaKit的ssrc过滤条件，不能使用设备应答的其它ssrc
+func (s *Server) invite(ctx context.Context, d *device, channelID, ssrc string, port int, tcp bool) (*session, error) {
+	req := s.newRequest(MethodInvite, d, channelID)
+	req.Header.Set("Contact", s.contact(d.conn))
+	req.Header.Set("Subject", fmt.Sprintf("%s:%s,%s:0", channelID, ssrc, s.config.ServerID))
+	req.Header.Set("Content-Type", "APPLICATION/SDP")
+	req.Body = []byte(s.offer(channelID, ssrc, port, tcp))
+
+	responses, done := s.startTransaction(req)
+	resp, err := s.roundTrip(ctx, d.conn, req, responses)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		// 设备可能仍在振铃，事务由cancelInvite结束
+		go func() {
+			defer done()
+			s.cancelInvite(d, req, responses)
+		}()
+		return nil, fmt.Errorf("点播通道%s失败: %w", channelID, err)
+	}
+	done()
+	if err != nil {
+		if resp != nil {
+			s.send(d.conn, failureAck(req, resp))
+		}
+		return nil, fmt.Errorf("点播通道%s失败: %w", channelID, err)
+	}
+
+	sess := newSession(d, req, resp)
+	sess.ChannelID, sess.SSRC, sess.Port, sess.TCP = channelID, ssrc, port, tcp
+	if err := sess.conn.send(sess.newRequest(s, MethodAck, sess.cseq)); err != nil {
+		// 设备收不到ACK会一直重传应答，直接结束会话
+		return nil, errors.Join(fmt.Errorf("发送ACK失败: %w", err), s.bye(context.WithoutCancel(ctx), sess))
+	}
+	if answer, err := zlmedia.ParseSDP(string(resp.Body)); err == nil {
+		if answerSSRC := sdpSSRC(answer); answerSSRC != "" && answerSSRC != ssrc {
+			err := fmt.Errorf("%w: 请求%s，应答%s", ErrSSRCMismatch, ssrc, answerSSRC)
+			return nil, errors.Join(err, s.bye(context.WithoutCancel(ctx), sess))
+		}
+	}
+	return sess, nil
+}
+
+// newSession 根据INVITE及其2xx应答创建对话
+func newSession(d *device, req, resp *Message) *session {
+	sess := &session{
+		Session: Session{
+			ID:        req.CallID(),
+			DeviceID:  d.ID,
+			StartedAt: time.Now(),
+		},
+		conn:   d.conn,
+		target: req.RequestURI,
+		from:   req.Header.Get("From"),
+		to:     resp.Header.Get("To"),
+	}
+	sess.cseq, _ = req.CSeq()
+	if contact := resp.Header.Get("Contact"); contact != "" {
+		sess.target = addressURI(contact)
+	}
+	return sess
+}
+
+// bye 向设备发送BYE结束对话
+func (s *Server) bye(ctx context.Context, sess *session) error {
+	sess.cseq++
+	if _, err := s.request(ctx, sess.conn, sess.newRequest(s, MethodBye, sess.cseq)); err != nil {
+		return fmt.Errorf("结束点播会话%s失败: %w", sess.ID, err)
+	}
+	return nil
+}
+
+// cancelInvite 发送CANCEL取消未应答的INVITE，并处理INVITE的最终响应
+// 设备在收到CANCEL前已经接通时发送ACK和BYE结束会话，其它最终响应发送ACK
+func (s *Server) cancelInvite(d *device, invite *Message, responses <-chan *Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.RequestTimeout)
+	defer cancel()
+
+	// 481表示INVITE事务已经结束，仍然需要处理INVITE的最终响应
+	var respErr *ResponseError
+	if _, err := s.request(ctx, d.conn, cancelRequest(invite)); err != nil && !(errors.As(err, &respErr) && respErr.StatusCode == 481) {
+		s.reportError(fmt.Errorf("取消点播%s失败: %w", invite.CallID(), err))
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case resp := <-responses:
+			switch {
+			case resp.StatusCode < 200:
+				continue
+			case resp.StatusCode >= 300:
+				s.send(d.conn, failureAck(invite, resp))
+			default:
+				sess := newSession(d, invite, resp)
+				s.send(d.conn, sess.newRequest(s, MethodAck, sess.cseq))
+				if err := s.bye(ctx, sess); err != nil {
+					s.reportError(err)
+				}
+			}
+			return
+		}
+	}
+}
+
+// cancelRequest 创建取消INVITE的CANCEL，除CSeq的方法外与INVITE的Via、From、To、Call-ID和CSeq相同
+func cancelRequest(invite *Message) *Message {
+	cseq, _ := invite.CSeq()
+	req := &Message{Method: MethodCancel, RequestURI: invite.RequestURI}
+	req.Header.Add("Via", invite.Header.Get("Via"))
+	req.Header.Add("From", invite.Header.Get("From"))
+	req.Header.Add("To", invite.Header.Get("To"))
+	req.Header.Add("Call-ID", invite.CallID())
+	req.Header.Add("CSeq", strconv.FormatUint(uint64(cseq), 10)+" "+MethodCancel)
+	req.Header.Add("Max-Forwards", "70")
+	return req
+}
+
+// failureAck 创建INVITE非2xx最终响应的ACK，属于INVITE事务，与INVITE使用相同的Via
+func failureAck(invite, resp *Message) *Message {
+	cseq, _ := invite.CSeq()
+	ack := &Message{Method: MethodAck, RequestURI: invite.RequestURI}
+	ack.Header.Add("Via", invite.Header.Get("Via"))
+	ack.Header.Add("From", invite.Header.Get("From"))
+	ack.Header.Add("To", resp.Header.Get("To"))
+	ack.Header.Add("Call-ID", invite.CallID())
+	ack.Header.Add("CSeq", strconv.FormatUint(uint64(cseq), 10)+" "+MethodAck)
+	ack.Header.Add("Max-Forwards", "70")
+	return ack
+}
+
+// offer 生成INVITE的sdp，ZLMediaKit为接收端
+func (s *Server) offer(channelID, ssrc string, port int, tcp bool) string {
+	media := &zlmedia.MediaDescription{
+		Media:   "video",
+		Port:    port,
+		Proto:   "RTP/AVP",
+		Formats: []string{"96", "97", "98"},
+		Attributes: zlmedia.SDPAttributes{
+			{Key: "recvonly"},
+			{Key: "rtpmap", Value: "96 PS/90000"},
+			{Key: "rtpmap", Value: "97 MPEG4/90000"},
+			{Key: "rtpmap", Value: "98 H264/90000"},
+		},
+	}
+	if tcp {
+		// ZLMediaKit被动监听，设备主动连接
+		media.Proto = "TCP/RTP/AVP"
+		media.Attributes = append(media.Attributes, zlmedia.SDPAttribute{Key: "setup", Value: "passive"}, zlmedia.SDPAttribute{Key: "connection", Value: "new"})
+	}
+
+	offer := &zlmedia.SessionDescription{
+		Origin:      fmt.Sprintf("%s 0 0 IN IP4 %s", channelID, s.config.MediaIP),
+		SessionName: "Play",
+		Fields: []zlmedia.SDPField{
+			{Type: 'c', Value: "IN IP4 " + s.config.MediaIP},
+			{Type: 't', Value: "0 0"},
+		},
+		Media: []*zlmedia.MediaDescription{media},
+	}
+	// GB28181扩展的y=需要写在媒体段的最后
+	return offer.Marshal() + "y=" + ssrc + "\r\n"
+}
+
+// Stop 结束点播会话
+// 向设备发送BYE并关闭RTP接收端口
+func (s *Server) Stop(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	sess, ok := s.sessions[sessionID]
+	delete(s.sessions, sessionID)
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+
+	var errs []error
+	if err := s.bye(ctx, sess); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.allocator.Close(ctx, sess.Key.Stream); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Sessions 获取所有点播会话
+func (s *Server) Sessions() []Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := make([]Session, 0, len(s.sessions))
+	for _, id := range sortedIDs(s.sessions) {
+		sessions = append(sessions, *sessionSnapshot(s.sessions[id]))
+	}
+	return sessions
+}
+
+// handleBye 处理设备发送的BYE，关闭对应的RTP接收端口
+func (s *Server) handleBye(req *Message) {
+	s.mu.Lock()
+	sess, ok := s.sessions[req.CallID()]
+	delete(s.sessions, req.CallID())
+	s.mu.Unlock()
+	if !ok {
+		s.reply(req, 481, "Call/Transaction Does Not Exist")
+		return
+	}
+	s.reply(req, 200, "OK")
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.RequestTimeout)
+	defer cancel()
+	if err := s.allocator.Close(ctx, sess.Key.Stream); err != nil {
+		s.reportError(fmt.Errorf("关闭点播会话%s的RTP接收端口失败: %w", sess.ID, err))
+	}
+	if s.config.OnSessionClosed != nil {
+		s.config.OnSessionClosed(*sessionSnapshot(sess))
+	}
+}
+
+// newRequest 创建对话内的请求，例如ACK和BYE
+func (sess *session) newRequest(s *Server, method string, cseq uint32) *Message {
+	req := &Message{Method: method, RequestURI: sess.target}
+	req.Header.Add("Via", s.via(sess.conn))
+	req.Header.Add("From", sess.from)
+	req.Header.Add("To", sess.to)
+	req.Header.Add("Call-ID", sess.ID)
+	req.Header.Add("CSeq", strconv.FormatUint(uint64(cseq), 10)+" "+method)
+	req.Header.Add("Max-Forwards", "70")
+	return req
+}
+
+// sessionSnapshot 复制会话信息
+func sessionSnapshot(sess *session) *Session {
+	snapshot := sess.Session
+	return &snapshot
+}
+
+// sdpSSRC 获取GB28181扩展的y=中的ssrc
+func sdpSSRC(sdp *zlmedia.SessionDescription) string {
+	fields := append([]zlmedia.SDPField(nil), sdp.Fields...)
+	for _, m := range sdp.Media {
+		fields = append(fields, m.Fields...)
+	}
+	for _, field := range fields {
+		if field.Type == 'y' {
+			return strings.TrimSpace(field.Value)
+		}
+	}
+	return ""
+}
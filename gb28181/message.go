@@ -0,0 +1,337 @@
+package gb28181
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// sipVersion SIP协议版本
+const sipVersion = "SIP/2.0"
+
+// maxMessageSize 单个SIP消息的最大字节数
+const maxMessageSize = 64 << 10
+
+// SIP方法
+const (
+	MethodRegister = "REGISTER"
+	MethodMessage  = "MESSAGE"
+	MethodInvite   = "INVITE"
+	MethodAck      = "ACK"
+	MethodBye      = "BYE"
+	MethodCancel   = "CANCEL"
+	MethodOptions  = "OPTIONS"
+)
+
+// compactHeaders SIP头的简写形式，见RFC 3261 7.3.3
+var compactHeaders = map[string]string{
+	"V": "Via",
+	"F": "From",
+	"T": "To",
+	"I": "Call-ID",
+	"M": "Contact",
+	"L": "Content-Length",
+	"C": "Content-Type",
+	"S": "Subject",
+	"K": "Supported",
+}
+
+// Header SIP消息头，保持原有顺序，名称不区分大小写
+type Header []HeaderField
+
+// HeaderField SIP消息头的一行
+type HeaderField struct {
+	Name  string
+	Value string
+}
+
+// canonicalHeader 规范化消息头名称，展开简写形式
+func canonicalHeader(name string) string {
+	name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+	if full, ok := compactHeaders[name]; ok {
+		return full
+	}
+	switch name {
+	case "Call-Id":
+		return "Call-ID"
+	case "Cseq":
+		return "CSeq"
+	case "Www-Authenticate":
+		return "WWW-Authenticate"
+	}
+	return name
+}
+
+// Get 获取第一个指定名称的消息头
+func (h Header) Get(name string) string {
+	name = canonicalHeader(name)
+	for _, field := range h {
+		if field.Name == name {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Values 获取所有指定名称的消息头
+func (h Header) Values(name string) []string {
+	name = canonicalHeader(name)
+	var values []string
+	for _, field := range h {
+		if field.Name == name {
+			values = append(values, field.Value)
+		}
+	}
+	return values
+}
+
+// Add 追加消息头
+func (h *Header) Add(name, value string) {
+	*h = append(*h, HeaderField{Name: canonicalHeader(name), Value: value})
+}
+
+// Set 设置消息头，替换所有同名消息头
+func (h *Header) Set(name, value string) {
+	name = canonicalHeader(name)
+	for i, field := range *h {
+		if field.Name == name {
+			(*h)[i].Value = value
+			h.del(name, i+1)
+			return
+		}
+	}
+	*h = append(*h, HeaderField{Name: name, Value: value})
+}
+
+// Del 删除所有指定名称的消息头
+func (h *Header) Del(name string) {
+	h.del(canonicalHeader(name), 0)
+}
+
+// del 删除from之后所有指定名称的消息头
+func (h *Header) del(name string, from int) {
+	kept := (*h)[:from]
+	for _, field := range (*h)[from:] {
+		if field.Name != name {
+			kept = append(kept, field)
+		}
+	}
+	*h = kept
+}
+
+// Message SIP请求或响应
+type Message struct {
+	Method     string // 请求方法，响应为空
+	RequestURI string // 请求URI，例如sip:34020000001320000001@3402000000
+	StatusCode int    // 响应状态码，请求为0
+	Reason     string // 响应原因短语
+	Header     Header // 消息头
+	Body       []byte // 消息体
+
+	// 消息来源，由传输层设置
+	source *connection
+}
+
+// IsRequest 是否为请求
+func (m *Message) IsRequest() bool {
+	return m.Method != ""
+}
+
+// CallID 获取Call-ID
+func (m *Message) CallID() string {
+	return m.Header.Get("Call-ID")
+}
+
+// CSeq 获取CSeq的序号和方法
+func (m *Message) CSeq() (uint32, string) {
+	seqValue, method, _ := strings.Cut(strings.TrimSpace(m.Header.Get("CSeq")), " ")
+	seq, _ := strconv.ParseUint(seqValue, 10, 32)
+	return uint32(seq), strings.TrimSpace(method)
+}
+
+// Branch 获取第一个Via的branch参数，用于匹配事务
+func (m *Message) Branch() string {
+	return headerParam(m.Header.Get("Via"), "branch")
+}
+
+// Marshal 序列化SIP消息，自动设置Content-Length
+func (m *Message) Marshal() []byte {
+	var b bytes.Buffer
+	if m.IsRequest() {
+		fmt.Fprintf(&b, "%s %s %s\r\n", m.Method, m.RequestURI, sipVersion)
+	} else {
+		fmt.Fprintf(&b, "%s %d %s\r\n", sipVersion, m.StatusCode, m.Reason)
+	}
+	for _, field := range m.Header {
+		if field.Name == "Content-Length" {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s\r\n", field.Name, field.Value)
+	}
+	fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n", len(m.Body))
+	b.Write(m.Body)
+	return b.Bytes()
+}
+
+// String 返回序列化后的消息，便于日志输出
+func (m *Message) String() string {
+	return string(m.Marshal())
+}
+
+// ParseMessage 解析一个完整的SIP消息，例如一个UDP数据包
+func ParseMessage(data []byte) (*Message, error) {
+	return readMessage(bufio.NewReader(bytes.NewReader(data)), true)
+}
+
+// readMessage 从流中读取一个SIP消息
+// 参数:
+//   - datagram: 是否为UDP数据包，数据包没有Content-Length时消息体为剩余的全部内容
+func readMessage(r *bufio.Reader, datagram bool) (*Message, error) {
+	tp := textproto.NewReader(r)
+
+	// 跳过TCP保活发送的空行
+	var line string
+	for line == "" {
+		var err error
+		if line, err = tp.ReadLine(); err != nil {
+			return nil, err
+		}
+	}
+
+	m := &Message{}
+	first := strings.SplitN(line, " ", 3)
+	if len(first) != 3 {
+		return nil, fmt.Errorf("SIP起始行格式错误: %q", line)
+	}
+	if first[0] == sipVersion {
+		code, err := strconv.Atoi(first[1])
+		if err != nil {
+			return nil, fmt.Errorf("SIP状态码错误: %q", line)
+		}
+		m.StatusCode, m.Reason = code, first[2]
+	} else {
+		if first[2] != sipVersion {
+			return nil, fmt.Errorf("不支持的SIP版本: %q", line)
+		}
+		m.Method, m.RequestURI = first[0], first[1]
+	}
+
+	for {
+		headerLine, err := tp.ReadContinuedLine()
+		if err != nil {
+			return nil, fmt.Errorf("读取SIP消息头失败: %w", err)
+		}
+		if headerLine == "" {
+			break
+		}
+		name, value, ok := strings.Cut(headerLine, ":")
+		if !ok {
+			return nil, fmt.Errorf("SIP消息头格式错误: %q", headerLine)
+		}
+		m.Header.Add(name, strings.TrimSpace(value))
+	}
+
+	lengthValue := m.Header.Get("Content-Length")
+	switch {
+	case lengthValue != "":
+		length, err := strconv.Atoi(lengthValue)
+		if err != nil || length < 0 || length > maxMessageSize {
+			return nil, fmt.Errorf("Content-Length错误: %q", lengthValue)
+		}
+		m.Body = make([]byte, length)
+		if _, err := io.ReadFull(r, m.Body); err != nil {
+			return nil, fmt.Errorf("读取SIP消息体失败: %w", err)
+		}
+	case datagram:
+		body, err := io.ReadAll(io.LimitReader(r, maxMessageSize))
+		if err != nil {
+			return nil, fmt.Errorf("读取SIP消息体失败: %w", err)
+		}
+		m.Body = body
+	}
+	return m, nil
+}
+
+// NewResponse 根据请求创建响应，复制Via、From、To、Call-ID和CSeq
+// To中没有tag时会生成一个新的tag
+func NewResponse(req *Message, code int, reason string) *Message {
+	resp := &Message{StatusCode: code, Reason: reason, source: req.source}
+	for _, via := range req.Header.Values("Via") {
+		resp.Header.Add("Via", via)
+	}
+	resp.Header.Add("From", req.Header.Get("From"))
+	to := req.Header.Get("To")
+	if headerParam(to, "tag") == "" && code > 100 {
+		to += ";tag=" + newTag()
+	}
+	resp.Header.Add("To", to)
+	resp.Header.Add("Call-ID", req.CallID())
+	resp.Header.Add("CSeq", req.Header.Get("CSeq"))
+	return resp
+}
+
+// headerParam 获取消息头中;分隔的参数，例如Via中的branch、To中的tag
+func headerParam(value, name string) string {
+	// 跳过尖括号中的URI，避免匹配到URI参数
+	if end := strings.LastIndex(value, ">"); end >= 0 {
+		value = value[end+1:]
+	}
+	for _, param := range strings.Split(value, ";")[1:] {
+		key, paramValue, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(key, name) {
+			return strings.Trim(paramValue, `"`)
+		}
+	}
+	return ""
+}
+
+// addressUser 获取From、To、Contact或请求URI中的用户部分，GB28181中为设备或通道编码
+// 例如"<sip:34020000001320000001@3402000000>;tag=1"返回34020000001320000001
+func addressUser(value string) string {
+	uri := addressURI(value)
+	uri = strings.TrimPrefix(strings.TrimPrefix(uri, "sips:"), "sip:")
+	user, _, ok := strings.Cut(uri, "@")
+	if !ok {
+		return ""
+	}
+	return user
+}
+
+// addressURI 获取From、To或Contact中的URI
+func addressURI(value string) string {
+	if start := strings.Index(value, "<"); start >= 0 {
+		if end := strings.Index(value[start:], ">"); end >= 0 {
+			return value[start+1 : start+end]
+		}
+	}
+	uri, _, _ := strings.Cut(strings.TrimSpace(value), ";")
+	return uri
+}
+
+// newTag 生成From/To的tag
+func newTag() string {
+	return randomHex(8)
+}
+
+// newBranch 生成Via的branch，以RFC 3261要求的z9hG4bK开头
+func newBranch() string {
+	return "z9hG4bK" + randomHex(12)
+}
+
+// newCallID 生成Call-ID
+func newCallID() string {
+	return randomHex(16)
+}
+
+// randomHex 生成n字节的随机十六进制字符串
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,596 @@
+// Package gb28181 实现GB28181的SIP信令服务，与ZLMediaKit的RTP接收端口配合完成设备注册、目录查询和实时点播
+package gb28181
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// GB28181相关错误
+var (
+	ErrServerClosed    = errors.New("SIP服务已关闭")
+	ErrDeviceNotFound  = errors.New("设备不存在")
+	ErrDeviceOffline   = errors.New("设备离线")
+	ErrSessionNotFound = errors.New("点播会话不存在")
+	ErrSSRCMismatch    = errors.New("设备应答的ssrc与请求的不一致")
+)
+
+// ResponseError 设备返回了非2xx的SIP响应
+type ResponseError struct {
+	Method     string // 请求方法
+	StatusCode int    // SIP状态码
+	Reason     string // 原因短语
+}
+
+// Error 实现error接口
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s请求失败，状态码: %d，原因: %s", e.Method, e.StatusCode, e.Reason)
+}
+
+// dateLayout GB28181注册响应中Date头的时间格式
+const dateLayout = "2006-01-02T15:04:05.000"
+
+// defaultRegisterExpires 注册请求未指定Expires时的有效期
+const defaultRegisterExpires = 3600 * time.Second
+
+// Config SIP服务配置
+type Config struct {
+	ServerID string // SIP服务器编码，20位，例如34020000002000000001
+	Domain   string // SIP域，默认为ServerID的前10位
+	Password string // 设备注册密码，为空时不鉴权
+
+	// 按设备编码获取注册密码，返回空字符串时不鉴权，设置后忽略Password
+	PasswordFunc func(deviceID string) string
+
+	ListenAddr   string // UDP和TCP的监听地址，默认为:5060
+	ExternalAddr string // 设备访问SIP服务使用的地址，写入Via和Contact，默认为连接的本端地址
+	MediaIP      string // ZLMediaKit接收RTP使用的ip，写入INVITE的sdp，默认为客户端BaseURL中的主机名
+
+	// 点播使用的ssrc和RTP接收端口分配器，为nil时使用ZLMediaKit随机端口创建一个新的分配器
+	// 多个服务共用同一个ZLMediaKit节点时应共用一个分配器
+	Allocator *Allocator
+
+	KeepaliveTimeout time.Duration // 超过该时间未收到心跳则设备离线，默认为180秒
+	RequestTimeout   time.Duration // 等待设备响应的超时时间，默认为10秒
+
+	// 解析设备发送的xml时使用的字符集转换，GB28181设备一般使用GB2312编码
+	// 为nil时不做转换，非ASCII字符会保留原始字节，可以使用golang.org/x/text提供的解码器
+	CharsetReader func(charset string, input io.Reader) (io.Reader, error)
+
+	OnDeviceOnline  func(device Device)                       // 设备上线，可以为nil
+	OnDeviceOffline func(device Device)                       // 设备注销或心跳超时，可以为nil
+	OnCatalog       func(deviceID string, channels []Channel) // 收到设备目录，可以为nil
+	OnSessionClosed func(session Session)                     // 设备发送BYE结束点播，可以为nil
+	OnError         func(err error)                           // 处理消息时的错误，可以为nil
+}
+
+// Device 设备信息
+type Device struct {
+	ID            string        // 设备编码
+	Transport     string        // 信令传输协议，UDP或TCP
+	RemoteAddr    string        // 设备的信令地址
+	Online        bool          // 是否在线
+	RegisteredAt  time.Time     // 最近一次注册的时间
+	LastKeepalive time.Time     // 最近一次心跳的时间
+	Expires       time.Duration // 注册有效期
+	Channels      []Channel     // 最近一次查询到的目录
+}
+
+// device 设备及其信令通道
+type device struct {
+	Device
+	conn *connection
+}
+
+// Server GB28181 SIP信令服务
+type Server struct {
+	config    Config
+	allocator *Allocator
+	auth      *digestAuth
+
+	mu           sync.Mutex
+	devices      map[string]*device
+	sessions     map[string]*session
+	transactions map[string]chan *Message
+	catalogs     map[catalogKey]*catalogQuery
+	sn           int
+	cseq         uint32
+	closers      map[io.Closer]struct{}
+	isClosed     bool
+}
+
+// NewServer 创建SIP信令服务
+// 参数:
+//   - client: 接收设备RTP流的ZLMediaKit节点
+//   - config: 服务配置，ServerID必须设置
+//
+// 返回: SIP信令服务，需要调用ListenAndServe或ServeUDP/ServeTCP和Run启动
+func NewServer(client *zlmedia.Client, config Config) (*Server, error) {
+	if len(config.ServerID) != 20 {
+		return nil, fmt.Errorf("SIP服务器编码需要为20位: %q", config.ServerID)
+	}
+	if config.Domain == "" {
+		config.Domain = config.ServerID[:10]
+	}
+	if config.ListenAddr == "" {
+		config.ListenAddr = ":5060"
+	}
+	if config.MediaIP == "" {
+		u, err := url.Parse(client.BaseURL())
+		if err != nil {
+			return nil, fmt.Errorf("解析BaseURL失败: %w", err)
+		}
+		config.MediaIP = u.Hostname()
+	}
+	if config.KeepaliveTimeout <= 0 {
+		config.KeepaliveTimeout = 180 * time.Second
+	}
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = 10 * time.Second
+	}
+
+	if config.Allocator == nil {
+		config.Allocator = NewAllocator(client, AllocatorConfig{Domain: config.Domain})
+	}
+
+	return &Server{
+		config:       config,
+		allocator:    config.Allocator,
+		auth:         newDigestAuth(config.Domain),
+		devices:      make(map[string]*device),
+		sessions:     make(map[string]*session),
+		transactions: make(map[string]chan *Message),
+		catalogs:     make(map[catalogKey]*catalogQuery),
+		closers:      make(map[io.Closer]struct{}),
+	}, nil
+}
+
+// ListenAndServe 在ListenAddr上同时监听UDP和TCP并处理SIP消息，直到ctx取消或监听出错
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	udp, err := net.ListenPacket("udp", s.config.ListenAddr)
+	if err != nil {
+		return fmt.Errorf("监听UDP失败: %w", err)
+	}
+	tcp, err := net.Listen("tcp", s.config.ListenAddr)
+	if err != nil {
+		udp.Close()
+		return fmt.Errorf("监听TCP失败: %w", err)
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- s.ServeUDP(udp) }()
+	go func() { errs <- s.ServeTCP(tcp) }()
+	go s.Run(ctx)
+
+	select {
+	case <-ctx.Done():
+		s.Close()
+		return ctx.Err()
+	case err := <-errs:
+		s.Close()
+		return err
+	}
+}
+
+// Run 定期检查设备的注册有效期和心跳，将超时的设备置为离线，直到ctx取消
+func (s *Server) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.KeepaliveTimeout / 6)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.expireDevices(time.Now())
+		}
+	}
+}
+
+// Close 关闭所有监听和连接
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.isClosed = true
+	closers := make([]io.Closer, 0, len(s.closers))
+	for closer := range s.closers {
+		closers = append(closers, closer)
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Devices 获取所有注册过的设备
+func (s *Server) Devices() []Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	devices := make([]Device, 0, len(s.devices))
+	for _, id := range sortedIDs(s.devices) {
+		devices = append(devices, s.devices[id].snapshot())
+	}
+	return devices
+}
+
+// Device 获取指定编码的设备
+func (s *Server) Device(id string) (Device, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.devices[id]
+	if !ok {
+		return Device{}, false
+	}
+	return d.snapshot(), true
+}
+
+// snapshot 复制设备信息
+func (d *device) snapshot() Device {
+	snapshot := d.Device
+	snapshot.Channels = append([]Channel(nil), d.Channels...)
+	return snapshot
+}
+
+// onlineDevice 获取在线设备的副本
+func (s *Server) onlineDevice(id string) (*device, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.devices[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, id)
+	}
+	if !d.Online {
+		return nil, fmt.Errorf("%w: %s", ErrDeviceOffline, id)
+	}
+	copied := *d
+	return &copied, nil
+}
+
+// dispatch 处理收到的SIP消息
+func (s *Server) dispatch(msg *Message) {
+	if !msg.IsRequest() {
+		s.deliverResponse(msg)
+		return
+	}
+
+	switch msg.Method {
+	case MethodRegister:
+		s.handleRegister(msg)
+	case MethodMessage:
+		s.handleMessage(msg)
+	case MethodBye:
+		s.handleBye(msg)
+	case MethodAck:
+		// 服务端不接受INVITE，ACK无需处理
+	case MethodOptions:
+		s.reply(msg, 200, "OK")
+	default:
+		resp := NewResponse(msg, 405, "Method Not Allowed")
+		resp.Header.Set("Allow", strings.Join([]string{MethodRegister, MethodMessage, MethodBye, MethodAck, MethodOptions}, ", "))
+		s.send(msg.source, resp)
+	}
+}
+
+// handleRegister 处理设备注册和注销
+func (s *Server) handleRegister(req *Message) {
+	id := addressUser(req.Header.Get("From"))
+	if id == "" {
+		s.reply(req, 400, "Bad Request")
+		return
+	}
+
+	if password := s.password(id); password != "" {
+		authorization := req.Header.Get("Authorization")
+		if authorization == "" || !s.auth.verify(MethodRegister, authorization, id, password) {
+			resp := NewResponse(req, 401, "Unauthorized")
+			resp.Header.Set("WWW-Authenticate", s.auth.challenge())
+			s.send(req.source, resp)
+			return
+		}
+	}
+
+	expires := defaultRegisterExpires
+	if value := req.Header.Get("Expires"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			expires = time.Duration(seconds) * time.Second
+		}
+	}
+
+	resp := NewResponse(req, 200, "OK")
+	if contact := req.Header.Get("Contact"); contact != "" {
+		resp.Header.Set("Contact", contact)
+	}
+	resp.Header.Set("Expires", strconv.Itoa(int(expires/time.Second)))
+	resp.Header.Set("Date", time.Now().Format(dateLayout))
+	s.send(req.source, resp)
+
+	if expires == 0 {
+		s.setOffline(id)
+		return
+	}
+	if s.register(id, req.source, expires) {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*s.config.RequestTimeout)
+			defer cancel()
+			if _, err := s.QueryCatalog(ctx, id); err != nil {
+				s.reportError(fmt.Errorf("查询设备%s的目录失败: %w", id, err))
+			}
+		}()
+	}
+}
+
+// register 记录设备注册
+// 返回: 设备是否由离线变为在线
+func (s *Server) register(id string, conn *connection, expires time.Duration) bool {
+	now := time.Now()
+	s.mu.Lock()
+	d, ok := s.devices[id]
+	if !ok {
+		d = &device{Device: Device{ID: id}}
+		s.devices[id] = d
+	}
+	wasOnline := d.Online
+	d.Online = true
+	d.conn = conn
+	d.Transport = conn.transport
+	d.RemoteAddr = conn.remote.String()
+	d.RegisteredAt = now
+	d.LastKeepalive = now
+	d.Expires = expires
+	snapshot := d.snapshot()
+	s.mu.Unlock()
+
+	if !wasOnline && s.config.OnDeviceOnline != nil {
+		s.config.OnDeviceOnline(snapshot)
+	}
+	return !wasOnline
+}
+
+// keepalive 记录设备心跳
+// 返回: 设备是否在线
+func (s *Server) keepalive(id string, conn *connection) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.devices[id]
+	if !ok || !d.Online {
+		return false
+	}
+	d.LastKeepalive = time.Now()
+	d.conn = conn
+	d.RemoteAddr = conn.remote.String()
+	return true
+}
+
+// setOffline 将设备置为离线
+func (s *Server) setOffline(id string) {
+	s.mu.Lock()
+	d, ok := s.devices[id]
+	if !ok || !d.Online {
+		s.mu.Unlock()
+		return
+	}
+	d.Online = false
+	snapshot := d.snapshot()
+	s.mu.Unlock()
+
+	if s.config.OnDeviceOffline != nil {
+		s.config.OnDeviceOffline(snapshot)
+	}
+}
+
+// expireDevices 将注册过期或心跳超时的设备置为离线
+func (s *Server) expireDevices(now time.Time) {
+	s.mu.Lock()
+	var expired []string
+	for id, d := range s.devices {
+		if !d.Online {
+			continue
+		}
+		if now.Sub(d.LastKeepalive) > s.config.KeepaliveTimeout || now.Sub(d.RegisteredAt) > d.Expires {
+			expired = append(expired, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, id := range expired {
+		s.setOffline(id)
+	}
+}
+
+// password 获取设备的注册密码
+func (s *Server) password(id string) string {
+	if s.config.PasswordFunc != nil {
+		return s.config.PasswordFunc(id)
+	}
+	return s.config.Password
+}
+
+// newRequest 创建发送给设备的请求
+// 参数:
+//   - target: 请求的目标编码，例如设备编码或通道编码
+func (s *Server) newRequest(method string, d *device, target string) *Message {
+	s.mu.Lock()
+	s.cseq++
+	cseq := s.cseq
+	s.mu.Unlock()
+
+	req := &Message{
+		Method:     method,
+		RequestURI: fmt.Sprintf("sip:%s@%s", target, d.conn.remote),
+	}
+	req.Header.Add("Via", s.via(d.conn))
+	req.Header.Add("From", fmt.Sprintf("<sip:%s@%s>;tag=%s", s.config.ServerID, s.config.Domain, newTag()))
+	req.Header.Add("To", fmt.Sprintf("<sip:%s@%s>", target, s.config.Domain))
+	req.Header.Add("Call-ID", newCallID())
+	req.Header.Add("CSeq", fmt.Sprintf("%d %s", cseq, method))
+	req.Header.Add("Max-Forwards", "70")
+	return req
+}
+
+// via 生成新的Via
+func (s *Server) via(conn *connection) string {
+	return fmt.Sprintf("%s/%s %s;rport;branch=%s", sipVersion, conn.transport, s.sentBy(conn), newBranch())
+}
+
+// contact 生成Contact
+func (s *Server) contact(conn *connection) string {
+	return fmt.Sprintf("<sip:%s@%s>", s.config.ServerID, s.sentBy(conn))
+}
+
+// sentBy SIP服务对设备可见的地址
+func (s *Server) sentBy(conn *connection) string {
+	if s.config.ExternalAddr != "" {
+		return s.config.ExternalAddr
+	}
+	return conn.local().String()
+}
+
+// transactionKey 客户端事务的标识
+// 按RFC 3261以Via的branch和CSeq的方法匹配响应，CANCEL与被取消的INVITE使用相同的branch
+func transactionKey(m *Message) string {
+	_, method := m.CSeq()
+	return m.Branch() + " " + method
+}
+
+// startTransaction 登记客户端事务
+// 返回: 接收响应的通道，以及结束事务的函数
+func (s *Server) startTransaction(req *Message) (<-chan *Message, func()) {
+	key := transactionKey(req)
+	responses := make(chan *Message, 8)
+	s.mu.Lock()
+	s.transactions[key] = responses
+	s.mu.Unlock()
+
+	return responses, func() {
+		s.mu.Lock()
+		delete(s.transactions, key)
+		s.mu.Unlock()
+	}
+}
+
+// request 发送请求并等待最终响应
+func (s *Server) request(ctx context.Context, conn *connection, req *Message) (*Message, error) {
+	responses, done := s.startTransaction(req)
+	defer done()
+	return s.roundTrip(ctx, conn, req, responses)
+}
+
+// roundTrip 在已登记的事务中发送请求并等待最终响应
+// UDP在收到响应前按RFC 3261的T1、T2定时器重传请求；非2xx的最终响应与ResponseError一起返回
+func (s *Server) roundTrip(ctx context.Context, conn *connection, req *Message, responses <-chan *Message) (*Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.RequestTimeout)
+	defer cancel()
+
+	if err := conn.send(req); err != nil {
+		return nil, fmt.Errorf("发送%s请求失败: %w", req.Method, err)
+	}
+
+	interval := 500 * time.Millisecond
+	retransmit := time.NewTimer(interval)
+	defer retransmit.Stop()
+	if conn.reliable() {
+		retransmit.Stop()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("等待%s响应超时: %w", req.Method, ctx.Err())
+		case <-retransmit.C:
+			if err := conn.send(req); err != nil {
+				return nil, fmt.Errorf("重传%s请求失败: %w", req.Method, err)
+			}
+			interval = min(2*interval, 4*time.Second)
+			retransmit.Reset(interval)
+		case resp := <-responses:
+			if resp.StatusCode < 200 {
+				// 收到临时响应后不再重传，等待最终响应
+				retransmit.Stop()
+				continue
+			}
+			if resp.StatusCode >= 300 {
+				return resp, &ResponseError{Method: req.Method, StatusCode: resp.StatusCode, Reason: resp.Reason}
+			}
+			return resp, nil
+		}
+	}
+}
+
+// deliverResponse 将响应交给等待的事务
+func (s *Server) deliverResponse(resp *Message) {
+	s.mu.Lock()
+	responses, ok := s.transactions[transactionKey(resp)]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	select {
+	case responses <- resp:
+	default:
+	}
+}
+
+// reply 回复请求
+func (s *Server) reply(req *Message, code int, reason string) {
+	s.send(req.source, NewResponse(req, code, reason))
+}
+
+// send 发送消息，出错时通过OnError报告
+func (s *Server) send(conn *connection, m *Message) {
+	if err := conn.send(m); err != nil {
+		s.reportError(fmt.Errorf("发送SIP消息到%s失败: %w", conn.remote, err))
+	}
+}
+
+// reportError 报告处理消息时的错误
+func (s *Server) reportError(err error) {
+	if s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
+
+// track 记录监听或连接，Close时一并关闭
+// 返回: 服务已关闭时返回false
+func (s *Server) track(closer io.Closer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isClosed {
+		return false
+	}
+	s.closers[closer] = struct{}{}
+	return true
+}
+
+// untrack 删除记录的监听或连接
+func (s *Server) untrack(closer io.Closer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.closers, closer)
+}
+
+// closed 服务是否已关闭
+func (s *Server) closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isClosed
+}
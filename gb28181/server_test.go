@@ -0,0 +1,690 @@
+package gb28181_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edwardpan/zlmedia_restapi_go/gb28181"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+const (
+	testServerID = "34020000002000000001"
+	testDomain   = "3402000000"
+	testDeviceID = "34020000001320000001"
+)
+
+// testServer 监听本地UDP和TCP端口的SIP服务
+type testServer struct {
+	*gb28181.Server
+	zlm     *zlmtest.Server
+	udpAddr net.Addr
+	tcpAddr net.Addr
+
+	mu       sync.Mutex
+	events   []string // 设备上线、离线、目录和会话结束回调，格式为事件/设备编码
+	errs     []error
+	catalogs chan []gb28181.Channel
+}
+
+// newTestServer 创建并启动SIP服务
+func newTestServer(t *testing.T, config gb28181.Config) *testServer {
+	t.Helper()
+	s := &testServer{zlm: zlmtest.NewServer(), catalogs: make(chan []gb28181.Channel, 4)}
+	t.Cleanup(s.zlm.Close)
+
+	config.ServerID = testServerID
+	if config.RequestTimeout == 0 {
+		config.RequestTimeout = 2 * time.Second
+	}
+	config.OnDeviceOnline = func(device gb28181.Device) { s.record("online/" + device.ID) }
+	config.OnDeviceOffline = func(device gb28181.Device) { s.record("offline/" + device.ID) }
+	config.OnCatalog = func(deviceID string, channels []gb28181.Channel) { s.catalogs <- channels }
+	config.OnSessionClosed = func(session gb28181.Session) { s.record("bye/" + session.DeviceID) }
+	config.OnError = func(err error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.errs = append(s.errs, err)
+	}
+	server, err := gb28181.NewServer(s.zlm.Client(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Server = server
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.udpAddr, s.tcpAddr = udp.LocalAddr(), tcp.Addr()
+	go server.ServeUDP(udp)
+	go server.ServeTCP(tcp)
+	t.Cleanup(func() { server.Close() })
+	return s
+}
+
+// record 记录回调事件
+func (s *testServer) record(event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+// Events 获取回调事件
+func (s *testServer) Events() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return strings.Join(s.events, ",")
+}
+
+// Errors 获取OnError报告的错误
+func (s *testServer) Errors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]error(nil), s.errs...)
+}
+
+// testDevice 通过UDP或TCP连接SIP服务的模拟设备
+// 自动应答目录查询、INVITE、CANCEL和BYE，收到的请求和响应分别投递到requests和responses
+type testDevice struct {
+	t         *testing.T
+	id        string
+	transport string
+	conn      net.Conn
+	channels  []gb28181.Channel
+	answer    func(invite *gb28181.Message) *gb28181.Message // 为nil时以200应答，sdp中的ssrc不变；返回nil时不应答，等待CANCEL
+	connected bool                                           // 收到CANCEL时INVITE是否已经接通，是则以200应答INVITE，否则以487应答
+
+	mu        sync.Mutex // 保护cseq和ringing，设备的应答与测试中的请求并发发送
+	cseq      int
+	ringing   *gb28181.Message // 未应答的INVITE
+	requests  chan *gb28181.Message
+	responses chan *gb28181.Message
+}
+
+// newTestDevice 连接SIP服务，transport为UDP或TCP
+func newTestDevice(t *testing.T, s *testServer, transport string) *testDevice {
+	t.Helper()
+	addr := s.udpAddr
+	if transport == gb28181.TransportTCP {
+		addr = s.tcpAddr
+	}
+	conn, err := net.Dial(strings.ToLower(transport), addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	d := &testDevice{
+		t:         t,
+		id:        testDeviceID,
+		transport: transport,
+		conn:      conn,
+		channels:  []gb28181.Channel{{DeviceID: "34020000001320000011", Name: "门口"}, {DeviceID: "34020000001320000012", Name: "大厅"}},
+		requests:  make(chan *gb28181.Message, 16),
+		responses: make(chan *gb28181.Message, 16),
+	}
+	go d.serve()
+	return d
+}
+
+// serve 接收SIP服务发送的消息
+func (d *testDevice) serve() {
+	r := bufio.NewReader(d.conn)
+	buf := make([]byte, 64<<10)
+	for {
+		var msg *gb28181.Message
+		var err error
+		if d.transport == gb28181.TransportUDP {
+			var n int
+			if n, err = d.conn.Read(buf); err == nil {
+				msg, err = gb28181.ParseMessage(buf[:n])
+			}
+		} else {
+			msg, err = readStreamMessage(r)
+		}
+		if err != nil {
+			return
+		}
+
+		if !msg.IsRequest() {
+			d.responses <- msg
+			continue
+		}
+		d.requests <- msg
+		d.handle(msg)
+	}
+}
+
+// handle 应答SIP服务发送的请求
+func (d *testDevice) handle(req *gb28181.Message) {
+	switch req.Method {
+	case gb28181.MethodMessage:
+		d.send(gb28181.NewResponse(req, 200, "OK"))
+		sn := regexp.MustCompile(`<SN>(\d+)</SN>`).FindSubmatch(req.Body)
+		if bytes.Contains(req.Body, []byte("<CmdType>Catalog</CmdType>")) && sn != nil {
+			d.sendCatalog(string(sn[1]))
+		}
+	case gb28181.MethodInvite:
+		d.send(gb28181.NewResponse(req, 100, "Trying"))
+		answer := inviteAnswer(req, "")
+		if d.answer != nil {
+			answer = d.answer(req)
+		}
+		if answer == nil {
+			d.mu.Lock()
+			d.ringing = req
+			d.mu.Unlock()
+			return
+		}
+		d.send(answer)
+	case gb28181.MethodCancel:
+		d.send(gb28181.NewResponse(req, 200, "OK"))
+		d.mu.Lock()
+		invite := d.ringing
+		d.ringing = nil
+		d.mu.Unlock()
+		if invite == nil {
+			return
+		}
+		if d.connected {
+			d.send(inviteAnswer(invite, ""))
+			return
+		}
+		d.send(gb28181.NewResponse(invite, 487, "Request Terminated"))
+	case gb28181.MethodBye:
+		d.send(gb28181.NewResponse(req, 200, "OK"))
+	}
+}
+
+// sendCatalog 分两条消息返回目录
+func (d *testDevice) sendCatalog(sn string) {
+	for _, channel := range d.channels {
+		body := fmt.Sprintf(`<?xml version="1.0" encoding="GB2312"?>
+<Response><CmdType>Catalog</CmdType><SN>%s</SN><DeviceID>%s</DeviceID><SumNum>%d</SumNum>
+<DeviceList Num="1"><Item><DeviceID>%s</DeviceID><Name>%s</Name><Status>ON</Status></Item></DeviceList></Response>`,
+			sn, d.id, len(d.channels), channel.DeviceID, channel.Name)
+		req := d.newRequest(gb28181.MethodMessage, "", body)
+		req.Header.Set("Content-Type", "Application/MANSCDP+xml")
+		d.send(req)
+	}
+}
+
+// inviteAnswer 生成INVITE的200应答，ssrc为空时使用INVITE中的ssrc
+func inviteAnswer(invite *gb28181.Message, ssrc string) *gb28181.Message {
+	if ssrc == "" {
+		ssrc = regexp.MustCompile(`y=(\d+)`).FindStringSubmatch(string(invite.Body))[1]
+	}
+	resp := gb28181.NewResponse(invite, 200, "OK")
+	resp.Header.Set("Contact", fmt.Sprintf("<sip:%s@127.0.0.1:5060>", testDeviceID))
+	resp.Header.Set("Content-Type", "APPLICATION/SDP")
+	resp.Body = []byte("v=0\r\no=" + testDeviceID + " 0 0 IN IP4 127.0.0.1\r\ns=Play\r\nc=IN IP4 127.0.0.1\r\nt=0 0\r\n" +
+		"m=video 15060 RTP/AVP 96\r\na=sendonly\r\na=rtpmap:96 PS/90000\r\ny=" + ssrc + "\r\n")
+	return resp
+}
+
+// newRequest 创建设备发送的请求
+func (d *testDevice) newRequest(method, callID, body string) *gb28181.Message {
+	d.mu.Lock()
+	d.cseq++
+	cseq := d.cseq
+	d.mu.Unlock()
+	if callID == "" {
+		callID = fmt.Sprintf("device-%d", time.Now().UnixNano())
+	}
+	req := &gb28181.Message{Method: method, RequestURI: fmt.Sprintf("sip:%s@%s", testServerID, testDomain), Body: []byte(body)}
+	req.Header.Add("Via", fmt.Sprintf("SIP/2.0/%s %s;rport;branch=z9hG4bK%d", d.transport, d.conn.LocalAddr(), time.Now().UnixNano()))
+	req.Header.Add("From", fmt.Sprintf("<sip:%s@%s>;tag=device", d.id, testDomain))
+	req.Header.Add("To", fmt.Sprintf("<sip:%s@%s>", d.id, testDomain))
+	req.Header.Add("Call-ID", callID)
+	req.Header.Add("CSeq", fmt.Sprintf("%d %s", cseq, method))
+	req.Header.Add("Max-Forwards", "70")
+	return req
+}
+
+// send 发送SIP消息
+func (d *testDevice) send(m *gb28181.Message) {
+	if _, err := d.conn.Write(m.Marshal()); err != nil {
+		d.t.Errorf("发送SIP消息失败: %v", err)
+	}
+}
+
+// register 发送REGISTER并返回最终响应
+// authorization为nil时不携带Authorization，否则根据上一次的401响应计算
+func (d *testDevice) register(expires int, authorization func(challenge string) string, challenge string) *gb28181.Message {
+	req := d.newRequest(gb28181.MethodRegister, "register", "")
+	req.Header.Set("Contact", fmt.Sprintf("<sip:%s@%s>", d.id, d.conn.LocalAddr()))
+	req.Header.Set("Expires", strconv.Itoa(expires))
+	if authorization != nil {
+		req.Header.Set("Authorization", authorization(challenge))
+	}
+	return d.roundTrip(req)
+}
+
+// roundTrip 发送请求并等待最终响应
+func (d *testDevice) roundTrip(req *gb28181.Message) *gb28181.Message {
+	d.t.Helper()
+	d.send(req)
+	return d.response(req)
+}
+
+// response 等待请求的最终响应，按Call-ID和CSeq匹配
+func (d *testDevice) response(req *gb28181.Message) *gb28181.Message {
+	d.t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case resp := <-d.responses:
+			if resp.CallID() == req.CallID() && resp.Header.Get("CSeq") == req.Header.Get("CSeq") && resp.StatusCode >= 200 {
+				return resp
+			}
+		case <-timeout:
+			d.t.Fatalf("等待%s响应超时", req.Method)
+			return nil
+		}
+	}
+}
+
+// request 等待SIP服务发送的指定方法的请求
+func (d *testDevice) request(method string) *gb28181.Message {
+	d.t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case req := <-d.requests:
+			if req.Method == method {
+				return req
+			}
+		case <-timeout:
+			d.t.Fatalf("等待%s请求超时", method)
+			return nil
+		}
+	}
+}
+
+// readStreamMessage 从TCP连接中读取一个SIP消息
+func readStreamMessage(r *bufio.Reader) (*gb28181.Message, error) {
+	var head bytes.Buffer
+	length := 0
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(line) == "" {
+			if head.Len() == 0 {
+				continue
+			}
+			head.WriteString(line)
+			break
+		}
+		head.WriteString(line)
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "Content-Length") {
+			length, _ = strconv.Atoi(strings.TrimSpace(value))
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return gb28181.ParseMessage(append(head.Bytes(), body...))
+}
+
+// digestParams 解析WWW-Authenticate中的参数
+func digestParams(challenge string) map[string]string {
+	params := make(map[string]string)
+	for _, m := range regexp.MustCompile(`(\w+)="?([^",]*)"?`).FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	return params
+}
+
+// digestAuthorization 按RFC 2617计算REGISTER的Authorization
+func digestAuthorization(username, password, qop string) func(challenge string) string {
+	md5Hex := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	return func(challenge string) string {
+		params := digestParams(challenge)
+		uri := "sip:" + testServerID + "@" + testDomain
+		ha1 := md5Hex(username + ":" + params["realm"] + ":" + password)
+		ha2 := md5Hex(gb28181.MethodRegister + ":" + uri)
+		if qop == "" {
+			response := md5Hex(ha1 + ":" + params["nonce"] + ":" + ha2)
+			return fmt.Sprintf(`Digest username="%s",realm="%s",nonce="%s",uri="%s",response="%s",algorithm=MD5`, username, params["realm"], params["nonce"], uri, response)
+		}
+		response := md5Hex(strings.Join([]string{ha1, params["nonce"], "00000001", "0a4f113b", qop, ha2}, ":"))
+		return fmt.Sprintf(`Digest username="%s",realm="%s",nonce="%s",uri="%s",qop=%s,nc=00000001,cnonce="0a4f113b",response="%s"`, username, params["realm"], params["nonce"], uri, qop, response)
+	}
+}
+
+func TestServerRegister(t *testing.T) {
+	tests := []struct {
+		name          string
+		password      string
+		authorization func(challenge string) string
+		wantCode      int
+	}{
+		{"不鉴权", "", nil, 200},
+		{"摘要认证", "12345678", digestAuthorization(testDeviceID, "12345678", ""), 200},
+		{"摘要认证qop", "12345678", digestAuthorization(testDeviceID, "12345678", "auth"), 200},
+		{"密码错误", "12345678", digestAuthorization(testDeviceID, "wrong", ""), 401},
+		{"用户名与设备编码不一致", "12345678", digestAuthorization("34020000001320000002", "12345678", ""), 401},
+		{"nonce不是服务端生成的", "12345678", func(string) string {
+			return digestAuthorization(testDeviceID, "12345678", "")(`Digest realm="3402000000",nonce="1234567890abcdef1234567890abcdef"`)
+		}, 401},
+	}
+	for _, transport := range []string{gb28181.TransportUDP, gb28181.TransportTCP} {
+		for _, tt := range tests {
+			t.Run(transport+"/"+tt.name, func(t *testing.T) {
+				server := newTestServer(t, gb28181.Config{Password: tt.password})
+				device := newTestDevice(t, server, transport)
+
+				resp := device.register(3600, nil, "")
+				challenge := ""
+				if tt.password != "" {
+					if resp.StatusCode != 401 {
+						t.Fatalf("未携带Authorization的REGISTER = %d, want 401", resp.StatusCode)
+					}
+					challenge = resp.Header.Get("WWW-Authenticate")
+					if params := digestParams(challenge); params["realm"] != testDomain || params["nonce"] == "" {
+						t.Fatalf("WWW-Authenticate = %q", challenge)
+					}
+					resp = device.register(3600, tt.authorization, challenge)
+				}
+				if resp.StatusCode != tt.wantCode {
+					t.Fatalf("REGISTER = %d %s, want %d", resp.StatusCode, resp.Reason, tt.wantCode)
+				}
+				if tt.wantCode != 200 {
+					if _, ok := server.Device(testDeviceID); ok {
+						t.Error("鉴权失败的设备被注册")
+					}
+					return
+				}
+				if resp.Header.Get("Expires") != "3600" || resp.Header.Get("Date") == "" {
+					t.Errorf("REGISTER响应 Expires=%q Date=%q", resp.Header.Get("Expires"), resp.Header.Get("Date"))
+				}
+
+				// 注册后自动查询目录，设备分两条消息返回，到达顺序不确定
+				select {
+				case channels := <-server.catalogs:
+					names := make([]string, len(channels))
+					for i, channel := range channels {
+						names[i] = channel.Name
+					}
+					sort.Strings(names)
+					if got := strings.Join(names, ","); got != "大厅,门口" {
+						t.Errorf("目录 = %s, want 大厅,门口", got)
+					}
+				case <-time.After(3 * time.Second):
+					t.Fatal("注册后没有收到目录")
+				}
+				registered, ok := server.Device(testDeviceID)
+				if !ok || !registered.Online || registered.Transport != transport || len(registered.Channels) != 2 {
+					t.Errorf("Device() = %+v, %v", registered, ok)
+				}
+
+				// 注销
+				if resp := device.register(0, tt.authorization, challenge); resp.StatusCode != 200 {
+					t.Fatalf("注销 = %d, want 200", resp.StatusCode)
+				}
+				if registered, _ := server.Device(testDeviceID); registered.Online {
+					t.Error("注销后设备仍在线")
+				}
+				if got, want := server.Events(), "online/"+testDeviceID+",offline/"+testDeviceID; got != want {
+					t.Errorf("events = %q, want %q", got, want)
+				}
+				if errs := server.Errors(); len(errs) != 0 {
+					t.Errorf("OnError = %v", errs)
+				}
+			})
+		}
+	}
+}
+
+func TestServerKeepalive(t *testing.T) {
+	keepalive := fmt.Sprintf(`<?xml version="1.0" encoding="GB2312"?>
+<Notify><CmdType>Keepalive</CmdType><SN>1</SN><DeviceID>%s</DeviceID><Status>OK</Status></Notify>`, testDeviceID)
+
+	for _, transport := range []string{gb28181.TransportUDP, gb28181.TransportTCP} {
+		t.Run(transport, func(t *testing.T) {
+			server := newTestServer(t, gb28181.Config{})
+			device := newTestDevice(t, server, transport)
+
+			// 未注册的设备发送心跳，回复404使设备重新注册
+			if resp := device.roundTrip(device.newRequest(gb28181.MethodMessage, "", keepalive)); resp.StatusCode != 404 {
+				t.Fatalf("未注册的心跳 = %d, want 404", resp.StatusCode)
+			}
+
+			device.register(3600, nil, "")
+			<-server.catalogs
+			before, _ := server.Device(testDeviceID)
+			if resp := device.roundTrip(device.newRequest(gb28181.MethodMessage, "", keepalive)); resp.StatusCode != 200 {
+				t.Fatalf("心跳 = %d, want 200", resp.StatusCode)
+			}
+			if after, _ := server.Device(testDeviceID); !after.LastKeepalive.After(before.LastKeepalive) {
+				t.Errorf("LastKeepalive没有更新: %v -> %v", before.LastKeepalive, after.LastKeepalive)
+			}
+		})
+	}
+}
+
+func TestServerPlay(t *testing.T) {
+	tests := []struct {
+		name   string
+		tcp    bool   // 是否使用TCP传输RTP
+		answer string // 应答中的ssrc，为空时与INVITE一致
+		reject int    // 设备拒绝INVITE的状态码，0为接受
+	}{
+		{"UDP传输RTP", false, "", 0},
+		{"TCP传输RTP", true, "", 0},
+		{"设备使用不同的ssrc", false, "0200009999", 0},
+		{"设备拒绝点播", false, "", 486},
+	}
+	for _, transport := range []string{gb28181.TransportUDP, gb28181.TransportTCP} {
+		for _, tt := range tests {
+			t.Run(transport+"/"+tt.name, func(t *testing.T) {
+				server := newTestServer(t, gb28181.Config{})
+				device := newTestDevice(t, server, transport)
+				device.answer = func(invite *gb28181.Message) *gb28181.Message {
+					if tt.reject != 0 {
+						return gb28181.NewResponse(invite, tt.reject, "Busy Here")
+					}
+					return inviteAnswer(invite, tt.answer)
+				}
+				device.register(3600, nil, "")
+				<-server.catalogs
+				ctx := context.Background()
+
+				channelID := device.channels[0].DeviceID
+				session, err := server.Play(ctx, testDeviceID, channelID, &gb28181.PlayOptions{TCP: tt.tcp})
+				invite := device.request(gb28181.MethodInvite)
+				if tt.reject != 0 {
+					var respErr *gb28181.ResponseError
+					if !errors.As(err, &respErr) || respErr.StatusCode != tt.reject {
+						t.Fatalf("Play() error = %v, want ResponseError %d", err, tt.reject)
+					}
+					// 非2xx最终响应的ACK属于INVITE事务，与INVITE使用相同的branch
+					if ack := device.request(gb28181.MethodAck); ack.Branch() != invite.Branch() {
+						t.Errorf("ACK branch = %q, want %q", ack.Branch(), invite.Branch())
+					}
+					if got := len(server.zlm.RtpServers()); got != 0 {
+						t.Errorf("点播失败后RTP接收端口数量 = %d, want 0", got)
+					}
+					return
+				}
+				if tt.answer != "" {
+					// ZLMediaKit只接收分配的ssrc，确认应答后立即结束会话
+					if !errors.Is(err, gb28181.ErrSSRCMismatch) {
+						t.Fatalf("Play() error = %v, want ErrSSRCMismatch", err)
+					}
+					if ack := device.request(gb28181.MethodAck); ack.CallID() != invite.CallID() {
+						t.Errorf("ACK Call-ID = %q, want %q", ack.CallID(), invite.CallID())
+					}
+					if bye := device.request(gb28181.MethodBye); bye.CallID() != invite.CallID() {
+						t.Errorf("BYE Call-ID = %q, want %q", bye.CallID(), invite.CallID())
+					}
+					if got := len(server.zlm.RtpServers()); got != 0 {
+						t.Errorf("点播失败后RTP接收端口数量 = %d, want 0", got)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				offer := string(invite.Body)
+				wantProto := "RTP/AVP"
+				if tt.tcp {
+					wantProto = "TCP/RTP/AVP"
+				}
+				if !strings.Contains(offer, fmt.Sprintf("m=video %d %s 96 97 98", session.Port, wantProto)) || !strings.Contains(offer, "y=0200000001") ||
+					!strings.Contains(offer, "c=IN IP4 127.0.0.1") {
+					t.Errorf("INVITE sdp:\n%s", offer)
+				}
+				if got := invite.Header.Get("Subject"); got != channelID+":0200000001,"+testServerID+":0" {
+					t.Errorf("Subject = %q", got)
+				}
+				if ack := device.request(gb28181.MethodAck); ack.CallID() != session.ID {
+					t.Errorf("ACK Call-ID = %q, want %q", ack.CallID(), session.ID)
+				}
+				if session.SSRC != "0200000001" || session.TCP != tt.tcp || session.Key.Stream != testDeviceID+"_"+channelID {
+					t.Errorf("Play() = %+v", session)
+				}
+				if got := len(server.zlm.RtpServers()); got != 1 {
+					t.Errorf("RTP接收端口数量 = %d, want 1", got)
+				}
+
+				// 服务端结束点播
+				if err := server.Stop(ctx, session.ID); err != nil {
+					t.Fatal(err)
+				}
+				if bye := device.request(gb28181.MethodBye); bye.CallID() != session.ID || !strings.Contains(bye.Header.Get("To"), "tag=") {
+					t.Errorf("BYE Call-ID=%q To=%q", bye.CallID(), bye.Header.Get("To"))
+				}
+				if got := len(server.zlm.RtpServers()); got != 0 {
+					t.Errorf("Stop()后RTP接收端口数量 = %d, want 0", got)
+				}
+
+				// 设备结束点播
+				session, err = server.Play(ctx, testDeviceID, channelID, &gb28181.PlayOptions{TCP: tt.tcp})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp := device.roundTrip(device.newRequest(gb28181.MethodBye, session.ID, "")); resp.StatusCode != 200 {
+					t.Fatalf("BYE = %d, want 200", resp.StatusCode)
+				}
+				deadline := time.Now().Add(time.Second)
+				for len(server.Sessions()) != 0 || len(server.zlm.RtpServers()) != 0 || !strings.HasSuffix(server.Events(), "bye/"+testDeviceID) {
+					if time.Now().After(deadline) {
+						t.Fatalf("设备BYE后 Sessions=%d RtpServers=%d events=%q", len(server.Sessions()), len(server.zlm.RtpServers()), server.Events())
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				if errs := server.Errors(); len(errs) != 0 {
+					t.Errorf("OnError = %v", errs)
+				}
+			})
+		}
+	}
+}
+
+func TestServerPlayCancel(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   time.Duration // 等待设备应答的超时时间
+		cancel    bool          // 是否在等待应答时取消ctx
+		connected bool          // 收到CANCEL时设备是否已经接通
+		wantErr   error
+	}{
+		{"等待应答超时", 300 * time.Millisecond, false, false, context.DeadlineExceeded},
+		{"取消点播", 0, true, false, context.Canceled},
+		{"取消时设备已经接通", 0, true, true, context.Canceled},
+	}
+	for _, transport := range []string{gb28181.TransportUDP, gb28181.TransportTCP} {
+		for _, tt := range tests {
+			t.Run(transport+"/"+tt.name, func(t *testing.T) {
+				server := newTestServer(t, gb28181.Config{RequestTimeout: tt.timeout})
+				device := newTestDevice(t, server, transport)
+				device.answer = func(invite *gb28181.Message) *gb28181.Message { return nil }
+				device.connected = tt.connected
+				device.register(3600, nil, "")
+				<-server.catalogs
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				if tt.cancel {
+					time.AfterFunc(200*time.Millisecond, cancel)
+				}
+				if _, err := server.Play(ctx, testDeviceID, device.channels[0].DeviceID, nil); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Play() error = %v, want %v", err, tt.wantErr)
+				}
+
+				// CANCEL除CSeq的方法外与INVITE的Via、Call-ID和CSeq序号相同
+				invite := device.request(gb28181.MethodInvite)
+				cancelReq := device.request(gb28181.MethodCancel)
+				inviteSeq, _ := invite.CSeq()
+				cancelSeq, method := cancelReq.CSeq()
+				if cancelReq.Branch() != invite.Branch() || cancelReq.CallID() != invite.CallID() || cancelSeq != inviteSeq || method != gb28181.MethodCancel {
+					t.Errorf("CANCEL = %s, INVITE = %s", cancelReq, invite)
+				}
+				ack := device.request(gb28181.MethodAck)
+				if ack.CallID() != invite.CallID() {
+					t.Errorf("ACK Call-ID = %q, want %q", ack.CallID(), invite.CallID())
+				}
+				if tt.connected {
+					// 已经接通的会话在确认后立即结束
+					if bye := device.request(gb28181.MethodBye); bye.CallID() != invite.CallID() {
+						t.Errorf("BYE Call-ID = %q, want %q", bye.CallID(), invite.CallID())
+					}
+				} else if ack.Branch() != invite.Branch() {
+					t.Errorf("ACK branch = %q, want %q", ack.Branch(), invite.Branch())
+				}
+				if got := len(server.zlm.RtpServers()); got != 0 {
+					t.Errorf("RTP接收端口数量 = %d, want 0", got)
+				}
+				if got := len(server.Sessions()); got != 0 {
+					t.Errorf("点播会话数量 = %d, want 0", got)
+				}
+			})
+		}
+	}
+}
+
+func TestServerPlayOfflineDevice(t *testing.T) {
+	server := newTestServer(t, gb28181.Config{})
+	ctx := context.Background()
+	if _, err := server.Play(ctx, testDeviceID, "34020000001320000011", nil); !errors.Is(err, gb28181.ErrDeviceNotFound) {
+		t.Errorf("Play() error = %v, want ErrDeviceNotFound", err)
+	}
+
+	device := newTestDevice(t, server, gb28181.TransportUDP)
+	device.register(3600, nil, "")
+	<-server.catalogs
+	device.register(0, nil, "")
+	if _, err := server.Play(ctx, testDeviceID, "34020000001320000011", nil); !errors.Is(err, gb28181.ErrDeviceOffline) {
+		t.Errorf("Play() error = %v, want ErrDeviceOffline", err)
+	}
+	if err := server.Stop(ctx, "unknown"); !errors.Is(err, gb28181.ErrSessionNotFound) {
+		t.Errorf("Stop() error = %v, want ErrSessionNotFound", err)
+	}
+}
@@ -0,0 +1,138 @@
+package gb28181
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+// 传输协议
+const (
+	TransportUDP = "UDP"
+	TransportTCP = "TCP"
+)
+
+// connection 消息的收发通道，UDP为监听的socket和对端地址，TCP为一个连接
+type connection struct {
+	transport string
+	remote    net.Addr
+	packet    net.PacketConn
+	stream    net.Conn
+
+	mu sync.Mutex // 保证TCP消息完整写入
+}
+
+// send 发送SIP消息
+func (c *connection) send(m *Message) error {
+	data := m.Marshal()
+	if c.transport == TransportUDP {
+		_, err := c.packet.WriteTo(data, c.remote)
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.stream.Write(data)
+	return err
+}
+
+// local 获取本端地址，用于Via和Contact
+func (c *connection) local() net.Addr {
+	if c.transport == TransportUDP {
+		return c.packet.LocalAddr()
+	}
+	return c.stream.LocalAddr()
+}
+
+// reliable 是否为可靠传输，可靠传输不需要重传请求
+func (c *connection) reliable() bool {
+	return c.transport == TransportTCP
+}
+
+// ServeUDP 在UDP socket上接收SIP消息，直到socket被关闭
+func (s *Server) ServeUDP(conn net.PacketConn) error {
+	if !s.track(conn) {
+		conn.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(conn)
+
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if s.closed() {
+				return ErrServerClosed
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return fmt.Errorf("接收UDP消息失败: %w", err)
+		}
+		if isKeepalivePacket(buf[:n]) {
+			continue
+		}
+
+		msg, err := ParseMessage(append([]byte(nil), buf[:n]...))
+		if err != nil {
+			s.reportError(fmt.Errorf("解析来自%s的SIP消息失败: %w", addr, err))
+			continue
+		}
+		msg.source = &connection{transport: TransportUDP, remote: addr, packet: conn}
+		go s.dispatch(msg)
+	}
+}
+
+// ServeTCP 在TCP监听上接受连接并接收SIP消息，直到监听被关闭
+func (s *Server) ServeTCP(l net.Listener) error {
+	if !s.track(l) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(l)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.closed() {
+				return ErrServerClosed
+			}
+			return fmt.Errorf("接受TCP连接失败: %w", err)
+		}
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn 接收一个TCP连接上的SIP消息
+func (s *Server) serveConn(conn net.Conn) {
+	if !s.track(conn) {
+		conn.Close()
+		return
+	}
+	defer s.untrack(conn)
+	defer conn.Close()
+
+	source := &connection{transport: TransportTCP, remote: conn.RemoteAddr(), stream: conn}
+	r := bufio.NewReader(conn)
+	for {
+		msg, err := readMessage(r, false)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !s.closed() {
+				s.reportError(fmt.Errorf("读取来自%s的SIP消息失败: %w", conn.RemoteAddr(), err))
+			}
+			return
+		}
+		msg.source = source
+		go s.dispatch(msg)
+	}
+}
+
+// isKeepalivePacket 是否为NAT保活的空数据包，例如"\r\n\r\n"
+func isKeepalivePacket(data []byte) bool {
+	return strings.TrimSpace(string(data)) == ""
+}
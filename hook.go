@@ -0,0 +1,534 @@
+package zlmedia
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+)
+
+// HookBase 所有hook事件都包含的字段
+type HookBase struct {
+	MediaServerID string `json:"mediaServerId"` // 服务器id，通过配置文件general.mediaServerId设置
+	HookIndex     int    `json:"hook_index"`    // hook序号
+}
+
+// HookMedia hook事件中的流信息
+type HookMedia struct {
+	Schema string `json:"schema"` // 协议，例如rtsp、rtmp
+	VHost  string `json:"vhost"`  // 虚拟主机
+	App    string `json:"app"`    // 应用名
+	Stream string `json:"stream"` // 流id
+	Params string `json:"params"` // url参数
+}
+
+// Key 获取流的唯一标识
+func (m HookMedia) Key() StreamKey {
+	return StreamKey{VHost: m.VHost, App: m.App, Stream: m.Stream}
+}
+
+// HookPeer hook事件中的客户端信息
+type HookPeer struct {
+	ID   string `json:"id"`   // tcp链接唯一id
+	IP   string `json:"ip"`   // 客户端ip
+	Port int    `json:"port"` // 客户端端口号
+}
+
+// HookResult hook的通用响应
+type HookResult struct {
+	Code int    `json:"code"`          // 0代表允许，其它均为不允许
+	Msg  string `json:"msg,omitempty"` // 不允许时的错误提示
+}
+
+// OnPublishHook rtsp/rtmp/rtp推流鉴权事件
+type OnPublishHook struct {
+	HookBase
+	HookMedia
+	HookPeer
+	OriginType    int    `json:"originType"`    // 产生源类型
+	OriginTypeStr string `json:"originTypeStr"` // 产生源类型名称
+}
+
+// PublishOptions 推流时的转协议和录制选项，为nil或空字符串的选项使用配置文件默认值
+type PublishOptions struct {
+	EnableHLS      *bool  `json:"enable_hls,omitempty"`       // 是否转换成hls-mpegts协议
+	EnableHLSFmp4  *bool  `json:"enable_hls_fmp4,omitempty"`  // 是否转换成hls-fmp4协议
+	EnableMp4      *bool  `json:"enable_mp4,omitempty"`       // 是否允许mp4录制
+	EnableRtsp     *bool  `json:"enable_rtsp,omitempty"`      // 是否转rtsp协议
+	EnableRtmp     *bool  `json:"enable_rtmp,omitempty"`      // 是否转rtmp/flv协议
+	EnableTS       *bool  `json:"enable_ts,omitempty"`        // 是否转http-ts/ws-ts协议
+	EnableFmp4     *bool  `json:"enable_fmp4,omitempty"`      // 是否转http-fmp4/ws-fmp4协议
+	EnableAudio    *bool  `json:"enable_audio,omitempty"`     // 转协议时是否开启音频
+	AddMuteAudio   *bool  `json:"add_mute_audio,omitempty"`   // 转协议时，无音频是否添加静音aac音频
+	Mp4SavePath    string `json:"mp4_save_path,omitempty"`    // mp4录制文件保存根目录，置空使用默认
+	Mp4MaxSecond   *int   `json:"mp4_max_second,omitempty"`   // mp4录制切片大小，单位秒
+	Mp4AsPlayer    *bool  `json:"mp4_as_player,omitempty"`    // mp4录制是否当作观看者参与播放人数计数
+	HlsSavePath    string `json:"hls_save_path,omitempty"`    // hls文件保存保存根目录，置空使用默认
+	ModifyStamp    *int   `json:"modify_stamp,omitempty"`     // 是否修改原始时间戳
+	ContinuePushMs *int   `json:"continue_push_ms,omitempty"` // 断连续推延时，单位毫秒
+	AutoClose      *bool  `json:"auto_close,omitempty"`       // 无人观看时，是否直接关闭
+	StreamReplace  string `json:"stream_replace,omitempty"`   // 是否修改流id
+}
+
+// OnPublishResult 推流鉴权响应
+type OnPublishResult struct {
+	Code int    `json:"code"`          // 0代表允许，其它均为不允许
+	Msg  string `json:"msg,omitempty"` // 不允许时的错误提示
+	PublishOptions
+}
+
+// OnPlayHook 播放器鉴权事件
+type OnPlayHook struct {
+	HookBase
+	HookMedia
+	HookPeer
+}
+
+// OnFlowReportHook 流量统计事件，播放器或推流器断开时触发
+type OnFlowReportHook struct {
+	HookBase
+	HookMedia
+	HookPeer
+	Duration   int   `json:"duration"`   // tcp链接维持时间，单位秒
+	Player     bool  `json:"player"`     // true为播放器，false为推流器
+	TotalBytes int64 `json:"totalBytes"` // 耗费上下行流量总和，单位字节
+}
+
+// OnHttpAccessHook 访问http文件服务器上hls之外的文件时触发
+type OnHttpAccessHook struct {
+	HookBase
+	HookPeer
+	IsDir  bool   `json:"is_dir"` // http客户端访问的是否为文件夹
+	Params string `json:"params"` // http url参数
+	Path   string `json:"path"`   // 请求访问的文件或目录
+}
+
+// OnHttpAccessResult http文件访问鉴权响应
+type OnHttpAccessResult struct {
+	Code   int    `json:"code"`           // 0代表允许访问
+	Err    string `json:"err"`            // 不允许访问的错误提示，为空代表允许访问
+	Path   string `json:"path"`           // 该客户端能访问或被禁止的顶端目录，为空代表当前目录
+	Second int    `json:"second"`         // 本次授权结果的有效期，单位秒
+	Mode   string `json:"mode,omitempty"` // 访问模式
+}
+
+// OnRecordMp4Hook mp4录制完成事件
+type OnRecordMp4Hook struct {
+	HookBase
+	VHost     string  `json:"vhost"`      // 虚拟主机
+	App       string  `json:"app"`        // 应用名
+	Stream    string  `json:"stream"`     // 流id
+	FileName  string  `json:"file_name"`  // 文件名
+	FilePath  string  `json:"file_path"`  // 文件绝对路径
+	FileSize  int64   `json:"file_size"`  // 文件大小，单位字节
+	Folder    string  `json:"folder"`     // 文件所在目录路径
+	StartTime int64   `json:"start_time"` // 开始录制时间戳，单位秒
+	TimeLen   float64 `json:"time_len"`   // 录制时长，单位秒
+	URL       string  `json:"url"`        // http/rtsp/rtmp点播相对url路径
+}
+
+// Key 获取流的唯一标识
+func (h OnRecordMp4Hook) Key() StreamKey {
+	return StreamKey{VHost: h.VHost, App: h.App, Stream: h.Stream}
+}
+
+// OnRecordTsHook ts切片录制完成事件，字段与mp4录制完成事件一致
+type OnRecordTsHook = OnRecordMp4Hook
+
+// OnRtspRealmHook rtsp专用鉴权事件，先询问流是否需要rtsp专属鉴权
+type OnRtspRealmHook struct {
+	HookBase
+	HookMedia
+	HookPeer
+}
+
+// OnRtspRealmResult rtsp专用鉴权响应
+type OnRtspRealmResult struct {
+	Code  int    `json:"code"`  // 请固定返回0
+	Realm string `json:"realm"` // 该rtsp流是否需要rtsp专有鉴权，空字符串代码不需要
+}
+
+// OnRtspAuthHook rtsp专用鉴权事件，on_rtsp_realm返回realm后触发
+type OnRtspAuthHook struct {
+	HookBase
+	HookMedia
+	HookPeer
+	MustNoEncrypt bool   `json:"must_no_encrypt"` // 请求的密码是否必须为明文
+	Realm         string `json:"realm"`           // rtsp播放鉴权加密realm
+	UserName      string `json:"user_name"`       // 播放用户名
+}
+
+// OnRtspAuthResult rtsp专用鉴权响应
+type OnRtspAuthResult struct {
+	Code      int    `json:"code"`          // 错误代码，0代表允许播放
+	Msg       string `json:"msg,omitempty"` // 错误提示
+	Encrypted bool   `json:"encrypted"`     // 用户密码是明文还是摘要
+	Passwd    string `json:"passwd"`        // 用户密码明文或摘要(md5(username:realm:password))
+}
+
+// OnShellLoginHook shell登录鉴权事件
+type OnShellLoginHook struct {
+	HookBase
+	HookPeer
+	UserName string `json:"user_name"` // 终端登录用户名
+	Passwd   string `json:"passwd"`    // 终端登录用户密码
+}
+
+// OnStreamChangedHook 流注册或注销事件
+// 注册时包含完整的流信息，注销时只包含schema/vhost/app/stream
+type OnStreamChangedHook struct {
+	HookBase
+	Regist bool `json:"regist"` // 流注册或注销
+	MediaInfo
+}
+
+// OnStreamNoneReaderHook 流无人观看事件
+type OnStreamNoneReaderHook struct {
+	HookBase
+	Schema string `json:"schema"` // 协议
+	VHost  string `json:"vhost"`  // 虚拟主机
+	App    string `json:"app"`    // 应用名
+	Stream string `json:"stream"` // 流id
+}
+
+// OnStreamNoneReaderResult 流无人观看响应
+type OnStreamNoneReaderResult struct {
+	Code  int  `json:"code"`  // 固定返回0
+	Close bool `json:"close"` // 是否关闭推流或拉流
+}
+
+// OnStreamNotFoundHook 流未找到事件，可在此时拉流或通知设备推流
+type OnStreamNotFoundHook struct {
+	HookBase
+	HookMedia
+	HookPeer
+}
+
+// OnServerStartedHook 服务器启动事件，Config为服务器的完整配置
+type OnServerStartedHook struct {
+	Config map[string]string // 配置项，键为"section.key"格式
+}
+
+// MediaServerID 获取服务器id
+func (h OnServerStartedHook) MediaServerID() string {
+	return h.Config["general.mediaServerId"]
+}
+
+// OnServerExitedHook 服务器退出事件
+type OnServerExitedHook struct {
+	HookBase
+}
+
+// OnServerKeepaliveHook 服务器定时上报事件
+type OnServerKeepaliveHook struct {
+	HookBase
+	Data Statistic `json:"data"` // 主要对象个数
+}
+
+// OnRtpServerTimeoutHook 调用openRtpServer接口后，rtp server长时间未收到数据事件
+type OnRtpServerTimeoutHook struct {
+	HookBase
+	LocalPort int    `json:"local_port"`  // openRtpServer输入的端口
+	ReUsePort bool   `json:"re_use_port"` // openRtpServer输入的re_use_port参数
+	SSRC      uint32 `json:"ssrc"`        // openRtpServer输入的ssrc参数
+	StreamID  string `json:"stream_id"`   // openRtpServer输入的stream_id参数
+	TcpMode   int    `json:"tcp_mode"`    // openRtpServer输入的tcp_mode参数
+}
+
+// OnSendRtpStoppedHook 调用startSendRtp接口后，rtp推流停止事件
+type OnSendRtpStoppedHook struct {
+	HookBase
+	VHost  string `json:"vhost"`  // 虚拟主机
+	App    string `json:"app"`    // 应用名
+	Stream string `json:"stream"` // 流id
+	SSRC   string `json:"ssrc"`   // startSendRtp输入的ssrc参数
+	Err    string `json:"msg"`    // 停止原因
+}
+
+// HookHandler ZLMediaKit hook事件处理接口，每个hook对应一个方法
+// 返回error时以错误代码响应ZLMediaKit，error为*APIError时使用其Code和Msg，否则为CodeOtherFailed；
+// 鉴权类hook(OnPublish、OnPlay、OnHttpAccess、OnRtspAuth、OnShellLogin)返回nil结果时按鉴权失败以CodeAuthFailed响应，
+// 其它hook返回nil结果时以成功响应
+// 只需处理部分hook时可以内嵌NopHookHandler
+type HookHandler interface {
+	OnPublish(ctx context.Context, hook *OnPublishHook) (*OnPublishResult, error)
+	OnPlay(ctx context.Context, hook *OnPlayHook) (*HookResult, error)
+	OnFlowReport(ctx context.Context, hook *OnFlowReportHook) error
+	OnHttpAccess(ctx context.Context, hook *OnHttpAccessHook) (*OnHttpAccessResult, error)
+	OnRecordMp4(ctx context.Context, hook *OnRecordMp4Hook) error
+	OnRecordTs(ctx context.Context, hook *OnRecordTsHook) error
+	OnRtspRealm(ctx context.Context, hook *OnRtspRealmHook) (*OnRtspRealmResult, error)
+	OnRtspAuth(ctx context.Context, hook *OnRtspAuthHook) (*OnRtspAuthResult, error)
+	OnShellLogin(ctx context.Context, hook *OnShellLoginHook) (*HookResult, error)
+	OnStreamChanged(ctx context.Context, hook *OnStreamChangedHook) error
+	OnStreamNoneReader(ctx context.Context, hook *OnStreamNoneReaderHook) (*OnStreamNoneReaderResult, error)
+	OnStreamNotFound(ctx context.Context, hook *OnStreamNotFoundHook) error
+	OnServerStarted(ctx context.Context, hook *OnServerStartedHook) error
+	OnServerExited(ctx context.Context, hook *OnServerExitedHook) error
+	OnServerKeepalive(ctx context.Context, hook *OnServerKeepaliveHook) error
+	OnRtpServerTimeout(ctx context.Context, hook *OnRtpServerTimeoutHook) error
+	OnSendRtpStopped(ctx context.Context, hook *OnSendRtpStoppedHook) error
+}
+
+// NopHookHandler 默认的hook处理实现，允许所有推流、播放和访问，无人观看时不关闭流，忽略所有通知
+type NopHookHandler struct{}
+
+// OnPublish 允许推流，转协议和录制选项使用配置文件默认值
+func (NopHookHandler) OnPublish(ctx context.Context, hook *OnPublishHook) (*OnPublishResult, error) {
+	return &OnPublishResult{}, nil
+}
+
+// OnPlay 允许播放
+func (NopHookHandler) OnPlay(ctx context.Context, hook *OnPlayHook) (*HookResult, error) {
+	return &HookResult{}, nil
+}
+
+// OnFlowReport 忽略流量统计
+func (NopHookHandler) OnFlowReport(ctx context.Context, hook *OnFlowReportHook) error {
+	return nil
+}
+
+// OnHttpAccess 允许访问，授权有效期为10分钟
+func (NopHookHandler) OnHttpAccess(ctx context.Context, hook *OnHttpAccessHook) (*OnHttpAccessResult, error) {
+	return &OnHttpAccessResult{Second: 600}, nil
+}
+
+// OnRecordMp4 忽略mp4录制完成事件
+func (NopHookHandler) OnRecordMp4(ctx context.Context, hook *OnRecordMp4Hook) error {
+	return nil
+}
+
+// OnRecordTs 忽略ts切片录制完成事件
+func (NopHookHandler) OnRecordTs(ctx context.Context, hook *OnRecordTsHook) error {
+	return nil
+}
+
+// OnRtspRealm 不需要rtsp专属鉴权
+func (NopHookHandler) OnRtspRealm(ctx context.Context, hook *OnRtspRealmHook) (*OnRtspRealmResult, error) {
+	return &OnRtspRealmResult{}, nil
+}
+
+// OnRtspAuth 拒绝rtsp专属鉴权，只有OnRtspRealm返回realm时才会触发
+func (NopHookHandler) OnRtspAuth(ctx context.Context, hook *OnRtspAuthHook) (*OnRtspAuthResult, error) {
+	return &OnRtspAuthResult{Code: CodeAuthFailed, Msg: "rtsp auth not implemented"}, nil
+}
+
+// OnShellLogin 允许shell登录
+func (NopHookHandler) OnShellLogin(ctx context.Context, hook *OnShellLoginHook) (*HookResult, error) {
+	return &HookResult{}, nil
+}
+
+// OnStreamChanged 忽略流注册或注销事件
+func (NopHookHandler) OnStreamChanged(ctx context.Context, hook *OnStreamChangedHook) error {
+	return nil
+}
+
+// OnStreamNoneReader 无人观看时不关闭流
+func (NopHookHandler) OnStreamNoneReader(ctx context.Context, hook *OnStreamNoneReaderHook) (*OnStreamNoneReaderResult, error) {
+	return &OnStreamNoneReaderResult{Close: false}, nil
+}
+
+// OnStreamNotFound 忽略流未找到事件
+func (NopHookHandler) OnStreamNotFound(ctx context.Context, hook *OnStreamNotFoundHook) error {
+	return nil
+}
+
+// OnServerStarted 忽略服务器启动事件
+func (NopHookHandler) OnServerStarted(ctx context.Context, hook *OnServerStartedHook) error {
+	return nil
+}
+
+// OnServerExited 忽略服务器退出事件
+func (NopHookHandler) OnServerExited(ctx context.Context, hook *OnServerExitedHook) error {
+	return nil
+}
+
+// OnServerKeepalive 忽略服务器定时上报事件
+func (NopHookHandler) OnServerKeepalive(ctx context.Context, hook *OnServerKeepaliveHook) error {
+	return nil
+}
+
+// OnRtpServerTimeout 忽略rtp server超时事件
+func (NopHookHandler) OnRtpServerTimeout(ctx context.Context, hook *OnRtpServerTimeoutHook) error {
+	return nil
+}
+
+// OnSendRtpStopped 忽略rtp推流停止事件
+func (NopHookHandler) OnSendRtpStopped(ctx context.Context, hook *OnSendRtpStoppedHook) error {
+	return nil
+}
+
+// HookServer ZLMediaKit hook事件的http.Handler
+// 按请求路径的最后一段分发事件，例如/index/hook/on_publish，因此可以挂载在任意路径前缀下
+type HookServer struct {
+	handler HookHandler
+	routes  map[string]func(ctx context.Context, body []byte) (interface{}, error)
+}
+
+// NewHookServer 创建hook服务器
+func NewHookServer(handler HookHandler) *HookServer {
+	s := &HookServer{handler: handler}
+	s.routes = map[string]func(ctx context.Context, body []byte) (interface{}, error){
+		"on_publish": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchAuthHook(ctx, body, handler.OnPublish)
+		},
+		"on_play": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchAuthHook(ctx, body, handler.OnPlay)
+		},
+		"on_flow_report": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchNotify(ctx, body, handler.OnFlowReport)
+		},
+		"on_http_access": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchAuthHook(ctx, body, handler.OnHttpAccess)
+		},
+		"on_record_mp4": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchNotify(ctx, body, handler.OnRecordMp4)
+		},
+		"on_record_ts": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchNotify(ctx, body, handler.OnRecordTs)
+		},
+		"on_rtsp_realm": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchHook(ctx, body, handler.OnRtspRealm)
+		},
+		"on_rtsp_auth": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchAuthHook(ctx, body, handler.OnRtspAuth)
+		},
+		"on_shell_login": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchAuthHook(ctx, body, handler.OnShellLogin)
+		},
+		"on_stream_changed": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchNotify(ctx, body, handler.OnStreamChanged)
+		},
+		"on_stream_none_reader": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchHook(ctx, body, handler.OnStreamNoneReader)
+		},
+		"on_stream_not_found": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchNotify(ctx, body, handler.OnStreamNotFound)
+		},
+		"on_server_started": func(ctx context.Context, body []byte) (interface{}, error) {
+			hook, err := decodeServerStarted(body)
+			if err != nil {
+				return nil, err
+			}
+			return &HookResult{Msg: "success"}, handler.OnServerStarted(ctx, hook)
+		},
+		"on_server_exited": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchNotify(ctx, body, handler.OnServerExited)
+		},
+		"on_server_keepalive": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchNotify(ctx, body, handler.OnServerKeepalive)
+		},
+		"on_rtp_server_timeout": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchNotify(ctx, body, handler.OnRtpServerTimeout)
+		},
+		"on_send_rtp_stopped": func(ctx context.Context, body []byte) (interface{}, error) {
+			return dispatchNotify(ctx, body, handler.OnSendRtpStopped)
+		},
+	}
+	return s
+}
+
+// ServeHTTP 实现http.Handler
+func (s *HookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	route, ok := s.routes[path.Base(r.URL.Path)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeHookResult(w, &HookResult{Code: CodeInvalidArgs, Msg: fmt.Sprintf("解析hook请求失败: %v", err)})
+		return
+	}
+
+	result, err := route(r.Context(), body)
+	if err != nil {
+		result := &HookResult{Code: CodeOtherFailed, Msg: err.Error()}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code != CodeSuccess {
+			result.Code, result.Msg = apiErr.Code, apiErr.Msg
+		}
+		writeHookResult(w, result)
+		return
+	}
+	writeHookResult(w, result)
+}
+
+// writeHookResult 写入hook响应
+func writeHookResult(w http.ResponseWriter, result interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// dispatchHook 解析需要响应内容的hook事件并调用处理函数
+func dispatchHook[H any, R any](ctx context.Context, body []byte, fn func(context.Context, *H) (*R, error)) (interface{}, error) {
+	var hook H
+	if err := json.Unmarshal(body, &hook); err != nil {
+		return nil, &APIError{Code: CodeInvalidArgs, Msg: fmt.Sprintf("解析hook请求失败: %v", err)}
+	}
+
+	result, err := fn(ctx, &hook)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return &HookResult{}, nil
+	}
+	return result, nil
+}
+
+// dispatchAuthHook 解析鉴权类hook事件并调用处理函数，处理函数返回nil结果时拒绝访问
+func dispatchAuthHook[H any, R any](ctx context.Context, body []byte, fn func(context.Context, *H) (*R, error)) (interface{}, error) {
+	var hook H
+	if err := json.Unmarshal(body, &hook); err != nil {
+		return nil, &APIError{Code: CodeInvalidArgs, Msg: fmt.Sprintf("解析hook请求失败: %v", err)}
+	}
+
+	result, err := fn(ctx, &hook)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return &HookResult{Code: CodeAuthFailed, Msg: "hook处理函数未返回鉴权结果"}, nil
+	}
+	return result, nil
+}
+
+// dispatchNotify 解析只需通知的hook事件并调用处理函数
+func dispatchNotify[H any](ctx context.Context, body []byte, fn func(context.Context, *H) error) (interface{}, error) {
+	var hook H
+	if err := json.Unmarshal(body, &hook); err != nil {
+		return nil, &APIError{Code: CodeInvalidArgs, Msg: fmt.Sprintf("解析hook请求失败: %v", err)}
+	}
+
+	if err := fn(ctx, &hook); err != nil {
+		return nil, err
+	}
+	return &HookResult{Msg: "success"}, nil
+}
+
+// decodeServerStarted 解析服务器启动事件，事件内容为服务器的完整配置
+func decodeServerStarted(body []byte) (*OnServerStartedHook, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, &APIError{Code: CodeInvalidArgs, Msg: fmt.Sprintf("解析hook请求失败: %v", err)}
+	}
+
+	hook := &OnServerStartedHook{Config: make(map[string]string, len(raw))}
+	for key, value := range raw {
+		if s, ok := value.(string); ok {
+			hook.Config[key] = s
+		} else {
+			hook.Config[key] = fmt.Sprint(value)
+		}
+	}
+	return hook, nil
+}
@@ -0,0 +1,104 @@
+package zlmedia_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// rejectingHookHandler 拒绝推流和播放的hook处理器
+type rejectingHookHandler struct {
+	zlmedia.NopHookHandler
+}
+
+func (rejectingHookHandler) OnPublish(ctx context.Context, hook *zlmedia.OnPublishHook) (*zlmedia.OnPublishResult, error) {
+	return nil, &zlmedia.APIError{Code: zlmedia.CodeAuthFailed, Msg: "forbidden"}
+}
+
+func (rejectingHookHandler) OnPlay(ctx context.Context, hook *zlmedia.OnPlayHook) (*zlmedia.HookResult, error) {
+	return nil, errors.New("boom")
+}
+
+// nilResultHookHandler 返回nil结果的hook处理器
+type nilResultHookHandler struct {
+	zlmedia.NopHookHandler
+}
+
+func (nilResultHookHandler) OnPublish(ctx context.Context, hook *zlmedia.OnPublishHook) (*zlmedia.OnPublishResult, error) {
+	return nil, nil
+}
+
+func (nilResultHookHandler) OnPlay(ctx context.Context, hook *zlmedia.OnPlayHook) (*zlmedia.HookResult, error) {
+	return nil, nil
+}
+
+func (nilResultHookHandler) OnHttpAccess(ctx context.Context, hook *zlmedia.OnHttpAccessHook) (*zlmedia.OnHttpAccessResult, error) {
+	return nil, nil
+}
+
+func (nilResultHookHandler) OnRtspAuth(ctx context.Context, hook *zlmedia.OnRtspAuthHook) (*zlmedia.OnRtspAuthResult, error) {
+	return nil, nil
+}
+
+func (nilResultHookHandler) OnShellLogin(ctx context.Context, hook *zlmedia.OnShellLoginHook) (*zlmedia.HookResult, error) {
+	return nil, nil
+}
+
+func (nilResultHookHandler) OnStreamNoneReader(ctx context.Context, hook *zlmedia.OnStreamNoneReaderHook) (*zlmedia.OnStreamNoneReaderResult, error) {
+	return nil, nil
+}
+
+func TestHookServer(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    zlmedia.HookHandler
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		want       string // 响应内容的前缀
+	}{
+		{"无人观看时不关闭流", zlmedia.NopHookHandler{}, http.MethodPost, "/index/hook/on_stream_none_reader", `{"app":"live","stream":"test"}`, http.StatusOK, `{"code":0,"close":false}`},
+		{"允许推流", zlmedia.NopHookHandler{}, http.MethodPost, "/index/hook/on_publish", `{"app":"live","stream":"test"}`, http.StatusOK, `{"code":0}`},
+		{"允许播放", zlmedia.NopHookHandler{}, http.MethodPost, "/index/hook/on_play", `{"app":"live","stream":"test"}`, http.StatusOK, `{"code":0}`},
+		{"允许访问", zlmedia.NopHookHandler{}, http.MethodPost, "/index/hook/on_http_access", `{"path":"/"}`, http.StatusOK, `{"code":0,"err":"","path":"","second":600}`},
+		{"通知事件", zlmedia.NopHookHandler{}, http.MethodPost, "/index/hook/on_stream_changed", `{"regist":true}`, http.StatusOK, `{"code":0,"msg":"success"}`},
+		{"挂载在任意前缀下", zlmedia.NopHookHandler{}, http.MethodPost, "/hooks/node1/on_server_keepalive", `{}`, http.StatusOK, `{"code":0,"msg":"success"}`},
+		{"处理器返回APIError", rejectingHookHandler{}, http.MethodPost, "/index/hook/on_publish", `{}`, http.StatusOK, `{"code":-100,"msg":"forbidden"}`},
+		{"处理器返回其它错误", rejectingHookHandler{}, http.MethodPost, "/index/hook/on_play", `{}`, http.StatusOK, `{"code":-1,"msg":"boom"}`},
+		{"推流鉴权返回nil结果", nilResultHookHandler{}, http.MethodPost, "/index/hook/on_publish", `{}`, http.StatusOK, `{"code":-100,`},
+		{"播放鉴权返回nil结果", nilResultHookHandler{}, http.MethodPost, "/index/hook/on_play", `{}`, http.StatusOK, `{"code":-100,`},
+		{"http访问鉴权返回nil结果", nilResultHookHandler{}, http.MethodPost, "/index/hook/on_http_access", `{}`, http.StatusOK, `{"code":-100,`},
+		{"rtsp鉴权返回nil结果", nilResultHookHandler{}, http.MethodPost, "/index/hook/on_rtsp_auth", `{}`, http.StatusOK, `{"code":-100,`},
+		{"shell登录返回nil结果", nilResultHookHandler{}, http.MethodPost, "/index/hook/on_shell_login", `{}`, http.StatusOK, `{"code":-100,`},
+		{"非鉴权hook返回nil结果", nilResultHookHandler{}, http.MethodPost, "/index/hook/on_stream_none_reader", `{}`, http.StatusOK, `{"code":0}`},
+		{"请求内容无法解析", zlmedia.NopHookHandler{}, http.MethodPost, "/index/hook/on_publish", `{`, http.StatusOK, `{"code":-300,`},
+		{"请求内容类型错误", zlmedia.NopHookHandler{}, http.MethodPost, "/index/hook/on_publish", `{"app":1}`, http.StatusOK, `{"code":-300,`},
+		{"未知事件", zlmedia.NopHookHandler{}, http.MethodPost, "/index/hook/on_unknown", `{}`, http.StatusNotFound, ``},
+		{"非POST请求", zlmedia.NopHookHandler{}, http.MethodGet, "/index/hook/on_publish", ``, http.StatusMethodNotAllowed, ``},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			zlmedia.NewHookServer(tt.handler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			body, _ := io.ReadAll(rec.Body)
+			if got := strings.TrimSpace(string(body)); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("body = %s, want prefix %s", got, tt.want)
+			}
+		})
+	}
+}
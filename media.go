@@ -15,9 +15,59 @@ func NewMediaAPI(client *Client) *MediaAPI {
 	return &MediaAPI{client: client}
 }
 
-// GetMediaAPI 获取流媒体API实例
-func GetMediaAPI() *MediaAPI {
-	return NewMediaAPI(GetClient())
+// OriginSock 流的源端连接信息
+type OriginSock struct {
+	Identifier string `json:"identifier"`
+	LocalIP    string `json:"local_ip"`
+	LocalPort  int    `json:"local_port"`
+	PeerIP     string `json:"peer_ip"`
+	PeerPort   int    `json:"peer_port"`
+}
+
+// Track 流的音视频轨道信息
+type Track struct {
+	CodecID       int     `json:"codec_id"`                  // 编码类型id
+	CodecIDName   string  `json:"codec_id_name"`             // 编码类型名称，例如H264、AAC
+	CodecType     int     `json:"codec_type"`                // 0为视频，1为音频
+	Ready         bool    `json:"ready"`                     // 轨道是否准备就绪
+	Frames        int64   `json:"frames,omitempty"`          // 累计接收帧数
+	Duration      int64   `json:"duration,omitempty"`        // 时长，单位毫秒
+	Loss          float64 `json:"loss,omitempty"`            // 丢包率，不是rtp推流时为-1
+	Channels      int     `json:"channels,omitempty"`        // 音频通道数
+	SampleBit     int     `json:"sample_bit,omitempty"`      // 音频采样位数
+	SampleRate    int     `json:"sample_rate,omitempty"`     // 音频采样率
+	FPS           float64 `json:"fps,omitempty"`             // 视频fps
+	Width         int     `json:"width,omitempty"`           // 视频宽
+	Height        int     `json:"height,omitempty"`          // 视频高
+	GopSize       int     `json:"gop_size,omitempty"`        // gop大小，单位帧数
+	GopIntervalMs int     `json:"gop_interval_ms,omitempty"` // gop间隔时间，单位毫秒
+	KeyFrames     int64   `json:"key_frames,omitempty"`      // 累计关键帧数
+}
+
+// MediaInfo 流媒体信息
+type MediaInfo struct {
+	Schema           string     `json:"schema"`           // 协议
+	VHost            string     `json:"vhost"`            // 虚拟主机
+	App              string     `json:"app"`              // 应用名
+	Stream           string     `json:"stream"`           // 流id
+	Params           string     `json:"params,omitempty"` // 推流url参数
+	ReaderCount      int        `json:"readerCount"`      // 本协议观看人数
+	TotalReaderCount int        `json:"totalReaderCount"` // 观看总人数，包括hls/rtsp/rtmp/http-flv/ws-flv/rtc
+	OriginType       int        `json:"originType"`       // 产生源类型
+	OriginTypeStr    string     `json:"originTypeStr"`    // 产生源类型名称
+	OriginURL        string     `json:"originUrl"`        // 产生源的url
+	OriginSock       OriginSock `json:"originSock"`       // 产生源的连接信息
+	CreateStamp      int64      `json:"createStamp"`      // 产生源的创建时间戳，单位秒
+	AliveSecond      int64      `json:"aliveSecond"`      // 存活时间，单位秒
+	BytesSpeed       int64      `json:"bytesSpeed"`       // 数据产生速度，单位byte/s
+	IsRecordingMP4   bool       `json:"isRecordingMP4"`   // 是否正在录制mp4
+	IsRecordingHLS   bool       `json:"isRecordingHLS"`   // 是否正在录制hls
+	Tracks           []Track    `json:"tracks"`           // 音视频轨道
+}
+
+// Key 获取流的唯一标识
+func (m MediaInfo) Key() StreamKey {
+	return StreamKey{VHost: m.VHost, App: m.App, Stream: m.Stream}
 }
 
 // GetMediaListRequest 获取流列表请求参数
@@ -37,7 +87,7 @@ type GetMediaListRequest struct {
 //   - Stream: 筛选流id，例如 test
 //
 // 返回: 流媒体列表信息
-func (m *MediaAPI) GetMediaList(ctx context.Context, req *GetMediaListRequest) (*BaseResponse, error) {
+func (m *MediaAPI) GetMediaList(ctx context.Context, req *GetMediaListRequest) (*Response[[]MediaInfo], error) {
 	params := make(map[string]interface{})
 	if req.Schema != "" {
 		params["schema"] = req.Schema
@@ -52,12 +102,12 @@ func (m *MediaAPI) GetMediaList(ctx context.Context, req *GetMediaListRequest) (
 		params["stream"] = req.Stream
 	}
 
-	respBody, err := m.client.SendRequest(ctx, "GET", "/index/api/getMediaList", params)
+	resp, err := doRequest[Response[[]MediaInfo]](ctx, m.client, "GET", "/index/api/getMediaList", params)
 	if err != nil {
-		return nil, fmt.Errorf("获取流列表失败: %w", err)
+		return resp, fmt.Errorf("获取流列表失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // CloseStreamRequest 关断单个流请求参数
@@ -69,6 +119,12 @@ type CloseStreamRequest struct {
 	Force  *bool  `json:"force,omitempty"` // 是否强制关闭(有人在观看是否还关闭)
 }
 
+// CloseStreamResponse 关断单个流响应
+type CloseStreamResponse struct {
+	Status
+	Result int `json:"result"` // 0：成功，-1：关闭失败，-2：该流不存在
+}
+
 // CloseStream 关断单个流
 // 关闭指定的流媒体
 // 参数:
@@ -79,7 +135,7 @@ type CloseStreamRequest struct {
 //   - Force: 是否强制关闭(有人在观看是否还关闭)
 //
 // 返回: 关闭结果
-func (m *MediaAPI) CloseStream(ctx context.Context, req *CloseStreamRequest) (*BaseResponse, error) {
+func (m *MediaAPI) CloseStream(ctx context.Context, req *CloseStreamRequest) (*CloseStreamResponse, error) {
 	params := map[string]interface{}{
 		"schema": req.Schema,
 		"vhost":  req.VHost,
@@ -94,12 +150,12 @@ func (m *MediaAPI) CloseStream(ctx context.Context, req *CloseStreamRequest) (*B
 		}
 	}
 
-	respBody, err := m.client.SendRequest(ctx, "GET", "/index/api/close_stream", params)
+	resp, err := doRequest[CloseStreamResponse](ctx, m.client, "GET", "/index/api/close_stream", params)
 	if err != nil {
-		return nil, fmt.Errorf("关闭流失败: %w", err)
+		return resp, fmt.Errorf("关闭流失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // CloseStreamsRequest 批量关断流请求参数
@@ -111,6 +167,13 @@ type CloseStreamsRequest struct {
 	Force  *bool  `json:"force,omitempty"`  // 是否强制关闭(有人在观看是否还关闭)
 }
 
+// CloseStreamsResponse 批量关断流响应
+type CloseStreamsResponse struct {
+	Status
+	CountHit    int `json:"count_hit"`    // 筛选命中的流个数
+	CountClosed int `json:"count_closed"` // 被关闭的流个数，可能小于count_hit
+}
+
 // CloseStreams 批量关断流
 // 批量关闭符合条件的流媒体
 // 参数:
@@ -121,7 +184,7 @@ type CloseStreamsRequest struct {
 //   - Force: 是否强制关闭(有人在观看是否还关闭)
 //
 // 返回: 关闭结果
-func (m *MediaAPI) CloseStreams(ctx context.Context, req *CloseStreamsRequest) (*BaseResponse, error) {
+func (m *MediaAPI) CloseStreams(ctx context.Context, req *CloseStreamsRequest) (*CloseStreamsResponse, error) {
 	params := make(map[string]interface{})
 	if req.Schema != "" {
 		params["schema"] = req.Schema
@@ -143,10 +206,10 @@ func (m *MediaAPI) CloseStreams(ctx context.Context, req *CloseStreamsRequest) (
 		}
 	}
 
-	respBody, err := m.client.SendRequest(ctx, "GET", "/index/api/close_streams", params)
+	resp, err := doRequest[CloseStreamsResponse](ctx, m.client, "GET", "/index/api/close_streams", params)
 	if err != nil {
-		return nil, fmt.Errorf("批量关闭流失败: %w", err)
+		return resp, fmt.Errorf("批量关闭流失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
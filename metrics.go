@@ -0,0 +1,267 @@
+package zlmedia
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsContentType Prometheus文本格式的Content-Type
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricsConfig 指标导出配置
+type MetricsConfig struct {
+	Namespace     string        // 指标名称前缀，默认为zlmedia
+	ScrapeTimeout time.Duration // 每个节点的采集超时时间，默认为5秒
+}
+
+// MetricsExporter ZLMediaKit指标导出器
+// 实现http.Handler，每次请求时采集注册表中所有节点的数据，并以Prometheus文本格式输出
+// 采集的接口: getStatistic、getThreadsLoad、getWorkThreadsLoad、getMediaList、getAllSession
+type MetricsExporter struct {
+	registry *Registry
+	config   MetricsConfig
+}
+
+// NewMetricsExporter 创建指标导出器
+func NewMetricsExporter(registry *Registry, config MetricsConfig) *MetricsExporter {
+	if config.Namespace == "" {
+		config.Namespace = "zlmedia"
+	}
+	if config.ScrapeTimeout <= 0 {
+		config.ScrapeTimeout = 5 * time.Second
+	}
+	return &MetricsExporter{registry: registry, config: config}
+}
+
+// metricFamily 同名指标的集合
+type metricFamily struct {
+	name    string
+	help    string
+	typ     string
+	samples []metricSample
+}
+
+// metricSample 单个指标样本
+type metricSample struct {
+	labels [][2]string
+	value  float64
+}
+
+// metricSet 一次采集得到的所有指标
+type metricSet struct {
+	namespace string
+	mu        sync.Mutex
+	families  map[string]*metricFamily
+}
+
+// add 添加一个样本，labels按name, value交替排列
+func (s *metricSet) add(name, typ, help string, value float64, labels ...string) {
+	name = s.namespace + "_" + name
+	sample := metricSample{value: value}
+	for i := 0; i+1 < len(labels); i += 2 {
+		sample.labels = append(sample.labels, [2]string{labels[i], labels[i+1]})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	family, ok := s.families[name]
+	if !ok {
+		family = &metricFamily{name: name, help: help, typ: typ}
+		s.families[name] = family
+	}
+	family.samples = append(family.samples, sample)
+}
+
+// write 以Prometheus文本格式输出，指标按名称排序，样本按标签排序
+func (s *metricSet) write(w io.Writer) error {
+	names := make([]string, 0, len(s.families))
+	for name := range s.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		family := s.families[name]
+		fmt.Fprintf(bw, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(bw, "# TYPE %s %s\n", family.name, family.typ)
+		sort.Slice(family.samples, func(i, j int) bool {
+			return family.samples[i].labelsKey() < family.samples[j].labelsKey()
+		})
+		for _, sample := range family.samples {
+			bw.WriteString(family.name)
+			if len(sample.labels) > 0 {
+				bw.WriteByte('{')
+				for i, label := range sample.labels {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					fmt.Fprintf(bw, "%s=\"%s\"", label[0], escapeLabelValue(label[1]))
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(strconv.FormatFloat(sample.value, 'g', -1, 64))
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// labelValueEscaper 转义标签值中的反斜杠、双引号和换行
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue 转义标签值
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+// labelsKey 用于样本排序的标签字符串
+func (m metricSample) labelsKey() string {
+	var b strings.Builder
+	for _, label := range m.labels {
+		b.WriteString(label[0])
+		b.WriteByte('=')
+		b.WriteString(label[1])
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// ServeHTTP 实现http.Handler
+// 采集失败体现在zlmedia_up和zlmedia_scrape_error指标中，响应总是完整的指标数据
+func (e *MetricsExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metricsContentType)
+	// 指标先完整生成再写入，写入失败时连接已不可用，无法再返回错误
+	_ = e.WriteMetrics(r.Context(), w)
+}
+
+// WriteMetrics 采集所有节点的数据并以Prometheus文本格式写入w
+// 单个节点采集失败不会返回错误，而是体现在zlmedia_up和zlmedia_scrape_error指标中；
+// 所有数据生成后才写入w，返回的错误只来自写入w
+func (e *MetricsExporter) WriteMetrics(ctx context.Context, w io.Writer) error {
+	set := &metricSet{namespace: e.config.Namespace, families: make(map[string]*metricFamily)}
+
+	var wg sync.WaitGroup
+	e.registry.Range(func(nodeID string, client *Client) bool {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.scrapeNode(ctx, set, nodeID, client)
+		}()
+		return true
+	})
+	wg.Wait()
+
+	var buf bytes.Buffer
+	if err := set.write(&buf); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// scrapeNode 采集单个节点
+func (e *MetricsExporter) scrapeNode(ctx context.Context, set *metricSet, nodeID string, client *Client) {
+	ctx, cancel := context.WithTimeout(ctx, e.config.ScrapeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	reachable, err := scrapeNodeMetrics(ctx, set, nodeID, client)
+	set.add("scrape_duration_seconds", "gauge", "节点采集耗时，单位秒",
+		time.Since(start).Seconds(), "node", nodeID)
+	set.add("up", "gauge", "节点是否可以访问，1为可以访问", boolGauge(reachable), "node", nodeID)
+	set.add("scrape_error", "gauge", "节点采集是否出错，1为出错，该节点的指标不完整", boolGauge(err != nil), "node", nodeID)
+}
+
+// boolGauge 将布尔值转换为0或1
+func boolGauge(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// scrapeNodeMetrics 调用节点的各个接口并生成指标
+// 返回: 节点是否可以访问（第一个接口调用成功），以及采集中的错误
+func scrapeNodeMetrics(ctx context.Context, set *metricSet, nodeID string, client *Client) (bool, error) {
+	serverAPI := NewServerAPI(client)
+
+	statistic, err := serverAPI.GetStatistic(ctx, &GetStatisticRequest{})
+	if err != nil {
+		return false, err
+	}
+	value := reflect.ValueOf(statistic.Data)
+	for i := 0; i < value.NumField(); i++ {
+		object := value.Type().Field(i).Tag.Get("json")
+		set.add("objects", "gauge", "ZLMediaKit主要对象个数",
+			float64(value.Field(i).Int()), "node", nodeID, "object", object)
+	}
+
+	for _, threadType := range []string{"network", "work"} {
+		var resp *Response[[]ThreadLoad]
+		if threadType == "network" {
+			resp, err = serverAPI.GetThreadsLoad(ctx, &GetThreadsLoadRequest{})
+		} else {
+			resp, err = serverAPI.GetWorkThreadsLoad(ctx, &GetWorkThreadsLoadRequest{})
+		}
+		if err != nil {
+			return true, err
+		}
+		for i, thread := range resp.Data {
+			index := strconv.Itoa(i)
+			set.add("thread_load", "gauge", "线程负载，0 ~ 100",
+				float64(thread.Load), "node", nodeID, "type", threadType, "thread", index)
+			set.add("thread_delay_milliseconds", "gauge", "线程延时，单位毫秒",
+				float64(thread.Delay), "node", nodeID, "type", threadType, "thread", index)
+		}
+	}
+
+	medias, err := NewMediaAPI(client).GetMediaList(ctx, &GetMediaListRequest{})
+	if err != nil {
+		return true, err
+	}
+	streamsPerSchema := make(map[string]int)
+	for _, media := range medias.Data {
+		streamsPerSchema[media.Schema]++
+		labels := []string{"node", nodeID, "vhost", media.VHost, "app", media.App, "stream", media.Stream, "schema", media.Schema}
+		set.add("stream_readers", "gauge", "流在该协议下的观看人数",
+			float64(media.ReaderCount), labels...)
+		set.add("stream_total_readers", "gauge", "流的观看总人数",
+			float64(media.TotalReaderCount), labels...)
+		set.add("stream_bytes_speed", "gauge", "流的数据产生速度，单位byte/s",
+			float64(media.BytesSpeed), labels...)
+		set.add("stream_alive_seconds", "gauge", "流的存活时间，单位秒",
+			float64(media.AliveSecond), labels...)
+	}
+	for schema, count := range streamsPerSchema {
+		set.add("streams", "gauge", "各协议的流数量",
+			float64(count), "node", nodeID, "schema", schema)
+	}
+
+	sessions, err := NewSessionAPI(client).GetAllSession(ctx, &GetAllSessionRequest{})
+	if err != nil {
+		return true, err
+	}
+	sessionsPerPort := make(map[int]int)
+	for _, session := range sessions.Data {
+		sessionsPerPort[session.LocalPort]++
+	}
+	for port, count := range sessionsPerPort {
+		set.add("sessions", "gauge", "各本机端口的tcp会话数量",
+			float64(count), "node", nodeID, "local_port", strconv.Itoa(port))
+	}
+
+	return true, nil
+}
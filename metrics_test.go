@@ -0,0 +1,77 @@
+package zlmedia_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+func TestMetricsExporter(t *testing.T) {
+	healthy := zlmtest.NewServer()
+	defer healthy.Close()
+	healthy.PublishStream(zlmedia.StreamKey{App: "live", Stream: "test"}, zlmtest.OriginTypeRtmpPush, "rtmp")
+
+	partial := zlmtest.NewServer()
+	defer partial.Close()
+	partial.InjectFault("/index/api/getAllSession", zlmtest.Fault{HTTPStatus: http.StatusInternalServerError})
+
+	down := zlmtest.NewServer()
+	downClient := down.Client()
+	down.Close()
+
+	registry := zlmedia.NewRegistry()
+	for nodeID, client := range map[string]*zlmedia.Client{"healthy": healthy.Client(), "partial": partial.Client(), "down": downClient} {
+		if err := registry.Add(nodeID, client); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := httptest.NewServer(zlmedia.NewMetricsExporter(registry, zlmedia.MetricsConfig{}))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Fatalf("status = %d, Content-Type = %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	tests := []struct {
+		name string
+		line string
+		want bool
+	}{
+		{"正常节点可以访问", `zlmedia_up{node="healthy"} 1`, true},
+		{"正常节点没有错误", `zlmedia_scrape_error{node="healthy"} 0`, true},
+		{"正常节点的流", `zlmedia_streams{node="healthy",schema="rtmp"} 1`, true},
+		{"部分失败的节点可以访问", `zlmedia_up{node="partial"} 1`, true},
+		{"部分失败的节点报告错误", `zlmedia_scrape_error{node="partial"} 1`, true},
+		{"部分失败的节点保留已采集的指标", `zlmedia_objects{node="partial",object="MediaSource"} 0`, true},
+		{"无法访问的节点", `zlmedia_up{node="down"} 0`, true},
+		{"无法访问的节点报告错误", `zlmedia_scrape_error{node="down"} 1`, true},
+		{"无法访问的节点没有其它指标", `zlmedia_objects{node="down"`, false},
+	}
+	lines := string(body)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Contains(lines, tt.line); got != tt.want {
+				t.Errorf("包含%q = %v, want %v", tt.line, got, tt.want)
+			}
+		})
+	}
+
+	// 每个指标族只有一组HELP/TYPE，且响应以完整的行结束
+	if strings.Count(lines, "# TYPE zlmedia_up ") != 1 || !strings.HasSuffix(lines, "\n") {
+		t.Errorf("响应格式错误:\n%s", lines)
+	}
+}
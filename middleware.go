@@ -0,0 +1,43 @@
+package zlmedia
+
+import "net/http"
+
+// Doer 发送HTTP请求的接口，*http.Client实现了该接口
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc 函数形式的Doer
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+// Do 实现Doer接口
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware HTTP中间件，包装下一个Doer
+// 可用于日志、注入请求头、故障注入、统计指标等
+type Middleware func(next Doer) Doer
+
+// chainMiddlewares 按顺序包装中间件，第一个中间件位于最外层
+func chainMiddlewares(doer Doer, middlewares []Middleware) Doer {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		doer = middlewares[i](doer)
+	}
+	return doer
+}
+
+// HeaderMiddleware 为每个请求设置指定的请求头
+func HeaderMiddleware(header http.Header) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			for key, values := range header {
+				req.Header.Del(key)
+				for _, value := range values {
+					req.Header.Add(key, value)
+				}
+			}
+			return next.Do(req)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package zlmedia_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+// callRecorder 记录中间件的调用顺序
+type callRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+// middleware 创建记录进入和返回的中间件，进入记为"name>"，返回记为"<name"
+func (r *callRecorder) middleware(name string) zlmedia.Middleware {
+	return func(next zlmedia.Doer) zlmedia.Doer {
+		return zlmedia.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			r.record(name + ">")
+			resp, err := next.Do(req)
+			r.record("<" + name)
+			return resp, err
+		})
+	}
+}
+
+func (r *callRecorder) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	const getMediaList = "/index/api/getMediaList"
+	once := []string{"a>", "b>", "c>", "<c", "<b", "<a"}
+
+	tests := []struct {
+		name      string
+		fault     *zlmtest.Fault
+		retry     bool
+		wantCalls []string
+		wantReqs  int
+		wantErr   bool
+	}{
+		{"第一个中间件位于最外层", nil, false, once, 1, false},
+		{"重试时每次请求都经过中间件", &zlmtest.Fault{HTTPStatus: http.StatusBadGateway, Times: 2}, true, append(append(append([]string{}, once...), once...), once...), 3, false},
+		{"未配置重试", &zlmtest.Fault{HTTPStatus: http.StatusBadGateway, Times: 1}, false, once, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm := zlmtest.NewServer()
+			defer zlm.Close()
+			if tt.fault != nil {
+				zlm.InjectFault(getMediaList, *tt.fault)
+			}
+
+			recorder := &callRecorder{}
+			config := zlm.Config()
+			config.Middlewares = []zlmedia.Middleware{recorder.middleware("a"), recorder.middleware("b"), recorder.middleware("c")}
+			if tt.retry {
+				config.RetryPolicy = &zlmedia.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Jitter: -1}
+			}
+			client := zlmedia.NewClient(config)
+
+			_, err := zlmedia.NewMediaAPI(client).GetMediaList(context.Background(), &zlmedia.GetMediaListRequest{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetMediaList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(recorder.calls, tt.wantCalls) {
+				t.Errorf("中间件调用顺序 = %v, want %v", recorder.calls, tt.wantCalls)
+			}
+			if got := zlm.RequestCount(getMediaList); got != tt.wantReqs {
+				t.Errorf("RequestCount(%s) = %d, want %d", getMediaList, got, tt.wantReqs)
+			}
+		})
+	}
+}
+
+func TestMiddlewareTracing(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	zlm.InjectFault("/index/api/getMediaList", zlmtest.Fault{HTTPStatus: http.StatusBadGateway, Times: 1})
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	// 记录每次请求时context中的span
+	var spanIDs []trace.SpanID
+	config := zlm.Config()
+	config.TracerProvider = provider
+	config.RetryPolicy = &zlmedia.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, Jitter: -1}
+	config.Middlewares = []zlmedia.Middleware{func(next zlmedia.Doer) zlmedia.Doer {
+		return zlmedia.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			spanIDs = append(spanIDs, trace.SpanContextFromContext(req.Context()).SpanID())
+			return next.Do(req)
+		})
+	}}
+	client := zlmedia.NewClient(config)
+
+	if _, err := zlmedia.NewMediaAPI(client).GetMediaList(context.Background(), &zlmedia.GetMediaListRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重试的请求属于同一个API调用span，中间件可以读取该span
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("len(spans) = %d, want 1", len(spans))
+	}
+	want := []trace.SpanID{spans[0].SpanContext.SpanID(), spans[0].SpanContext.SpanID()}
+	if !reflect.DeepEqual(spanIDs, want) {
+		t.Errorf("中间件中的span = %v, want %v", spanIDs, want)
+	}
+}
+
+func TestHeaderMiddleware(t *testing.T) {
+	const getMediaList = "/index/api/getMediaList"
+
+	tests := []struct {
+		name        string
+		middlewares []zlmedia.Middleware
+		want        http.Header // 服务器收到的请求头，只检查其中的key
+	}{
+		{"设置请求头", []zlmedia.Middleware{
+			zlmedia.HeaderMiddleware(http.Header{"X-Request-Id": {"abc"}, "User-Agent": {"zlmctl"}}),
+		}, http.Header{"X-Request-Id": {"abc"}, "User-Agent": {"zlmctl"}}},
+		{"多个值", []zlmedia.Middleware{
+			zlmedia.HeaderMiddleware(http.Header{"X-Tag": {"a", "b"}}),
+		}, http.Header{"X-Tag": {"a", "b"}}},
+		{"内层中间件覆盖外层的请求头", []zlmedia.Middleware{
+			zlmedia.HeaderMiddleware(http.Header{"X-Request-Id": {"outer"}, "X-Outer": {"1"}}),
+			zlmedia.HeaderMiddleware(http.Header{"X-Request-Id": {"inner"}}),
+		}, http.Header{"X-Request-Id": {"inner"}, "X-Outer": {"1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm := zlmtest.NewServer()
+			defer zlm.Close()
+			config := zlm.Config()
+			config.Middlewares = tt.middlewares
+			client := zlmedia.NewClient(config)
+
+			if _, err := zlmedia.NewMediaAPI(client).GetMediaList(context.Background(), &zlmedia.GetMediaListRequest{}); err != nil {
+				t.Fatal(err)
+			}
+			header := zlm.LastRequestHeader(getMediaList)
+			for key, values := range tt.want {
+				if got := header.Values(key); !reflect.DeepEqual(got, values) {
+					t.Errorf("请求头%s = %v, want %v", key, got, values)
+				}
+			}
+		})
+	}
+}
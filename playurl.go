@@ -0,0 +1,247 @@
+package zlmedia
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PlayProtocol 播放协议
+type PlayProtocol string
+
+// 播放协议
+const (
+	PlayRTSP     PlayProtocol = "rtsp"      // rtsp://host:554/app/stream
+	PlayRTMP     PlayProtocol = "rtmp"      // rtmp://host:1935/app/stream
+	PlayHTTPFLV  PlayProtocol = "http-flv"  // http://host/app/stream.live.flv
+	PlayWSFLV    PlayProtocol = "ws-flv"    // ws://host/app/stream.live.flv
+	PlayHLS      PlayProtocol = "hls"       // http://host/app/stream/hls.m3u8
+	PlayHLSFmp4  PlayProtocol = "hls-fmp4"  // http://host/app/stream/hls.fmp4.m3u8
+	PlayHTTPTS   PlayProtocol = "http-ts"   // http://host/app/stream.live.ts
+	PlayWSTS     PlayProtocol = "ws-ts"     // ws://host/app/stream.live.ts
+	PlayHTTPFmp4 PlayProtocol = "http-fmp4" // http://host/app/stream.live.mp4
+	PlayWSFmp4   PlayProtocol = "ws-fmp4"   // ws://host/app/stream.live.mp4
+	PlayWebRTC   PlayProtocol = "webrtc"    // http://host/index/api/webrtc?app=app&stream=stream&type=play
+	PlaySRT      PlayProtocol = "srt"       // srt://host:9000?streamid=#!::r=app/stream,m=request
+)
+
+// playProtocols 所有播放协议，Build按此顺序返回
+var playProtocols = []PlayProtocol{
+	PlayRTSP, PlayRTMP, PlayHTTPFLV, PlayWSFLV, PlayHLS, PlayHLSFmp4,
+	PlayHTTPTS, PlayWSTS, PlayHTTPFmp4, PlayWSFmp4, PlayWebRTC, PlaySRT,
+}
+
+// PlayURL 播放地址
+type PlayURL struct {
+	Protocol PlayProtocol `json:"protocol"` // 播放协议
+	URL      string       `json:"url"`      // 播放地址
+}
+
+// PlayURLBuilder 播放地址生成器
+// 根据节点的端口配置和转协议开关生成各协议的播放地址，端口为0的协议视为未开启
+type PlayURLBuilder struct {
+	Host   string        // 播放地址中的主机名或ip，例如对外的域名
+	Config *ServerConfig // 节点的服务器配置
+	Secure bool          // 是否使用https/wss/rtsps/rtmps，对应的ssl端口为0时该协议视为未开启
+}
+
+// NewPlayURLBuilder 获取节点的服务器配置并创建播放地址生成器
+// 参数:
+//   - client: 节点的客户端，BaseURL为https时生成https/wss/rtsps/rtmps播放地址
+//   - publicHost: 播放地址中的主机名，为空时使用客户端BaseURL中的主机名
+//
+// 返回: 播放地址生成器
+func NewPlayURLBuilder(ctx context.Context, client *Client, publicHost string) (*PlayURLBuilder, error) {
+	u, err := url.Parse(client.BaseURL())
+	if err != nil {
+		return nil, fmt.Errorf("解析BaseURL失败: %w", err)
+	}
+	// 与端口和转协议无关的配置项解析失败时不影响生成播放地址
+	config, err := NewServerAPI(client).GetTypedServerConfig(ctx)
+	if config == nil {
+		return nil, fmt.Errorf("创建播放地址生成器失败: %w", err)
+	}
+
+	if publicHost == "" {
+		publicHost = u.Hostname()
+	}
+	return &PlayURLBuilder{Host: publicHost, Config: config, Secure: u.Scheme == "https"}, nil
+}
+
+// Build 生成流在所有已开启协议下的播放地址
+// 参数:
+//   - key: 流的唯一标识
+//   - options: 流的转协议开关，为nil或字段为nil时使用服务器protocol.enable_*配置
+//   - params: 附加在播放地址上的参数，例如鉴权参数，可以为nil
+//
+// 返回: 按协议固定顺序排列的播放地址
+func (b *PlayURLBuilder) Build(key StreamKey, options *PublishOptions, params url.Values) []PlayURL {
+	var urls []PlayURL
+	for _, protocol := range playProtocols {
+		if !b.enabled(protocol, options) {
+			continue
+		}
+		if playURL, ok := b.URL(protocol, key, params); ok {
+			urls = append(urls, PlayURL{Protocol: protocol, URL: playURL})
+		}
+	}
+	return urls
+}
+
+// BuildForProxy 根据添加拉流代理的请求参数生成播放地址，使用请求中的enable_*开关
+func (b *PlayURLBuilder) BuildForProxy(req *AddStreamProxyRequest, params url.Values) []PlayURL {
+	options := &PublishOptions{
+		EnableHLS:     req.EnableHLS,
+		EnableHLSFmp4: req.EnableHLSFmp4,
+		EnableRtsp:    req.EnableRtsp,
+		EnableRtmp:    req.EnableRtmp,
+		EnableTS:      req.EnableTS,
+		EnableFmp4:    req.EnableFmp4,
+	}
+	return b.Build(StreamKey{VHost: req.VHost, App: req.App, Stream: req.Stream}, options, params)
+}
+
+// enabled 判断流是否开启了协议对应的转协议
+func (b *PlayURLBuilder) enabled(protocol PlayProtocol, options *PublishOptions) bool {
+	if options == nil {
+		options = &PublishOptions{}
+	}
+	pick := func(override *bool, fallback bool) bool {
+		if override != nil {
+			return *override
+		}
+		return fallback
+	}
+
+	protocolConfig := b.Config.Protocol
+	switch protocol {
+	case PlayRTSP, PlayWebRTC:
+		// webrtc播放复用rtsp的转协议
+		return pick(options.EnableRtsp, protocolConfig.EnableRtsp)
+	case PlayRTMP, PlayHTTPFLV, PlayWSFLV:
+		return pick(options.EnableRtmp, protocolConfig.EnableRtmp)
+	case PlayHLS:
+		return pick(options.EnableHLS, protocolConfig.EnableHLS)
+	case PlayHLSFmp4:
+		return pick(options.EnableHLSFmp4, protocolConfig.EnableHLSFmp4)
+	case PlayHTTPTS, PlayWSTS, PlaySRT:
+		// srt播放使用ts封装
+		return pick(options.EnableTS, protocolConfig.EnableTS)
+	case PlayHTTPFmp4, PlayWSFmp4:
+		return pick(options.EnableFmp4, protocolConfig.EnableFmp4)
+	default:
+		return false
+	}
+}
+
+// URL 生成流在指定协议下的播放地址，不检查转协议开关
+// 返回: 播放地址，协议对应的端口为0或srt的附加参数有多个值时返回false
+func (b *PlayURLBuilder) URL(protocol PlayProtocol, key StreamKey, params url.Values) (string, bool) {
+	query := url.Values{}
+	for name, values := range params {
+		query[name] = append([]string(nil), values...)
+	}
+	if key.NormalizedVHost() != DefaultVHost {
+		query.Set("vhost", key.VHost)
+	}
+	path := "/" + key.App + "/" + key.Stream
+
+	switch protocol {
+	case PlayRTSP:
+		return b.streamURL("rtsp", "rtsps", b.Config.RTSP.Port, b.Config.RTSP.SSLPort, 554, 322, path, query)
+	case PlayRTMP:
+		return b.streamURL("rtmp", "rtmps", b.Config.RTMP.Port, b.Config.RTMP.SSLPort, 1935, 443, path, query)
+	case PlayHTTPFLV:
+		return b.httpURL("http", "https", path+".live.flv", query)
+	case PlayWSFLV:
+		return b.httpURL("ws", "wss", path+".live.flv", query)
+	case PlayHLS:
+		return b.httpURL("http", "https", path+"/hls.m3u8", query)
+	case PlayHLSFmp4:
+		return b.httpURL("http", "https", path+"/hls.fmp4.m3u8", query)
+	case PlayHTTPTS:
+		return b.httpURL("http", "https", path+".live.ts", query)
+	case PlayWSTS:
+		return b.httpURL("ws", "wss", path+".live.ts", query)
+	case PlayHTTPFmp4:
+		return b.httpURL("http", "https", path+".live.mp4", query)
+	case PlayWSFmp4:
+		return b.httpURL("ws", "wss", path+".live.mp4", query)
+	case PlayWebRTC:
+		if b.Config.RTC.Port == 0 && b.Config.RTC.TCPPort == 0 {
+			return "", false
+		}
+		query.Set("app", key.App)
+		query.Set("stream", key.Stream)
+		query.Set("type", "play")
+		return b.httpURL("http", "https", "/index/api/webrtc", query)
+	case PlaySRT:
+		return b.srtURL(key, query)
+	default:
+		return "", false
+	}
+}
+
+// httpURL 生成使用http服务器端口的播放地址
+func (b *PlayURLBuilder) httpURL(scheme, secureScheme, path string, query url.Values) (string, bool) {
+	return b.streamURL(scheme, secureScheme, b.Config.HTTP.Port, b.Config.HTTP.SSLPort, 80, 443, path, query)
+}
+
+// streamURL 生成播放地址，端口为协议默认端口时省略端口
+func (b *PlayURLBuilder) streamURL(scheme, secureScheme string, port, sslPort, defaultPort, defaultSSLPort int, path string, query url.Values) (string, bool) {
+	if b.Secure {
+		scheme, port, defaultPort = secureScheme, sslPort, defaultSSLPort
+	}
+	if port == 0 {
+		return "", false
+	}
+
+	u := url.URL{Scheme: scheme, Host: b.hostPort(port, defaultPort), Path: path, RawQuery: query.Encode()}
+	return u.String(), true
+}
+
+// srtURL 生成srt播放地址，vhost和附加参数放在streamid中
+// streamid以逗号分隔字段，附加参数的名称和值经过url编码，ZLMediaKit将其原样转发到hook的params中；
+// streamid中同名字段只保留一个，附加参数有多个值时无法表示，返回false
+func (b *PlayURLBuilder) srtURL(key StreamKey, query url.Values) (string, bool) {
+	if b.Config.SRT.Port == 0 {
+		return "", false
+	}
+	for _, values := range query {
+		if len(values) > 1 {
+			return "", false
+		}
+	}
+
+	fields := make([]string, 0, len(query)+3)
+	if vhost := query.Get("vhost"); vhost != "" {
+		fields = append(fields, "h="+vhost)
+		query.Del("vhost")
+	}
+	fields = append(fields, "r="+key.App+"/"+key.Stream, "m=request")
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fields = append(fields, url.QueryEscape(name)+"="+url.QueryEscape(query.Get(name)))
+	}
+
+	return "srt://" + b.hostPort(b.Config.SRT.Port, 0) + "?streamid=#!::" + strings.Join(fields, ","), true
+}
+
+// hostPort 拼接主机和端口，端口为默认端口时省略
+func (b *PlayURLBuilder) hostPort(port, defaultPort int) string {
+	if port == defaultPort {
+		if strings.Contains(b.Host, ":") {
+			return "[" + b.Host + "]"
+		}
+		return b.Host
+	}
+	return net.JoinHostPort(b.Host, strconv.Itoa(port))
+}
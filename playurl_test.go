@@ -0,0 +1,169 @@
+package zlmedia_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+// testPlayConfig 生成使用ZLMediaKit默认端口并开启所有转协议的服务器配置
+func testPlayConfig() *zlmedia.ServerConfig {
+	config := &zlmedia.ServerConfig{}
+	config.HTTP.Port, config.HTTP.SSLPort = 80, 443
+	config.RTSP.Port, config.RTSP.SSLPort = 554, 322
+	config.RTMP.Port, config.RTMP.SSLPort = 1935, 443
+	config.RTC.Port, config.RTC.TCPPort = 8000, 8000
+	config.SRT.Port = 9000
+	config.Protocol.EnableRtsp, config.Protocol.EnableRtmp, config.Protocol.EnableTS = true, true, true
+	config.Protocol.EnableHLS, config.Protocol.EnableHLSFmp4, config.Protocol.EnableFmp4 = true, true, true
+	return config
+}
+
+func TestPlayURLBuilderURL(t *testing.T) {
+	key := zlmedia.StreamKey{App: "live", Stream: "test"}
+	vhostKey := zlmedia.StreamKey{VHost: "example.com", App: "live", Stream: "test"}
+	token := url.Values{"token": {"abc"}}
+
+	tests := []struct {
+		name     string
+		protocol zlmedia.PlayProtocol
+		key      zlmedia.StreamKey
+		params   url.Values
+		secure   bool
+		modify   func(config *zlmedia.ServerConfig) // 修改默认配置，可以为nil
+		want     string                             // 为空时应返回false
+	}{
+		{"rtsp", zlmedia.PlayRTSP, key, nil, false, nil, "rtsp://media.example.com/live/test"},
+		{"rtmp", zlmedia.PlayRTMP, key, nil, false, nil, "rtmp://media.example.com/live/test"},
+		{"http-flv", zlmedia.PlayHTTPFLV, key, nil, false, nil, "http://media.example.com/live/test.live.flv"},
+		{"ws-flv", zlmedia.PlayWSFLV, key, nil, false, nil, "ws://media.example.com/live/test.live.flv"},
+		{"hls", zlmedia.PlayHLS, key, nil, false, nil, "http://media.example.com/live/test/hls.m3u8"},
+		{"hls-fmp4", zlmedia.PlayHLSFmp4, key, nil, false, nil, "http://media.example.com/live/test/hls.fmp4.m3u8"},
+		{"http-ts", zlmedia.PlayHTTPTS, key, nil, false, nil, "http://media.example.com/live/test.live.ts"},
+		{"ws-ts", zlmedia.PlayWSTS, key, nil, false, nil, "ws://media.example.com/live/test.live.ts"},
+		{"http-fmp4", zlmedia.PlayHTTPFmp4, key, nil, false, nil, "http://media.example.com/live/test.live.mp4"},
+		{"ws-fmp4", zlmedia.PlayWSFmp4, key, nil, false, nil, "ws://media.example.com/live/test.live.mp4"},
+		{"webrtc", zlmedia.PlayWebRTC, key, nil, false, nil, "http://media.example.com/index/api/webrtc?app=live&stream=test&type=play"},
+		{"srt", zlmedia.PlaySRT, key, nil, false, nil, "srt://media.example.com:9000?streamid=#!::r=live/test,m=request"},
+		{"未知协议", zlmedia.PlayProtocol("unknown"), key, nil, false, nil, ""},
+
+		{"rtsps", zlmedia.PlayRTSP, key, nil, true, nil, "rtsps://media.example.com/live/test"},
+		{"rtmps", zlmedia.PlayRTMP, key, nil, true, nil, "rtmps://media.example.com/live/test"},
+		{"https-flv", zlmedia.PlayHTTPFLV, key, nil, true, nil, "https://media.example.com/live/test.live.flv"},
+		{"wss-flv", zlmedia.PlayWSFLV, key, nil, true, nil, "wss://media.example.com/live/test.live.flv"},
+		{"https-hls", zlmedia.PlayHLS, key, nil, true, nil, "https://media.example.com/live/test/hls.m3u8"},
+		{"https-webrtc", zlmedia.PlayWebRTC, key, nil, true, nil, "https://media.example.com/index/api/webrtc?app=live&stream=test&type=play"},
+		{"srt没有加密变体", zlmedia.PlaySRT, key, nil, true, nil, "srt://media.example.com:9000?streamid=#!::r=live/test,m=request"},
+
+		{"非默认端口", zlmedia.PlayHTTPFLV, key, nil, false, func(c *zlmedia.ServerConfig) { c.HTTP.Port = 8080 }, "http://media.example.com:8080/live/test.live.flv"},
+		{"非默认ssl端口", zlmedia.PlayRTSP, key, nil, true, func(c *zlmedia.ServerConfig) { c.RTSP.SSLPort = 8322 }, "rtsps://media.example.com:8322/live/test"},
+		{"端口为0", zlmedia.PlayRTMP, key, nil, false, func(c *zlmedia.ServerConfig) { c.RTMP.Port = 0 }, ""},
+		{"ssl端口为0", zlmedia.PlayHLS, key, nil, true, func(c *zlmedia.ServerConfig) { c.HTTP.SSLPort = 0 }, ""},
+		{"rtc端口为0", zlmedia.PlayWebRTC, key, nil, false, func(c *zlmedia.ServerConfig) { c.RTC.Port, c.RTC.TCPPort = 0, 0 }, ""},
+		{"srt端口为0", zlmedia.PlaySRT, key, nil, false, func(c *zlmedia.ServerConfig) { c.SRT.Port = 0 }, ""},
+
+		{"虚拟主机", zlmedia.PlayRTMP, vhostKey, nil, false, nil, "rtmp://media.example.com/live/test?vhost=example.com"},
+		{"srt虚拟主机", zlmedia.PlaySRT, vhostKey, nil, false, nil, "srt://media.example.com:9000?streamid=#!::h=example.com,r=live/test,m=request"},
+		{"附加参数", zlmedia.PlayHLS, key, token, false, nil, "http://media.example.com/live/test/hls.m3u8?token=abc"},
+		{"附加参数和虚拟主机", zlmedia.PlayHTTPTS, vhostKey, token, false, nil, "http://media.example.com/live/test.live.ts?token=abc&vhost=example.com"},
+		{"附加参数有多个值", zlmedia.PlayRTSP, key, url.Values{"k": {"1", "2"}}, false, nil, "rtsp://media.example.com/live/test?k=1&k=2"},
+		{"srt附加参数", zlmedia.PlaySRT, key, url.Values{"token": {"abc"}, "b": {"1"}}, false, nil, "srt://media.example.com:9000?streamid=#!::r=live/test,m=request,b=1,token=abc"},
+		{"srt附加参数中的保留字符", zlmedia.PlaySRT, key, url.Values{"sign": {"a,b=c&d"}}, false, nil, "srt://media.example.com:9000?streamid=#!::r=live/test,m=request,sign=a%2Cb%3Dc%26d"},
+		{"srt附加参数有多个值", zlmedia.PlaySRT, key, url.Values{"k": {"1", "2"}}, false, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testPlayConfig()
+			if tt.modify != nil {
+				tt.modify(config)
+			}
+			builder := &zlmedia.PlayURLBuilder{Host: "media.example.com", Config: config, Secure: tt.secure}
+
+			got, ok := builder.URL(tt.protocol, tt.key, tt.params)
+			if ok != (tt.want != "") || got != tt.want {
+				t.Errorf("URL() = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlayURLBuilderBuild(t *testing.T) {
+	key := zlmedia.StreamKey{App: "live", Stream: "test"}
+	off, on := false, true
+
+	tests := []struct {
+		name    string
+		modify  func(config *zlmedia.ServerConfig)
+		options *zlmedia.PublishOptions
+		want    []zlmedia.PlayProtocol
+	}{
+		{"所有协议", nil, nil, []zlmedia.PlayProtocol{
+			zlmedia.PlayRTSP, zlmedia.PlayRTMP, zlmedia.PlayHTTPFLV, zlmedia.PlayWSFLV, zlmedia.PlayHLS, zlmedia.PlayHLSFmp4,
+			zlmedia.PlayHTTPTS, zlmedia.PlayWSTS, zlmedia.PlayHTTPFmp4, zlmedia.PlayWSFmp4, zlmedia.PlayWebRTC, zlmedia.PlaySRT,
+		}},
+		{"流关闭转协议", nil, &zlmedia.PublishOptions{EnableRtsp: &off, EnableTS: &off, EnableHLSFmp4: &off, EnableFmp4: &off}, []zlmedia.PlayProtocol{
+			zlmedia.PlayRTMP, zlmedia.PlayHTTPFLV, zlmedia.PlayWSFLV, zlmedia.PlayHLS,
+		}},
+		{"流开启服务器关闭的转协议", func(c *zlmedia.ServerConfig) {
+			c.Protocol = zlmedia.ProtocolConfig{}
+		}, &zlmedia.PublishOptions{EnableHLS: &on}, []zlmedia.PlayProtocol{zlmedia.PlayHLS}},
+		{"端口为0的协议", func(c *zlmedia.ServerConfig) {
+			c.HTTP.Port, c.SRT.Port = 0, 0
+		}, nil, []zlmedia.PlayProtocol{zlmedia.PlayRTSP, zlmedia.PlayRTMP}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testPlayConfig()
+			if tt.modify != nil {
+				tt.modify(config)
+			}
+			builder := &zlmedia.PlayURLBuilder{Host: "media.example.com", Config: config}
+
+			var got []zlmedia.PlayProtocol
+			for _, playURL := range builder.Build(key, tt.options, nil) {
+				got = append(got, playURL.Protocol)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Build() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPlayURLBuilder(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	tlsServer := httptest.NewTLSServer(zlm)
+	defer tlsServer.Close()
+
+	tests := []struct {
+		name       string
+		client     *zlmedia.Client
+		publicHost string
+		wantHost   string
+		wantSecure bool
+	}{
+		{"http", zlm.Client(), "", "127.0.0.1", false},
+		{"https", zlmedia.NewClient(zlmedia.Config{BaseURL: tlsServer.URL, Secret: zlm.Secret(), HTTPClient: tlsServer.Client()}), "", "127.0.0.1", true},
+		{"对外的主机名", zlm.Client(), "media.example.com", "media.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder, err := zlmedia.NewPlayURLBuilder(context.Background(), tt.client, tt.publicHost)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if builder.Host != tt.wantHost || builder.Secure != tt.wantSecure {
+				t.Errorf("NewPlayURLBuilder() Host = %q, Secure = %v, want %q, %v", builder.Host, builder.Secure, tt.wantHost, tt.wantSecure)
+			}
+			if builder.Config.SRT.Port != 9000 {
+				t.Errorf("Config.SRT.Port = %d, want 9000", builder.Config.SRT.Port)
+			}
+		})
+	}
+}
@@ -15,9 +15,43 @@ func NewProxyAPI(client *Client) *ProxyAPI {
 	return &ProxyAPI{client: client}
 }
 
-// GetProxyAPI 获取代理API实例
-func GetProxyAPI() *ProxyAPI {
-	return NewProxyAPI(GetClient())
+// StreamTuple 流的虚拟主机、应用名和流id
+type StreamTuple struct {
+	VHost  string `json:"vhost"`
+	App    string `json:"app"`
+	Stream string `json:"stream"`
+	Params string `json:"params,omitempty"`
+}
+
+// ProxyKey 添加代理后返回的key
+type ProxyKey struct {
+	Key string `json:"key"` // 代理的唯一标识，用于关闭代理
+}
+
+// DelProxyResult 关闭代理结果
+type DelProxyResult struct {
+	Flag bool `json:"flag"` // 成功与否
+}
+
+// ProxyInfo 拉流代理信息
+type ProxyInfo struct {
+	Key              string      `json:"key"`              // 拉流代理的key
+	URL              string      `json:"url"`              // 拉流地址
+	Src              StreamTuple `json:"src"`              // 拉流代理生成的流
+	Status           int         `json:"status"`           // 拉流状态
+	LiveSecs         int64       `json:"liveSecs"`         // 本次拉流持续时间，单位秒
+	RePullCount      int         `json:"rePullCount"`      // 重新拉流次数
+	TotalReaderCount int         `json:"totalReaderCount"` // 观看总人数
+}
+
+// PusherProxyInfo 推流代理信息
+type PusherProxyInfo struct {
+	Key            string      `json:"key"`            // 推流代理的key
+	URL            string      `json:"url"`            // 推流地址
+	Src            StreamTuple `json:"src"`            // 被推送的流
+	Status         int         `json:"status"`         // 推流状态
+	LiveSecs       int64       `json:"liveSecs"`       // 本次推流持续时间，单位秒
+	RePublishCount int         `json:"rePublishCount"` // 重新推流次数
 }
 
 // AddStreamProxyRequest 添加拉流代理请求参数
@@ -60,7 +94,7 @@ type AddStreamProxyRequest struct {
 //   - 其他参数: 各种转码和录制选项
 //
 // 返回: 拉流代理的key，用于后续管理
-func (p *ProxyAPI) AddStreamProxy(ctx context.Context, req *AddStreamProxyRequest) (*BaseResponse, error) {
+func (p *ProxyAPI) AddStreamProxy(ctx context.Context, req *AddStreamProxyRequest) (*Response[ProxyKey], error) {
 	params := map[string]interface{}{
 		"vhost":  req.VHost,
 		"app":    req.App,
@@ -166,12 +200,12 @@ func (p *ProxyAPI) AddStreamProxy(ctx context.Context, req *AddStreamProxyReques
 		params["passphrase"] = req.Passphrase
 	}
 
-	respBody, err := p.client.SendRequest(ctx, "GET", "/index/api/addStreamProxy", params)
+	resp, err := doRequest[Response[ProxyKey]](ctx, p.client, "GET", "/index/api/addStreamProxy", params)
 	if err != nil {
-		return nil, fmt.Errorf("添加拉流代理失败: %w", err)
+		return resp, fmt.Errorf("添加拉流代理失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // DelStreamProxyRequest 关闭拉流代理请求参数
@@ -185,17 +219,17 @@ type DelStreamProxyRequest struct {
 //   - Key: addStreamProxy接口返回的key
 //
 // 返回: 关闭结果
-func (p *ProxyAPI) DelStreamProxy(ctx context.Context, req *DelStreamProxyRequest) (*BaseResponse, error) {
+func (p *ProxyAPI) DelStreamProxy(ctx context.Context, req *DelStreamProxyRequest) (*Response[DelProxyResult], error) {
 	params := map[string]interface{}{
 		"key": req.Key,
 	}
 
-	respBody, err := p.client.SendRequest(ctx, "GET", "/index/api/delStreamProxy", params)
+	resp, err := doRequest[Response[DelProxyResult]](ctx, p.client, "GET", "/index/api/delStreamProxy", params)
 	if err != nil {
-		return nil, fmt.Errorf("关闭拉流代理失败: %w", err)
+		return resp, fmt.Errorf("关闭拉流代理失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // ListStreamProxyRequest 获取拉流代理列表请求参数
@@ -206,13 +240,13 @@ type ListStreamProxyRequest struct {
 // ListStreamProxy 获取拉流代理列表
 // 获取所有拉流代理的列表
 // 返回: 拉流代理列表信息
-func (p *ProxyAPI) ListStreamProxy(ctx context.Context, req *ListStreamProxyRequest) (*BaseResponse, error) {
-	respBody, err := p.client.SendRequest(ctx, "GET", "/index/api/listStreamProxy", nil)
+func (p *ProxyAPI) ListStreamProxy(ctx context.Context, req *ListStreamProxyRequest) (*Response[[]ProxyInfo], error) {
+	resp, err := doRequest[Response[[]ProxyInfo]](ctx, p.client, "GET", "/index/api/listStreamProxy", nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取拉流代理列表失败: %w", err)
+		return resp, fmt.Errorf("获取拉流代理列表失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // AddStreamPusherProxyRequest 添加推流代理请求参数
@@ -240,7 +274,7 @@ type AddStreamPusherProxyRequest struct {
 //   - RetryCount: 推流重试次数,不传此参数或传值<=0时，则无限重试
 //
 // 返回: 推流代理的key，用于后续管理
-func (p *ProxyAPI) AddStreamPusherProxy(ctx context.Context, req *AddStreamPusherProxyRequest) (*BaseResponse, error) {
+func (p *ProxyAPI) AddStreamPusherProxy(ctx context.Context, req *AddStreamPusherProxyRequest) (*Response[ProxyKey], error) {
 	params := map[string]interface{}{
 		"schema":  req.Schema,
 		"vhost":   req.VHost,
@@ -259,12 +293,12 @@ func (p *ProxyAPI) AddStreamPusherProxy(ctx context.Context, req *AddStreamPushe
 		params["retry_count"] = *req.RetryCount
 	}
 
-	respBody, err := p.client.SendRequest(ctx, "GET", "/index/api/addStreamPusherProxy", params)
+	resp, err := doRequest[Response[ProxyKey]](ctx, p.client, "GET", "/index/api/addStreamPusherProxy", params)
 	if err != nil {
-		return nil, fmt.Errorf("添加推流代理失败: %w", err)
+		return resp, fmt.Errorf("添加推流代理失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // ListStreamPusherProxyRequest 获取推流代理列表请求参数
@@ -275,11 +309,11 @@ type ListStreamPusherProxyRequest struct {
 // ListStreamPusherProxy 获取推流代理列表
 // 获取所有推流代理的列表
 // 返回: 推流代理列表信息
-func (p *ProxyAPI) ListStreamPusherProxy(ctx context.Context, req *ListStreamPusherProxyRequest) (*BaseResponse, error) {
-	respBody, err := p.client.SendRequest(ctx, "GET", "/index/api/listStreamPusherProxy", nil)
+func (p *ProxyAPI) ListStreamPusherProxy(ctx context.Context, req *ListStreamPusherProxyRequest) (*Response[[]PusherProxyInfo], error) {
+	resp, err := doRequest[Response[[]PusherProxyInfo]](ctx, p.client, "GET", "/index/api/listStreamPusherProxy", nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取推流代理列表失败: %w", err)
+		return resp, fmt.Errorf("获取推流代理列表失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
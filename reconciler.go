@@ -0,0 +1,214 @@
+package zlmedia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ProxyReconcilerConfig 拉流代理调和器配置
+type ProxyReconcilerConfig struct {
+	Interval      time.Duration                            // 调和间隔，默认为30秒
+	Prune         bool                                     // 是否删除不在期望集合中的所有拉流代理，默认只删除由调和器添加过的代理
+	MediaServerID string                                   // 只响应该服务器的on_server_started事件，为空时响应所有服务器
+	OnReconcile   func(result *ReconcileResult, err error) // 每次调和完成后的回调，可用于记录日志
+}
+
+// ReconcileResult 一次调和的结果
+type ReconcileResult struct {
+	Added   []StreamKey // 新添加的拉流代理
+	Updated []StreamKey // 拉流地址或参数变化而重建的拉流代理
+	Removed []StreamKey // 删除的拉流代理
+	Failed  []StreamKey // 调和失败的拉流代理
+}
+
+// ProxyReconciler 声明式拉流代理调和器
+// 比较期望的拉流代理集合与listStreamProxy的结果，添加缺失的代理、重建拉流地址或参数变化的代理并删除多余的代理，
+// 定期重新调和，并在ZLMediaKit重启(on_server_started)后立即调和。
+// listStreamProxy只返回拉流地址，参数是否变化通过最后一次应用的请求判断；
+// 已存在且拉流地址一致但不是由调和器添加的代理会被直接接管，不会重建
+type ProxyReconciler struct {
+	client  *Client
+	config  ProxyReconcilerConfig
+	trigger chan struct{}
+
+	// reconcileMu 保证同一时间只有一次调和
+	reconcileMu sync.Mutex
+
+	mu      sync.Mutex
+	desired map[StreamKey]AddStreamProxyRequest
+	applied map[StreamKey]AddStreamProxyRequest // 由调和器添加或接管的代理，值为最后一次应用的请求
+}
+
+// NewProxyReconciler 创建拉流代理调和器
+func NewProxyReconciler(client *Client, config ProxyReconcilerConfig) *ProxyReconciler {
+	if config.Interval <= 0 {
+		config.Interval = 30 * time.Second
+	}
+
+	return &ProxyReconciler{
+		client:  client,
+		config:  config,
+		trigger: make(chan struct{}, 1),
+		desired: make(map[StreamKey]AddStreamProxyRequest),
+		applied: make(map[StreamKey]AddStreamProxyRequest),
+	}
+}
+
+// SetDesired 设置期望的拉流代理集合，替换之前的集合
+// 以vhost/app/stream区分代理，重复的流以最后一个为准；设置后会触发一次调和
+func (r *ProxyReconciler) SetDesired(proxies []AddStreamProxyRequest) {
+	desired := make(map[StreamKey]AddStreamProxyRequest, len(proxies))
+	for _, proxy := range proxies {
+		key := StreamKey{VHost: proxy.VHost, App: proxy.App, Stream: proxy.Stream}.Normalize()
+		proxy.VHost = key.VHost
+		desired[key] = proxy
+	}
+
+	r.mu.Lock()
+	r.desired = desired
+	r.mu.Unlock()
+
+	r.Trigger()
+}
+
+// Trigger 请求Run尽快执行一次调和，不会阻塞
+func (r *ProxyReconciler) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run 立即调和一次，之后按Interval定期调和或在Trigger后调和，直到ctx结束
+// 调和失败不会中断运行，结果通过OnReconcile回调报告
+func (r *ProxyReconciler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := r.Reconcile(ctx)
+		if r.config.OnReconcile != nil {
+			r.config.OnReconcile(result, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-r.trigger:
+		}
+	}
+}
+
+// Reconcile 执行一次调和
+// 单个代理的失败不会中断调和，所有失败会合并为一个错误返回
+// 返回: 调和结果，获取拉流代理列表失败时为nil
+func (r *ProxyReconciler) Reconcile(ctx context.Context) (*ReconcileResult, error) {
+	r.reconcileMu.Lock()
+	defer r.reconcileMu.Unlock()
+
+	proxyAPI := NewProxyAPI(r.client)
+	list, err := proxyAPI.ListStreamProxy(ctx, &ListStreamProxyRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("调和拉流代理失败: %w", err)
+	}
+
+	current := make(map[StreamKey]ProxyInfo, len(list.Data))
+	for _, proxy := range list.Data {
+		key := StreamKey{VHost: proxy.Src.VHost, App: proxy.Src.App, Stream: proxy.Src.Stream}.Normalize()
+		current[key] = proxy
+	}
+
+	r.mu.Lock()
+	desired := make(map[StreamKey]AddStreamProxyRequest, len(r.desired))
+	for key, proxy := range r.desired {
+		desired[key] = proxy
+	}
+	applied := make(map[StreamKey]AddStreamProxyRequest, len(r.applied))
+	for key, proxy := range r.applied {
+		applied[key] = proxy
+	}
+	r.mu.Unlock()
+
+	result := &ReconcileResult{}
+	var errs []error
+
+	for _, key := range sortedStreamKeys(current) {
+		proxy := current[key]
+		_, managed := applied[key]
+		if _, ok := desired[key]; ok || !(r.config.Prune || managed) {
+			continue
+		}
+		if _, err := proxyAPI.DelStreamProxy(ctx, &DelStreamProxyRequest{Key: proxy.Key}); err != nil {
+			result.Failed = append(result.Failed, key)
+			errs = append(errs, fmt.Errorf("删除拉流代理%s失败: %w", key, err))
+			continue
+		}
+		delete(applied, key)
+		result.Removed = append(result.Removed, key)
+	}
+
+	for _, key := range sortedStreamKeys(desired) {
+		want := desired[key]
+		proxy, exists := current[key]
+		if exists && proxy.URL == want.URL {
+			// 没有应用记录的代理直接接管，否则参数也一致时才视为未变化
+			if last, ok := applied[key]; !ok || reflect.DeepEqual(last, want) {
+				applied[key] = want
+				continue
+			}
+		}
+		if exists {
+			if _, err := proxyAPI.DelStreamProxy(ctx, &DelStreamProxyRequest{Key: proxy.Key}); err != nil {
+				result.Failed = append(result.Failed, key)
+				errs = append(errs, fmt.Errorf("重建拉流代理%s失败: %w", key, err))
+				continue
+			}
+		}
+		if _, err := proxyAPI.AddStreamProxy(ctx, &want); err != nil {
+			result.Failed = append(result.Failed, key)
+			errs = append(errs, fmt.Errorf("拉流代理%s: %w", key, err))
+			continue
+		}
+		applied[key] = want
+		if exists {
+			result.Updated = append(result.Updated, key)
+		} else {
+			result.Added = append(result.Added, key)
+		}
+	}
+
+	r.mu.Lock()
+	r.applied = applied
+	r.mu.Unlock()
+
+	return result, errors.Join(errs...)
+}
+
+// HookHandler 创建在on_server_started事件后触发调和的HookHandler
+// 事件先触发调和，再调用next，next为nil时使用NopHookHandler
+func (r *ProxyReconciler) HookHandler(next HookHandler) HookHandler {
+	if next == nil {
+		next = NopHookHandler{}
+	}
+	return &reconcilerHookHandler{HookHandler: next, reconciler: r}
+}
+
+// reconcilerHookHandler 在服务器启动后触发调和的HookHandler
+type reconcilerHookHandler struct {
+	HookHandler
+	reconciler *ProxyReconciler
+}
+
+// OnServerStarted 服务器启动后所有拉流代理都已丢失，立即触发调和
+func (h *reconcilerHookHandler) OnServerStarted(ctx context.Context, hook *OnServerStartedHook) error {
+	serverID := h.reconciler.config.MediaServerID
+	if serverID == "" || serverID == hook.MediaServerID() {
+		h.reconciler.Trigger()
+	}
+	return h.HookHandler.OnServerStarted(ctx, hook)
+}
@@ -0,0 +1,63 @@
+package zlmedia_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+// testProxy 生成拉流代理请求
+func testProxy(stream, url string, retryCount int) zlmedia.AddStreamProxyRequest {
+	return zlmedia.AddStreamProxyRequest{App: "live", Stream: stream, URL: url, RetryCount: &retryCount}
+}
+
+func TestProxyReconciler(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	ctx := context.Background()
+
+	// 其它进程添加的代理
+	if _, err := zlmedia.NewProxyAPI(zlm.Client()).AddStreamProxy(ctx, &zlmedia.AddStreamProxyRequest{VHost: zlmedia.DefaultVHost, App: "live", Stream: "external", URL: "rtsp://external"}); err != nil {
+		t.Fatal(err)
+	}
+	reconciler := zlmedia.NewProxyReconciler(zlm.Client(), zlmedia.ProxyReconcilerConfig{})
+
+	// 按顺序执行，每一步在上一步的基础上调和
+	tests := []struct {
+		name    string
+		desired []zlmedia.AddStreamProxyRequest
+		before  func()
+		want    string // added/updated/removed
+		proxies int    // 调和后ZLMediaKit上的代理数量
+	}{
+		{"添加缺失的代理", []zlmedia.AddStreamProxyRequest{testProxy("a", "rtsp://a", 0), testProxy("b", "rtsp://b", 0)}, nil, "[__defaultVhost__/live/a __defaultVhost__/live/b]/[]/[]", 3},
+		{"没有变化", []zlmedia.AddStreamProxyRequest{testProxy("a", "rtsp://a", 0), testProxy("b", "rtsp://b", 0)}, nil, "[]/[]/[]", 3},
+		{"拉流地址变化", []zlmedia.AddStreamProxyRequest{testProxy("a", "rtsp://a2", 0), testProxy("b", "rtsp://b", 0)}, nil, "[]/[__defaultVhost__/live/a]/[]", 3},
+		{"参数变化", []zlmedia.AddStreamProxyRequest{testProxy("a", "rtsp://a2", 0), testProxy("b", "rtsp://b", 3)}, nil, "[]/[__defaultVhost__/live/b]/[]", 3},
+		{"接管地址一致的代理", []zlmedia.AddStreamProxyRequest{testProxy("a", "rtsp://a2", 0), testProxy("b", "rtsp://b", 3), testProxy("external", "rtsp://external", 0)}, nil, "[]/[]/[]", 3},
+		{"接管后参数变化", []zlmedia.AddStreamProxyRequest{testProxy("a", "rtsp://a2", 0), testProxy("b", "rtsp://b", 3), testProxy("external", "rtsp://external", 5)}, nil, "[]/[__defaultVhost__/live/external]/[]", 3},
+		{"删除不再期望的代理", []zlmedia.AddStreamProxyRequest{testProxy("a", "rtsp://a2", 0)}, nil, "[]/[]/[__defaultVhost__/live/b __defaultVhost__/live/external]", 1},
+		{"ZLMediaKit重启", []zlmedia.AddStreamProxyRequest{testProxy("a", "rtsp://a2", 0)}, zlm.Restart, "[__defaultVhost__/live/a]/[]/[]", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.before != nil {
+				tt.before()
+			}
+			reconciler.SetDesired(tt.desired)
+			result, err := reconciler.Reconcile(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := fmt.Sprintf("%v/%v/%v", result.Added, result.Updated, result.Removed); got != tt.want {
+				t.Errorf("Reconcile() = %s, want %s", got, tt.want)
+			}
+			if got := len(zlm.Proxies()); got != tt.proxies {
+				t.Errorf("len(Proxies()) = %d, want %d", got, tt.proxies)
+			}
+		})
+	}
+}
@@ -1,8 +1,17 @@
 package zlmedia
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 )
 
 // RecordAPI 录制管理相关API
@@ -15,11 +24,6 @@ func NewRecordAPI(client *Client) *RecordAPI {
 	return &RecordAPI{client: client}
 }
 
-// GetRecordAPI 获取录制API实例
-func GetRecordAPI() *RecordAPI {
-	return NewRecordAPI(GetClient())
-}
-
 // IsRecordingRequest 判断是否正在录制请求参数
 type IsRecordingRequest struct {
 	Type   int    `json:"type"`   // 0为hls，1为mp4
@@ -28,6 +32,12 @@ type IsRecordingRequest struct {
 	Stream string `json:"stream"` // 流id，例如obs
 }
 
+// IsRecordingResponse 判断是否正在录制响应
+type IsRecordingResponse struct {
+	Status
+	Recording bool `json:"status"` // 是否正在录制
+}
+
 // IsRecording 判断是否正在录制
 // 检查指定流是否正在录制
 // 参数:
@@ -37,7 +47,7 @@ type IsRecordingRequest struct {
 //   - Stream: 流id，例如obs
 //
 // 返回: 录制状态信息
-func (r *RecordAPI) IsRecording(ctx context.Context, req *IsRecordingRequest) (*BaseResponse, error) {
+func (r *RecordAPI) IsRecording(ctx context.Context, req *IsRecordingRequest) (*IsRecordingResponse, error) {
 	params := map[string]interface{}{
 		"type":   req.Type,
 		"vhost":  req.VHost,
@@ -45,12 +55,12 @@ func (r *RecordAPI) IsRecording(ctx context.Context, req *IsRecordingRequest) (*
 		"stream": req.Stream,
 	}
 
-	respBody, err := r.client.SendRequest(ctx, "GET", "/index/api/isRecording", params)
+	resp, err := doRequest[IsRecordingResponse](ctx, r.client, "GET", "/index/api/isRecording", params)
 	if err != nil {
-		return nil, fmt.Errorf("判断录制状态失败: %w", err)
+		return resp, fmt.Errorf("判断录制状态失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // StartRecordRequest 开始录制请求参数
@@ -63,6 +73,12 @@ type StartRecordRequest struct {
 	MaxSecond      *int   `json:"max_second,omitempty"`      // mp4录制切片大小，单位秒，置空时采用配置文件默认值
 }
 
+// RecordResultResponse 开始/停止录制响应
+type RecordResultResponse struct {
+	Status
+	Result bool `json:"result"` // 成功与否
+}
+
 // StartRecord 开始录制
 // 开始录制指定流
 // 参数:
@@ -74,7 +90,7 @@ type StartRecordRequest struct {
 //   - MaxSecond: mp4录制切片大小，单位秒，置空时采用配置文件默认值
 //
 // 返回: 开始录制结果
-func (r *RecordAPI) StartRecord(ctx context.Context, req *StartRecordRequest) (*BaseResponse, error) {
+func (r *RecordAPI) StartRecord(ctx context.Context, req *StartRecordRequest) (*RecordResultResponse, error) {
 	params := map[string]interface{}{
 		"type":   req.Type,
 		"vhost":  req.VHost,
@@ -89,12 +105,12 @@ func (r *RecordAPI) StartRecord(ctx context.Context, req *StartRecordRequest) (*
 		params["max_second"] = *req.MaxSecond
 	}
 
-	respBody, err := r.client.SendRequest(ctx, "GET", "/index/api/startRecord", params)
+	resp, err := doRequest[RecordResultResponse](ctx, r.client, "GET", "/index/api/startRecord", params)
 	if err != nil {
-		return nil, fmt.Errorf("开始录制失败: %w", err)
+		return resp, fmt.Errorf("开始录制失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // StopRecordRequest 停止录制请求参数
@@ -114,7 +130,7 @@ type StopRecordRequest struct {
 //   - Stream: 流id，例如obs
 //
 // 返回: 停止录制结果
-func (r *RecordAPI) StopRecord(ctx context.Context, req *StopRecordRequest) (*BaseResponse, error) {
+func (r *RecordAPI) StopRecord(ctx context.Context, req *StopRecordRequest) (*RecordResultResponse, error) {
 	params := map[string]interface{}{
 		"type":   req.Type,
 		"vhost":  req.VHost,
@@ -122,12 +138,12 @@ func (r *RecordAPI) StopRecord(ctx context.Context, req *StopRecordRequest) (*Ba
 		"stream": req.Stream,
 	}
 
-	respBody, err := r.client.SendRequest(ctx, "GET", "/index/api/stopRecord", params)
+	resp, err := doRequest[RecordResultResponse](ctx, r.client, "GET", "/index/api/stopRecord", params)
 	if err != nil {
-		return nil, fmt.Errorf("停止录制失败: %w", err)
+		return resp, fmt.Errorf("停止录制失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // GetMp4RecordFileRequest 获取录制文件夹内的文件列表请求参数
@@ -138,6 +154,12 @@ type GetMp4RecordFileRequest struct {
 	Period string `json:"period"` // 流的录制日期，格式为2020-02-01,如果不是完整的日期，那么是搜索录制文件夹列表，否则搜索对应日期下的mp4文件列表
 }
 
+// Mp4RecordFiles 录制文件夹内的文件列表
+type Mp4RecordFiles struct {
+	Paths    []string `json:"paths"`    // 文件夹列表或mp4文件列表
+	RootPath string   `json:"rootPath"` // 录制文件所在的根目录
+}
+
 // GetMp4RecordFile 获取录制文件夹内的文件列表
 // 获取指定流的录制文件列表
 // 参数:
@@ -147,7 +169,7 @@ type GetMp4RecordFileRequest struct {
 //   - Period: 流的录制日期，格式为2020-02-01,如果不是完整的日期，那么是搜索录制文件夹列表，否则搜索对应日期下的mp4文件列表
 //
 // 返回: 录制文件列表
-func (r *RecordAPI) GetMp4RecordFile(ctx context.Context, req *GetMp4RecordFileRequest) (*BaseResponse, error) {
+func (r *RecordAPI) GetMp4RecordFile(ctx context.Context, req *GetMp4RecordFileRequest) (*Response[Mp4RecordFiles], error) {
 	params := map[string]interface{}{
 		"vhost":  req.VHost,
 		"app":    req.App,
@@ -155,12 +177,12 @@ func (r *RecordAPI) GetMp4RecordFile(ctx context.Context, req *GetMp4RecordFileR
 		"period": req.Period,
 	}
 
-	respBody, err := r.client.SendRequest(ctx, "GET", "/index/api/getMp4RecordFile", params)
+	resp, err := doRequest[Response[Mp4RecordFiles]](ctx, r.client, "GET", "/index/api/getMp4RecordFile", params)
 	if err != nil {
-		return nil, fmt.Errorf("获取录制文件列表失败: %w", err)
+		return resp, fmt.Errorf("获取录制文件列表失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // DeleteRecordDirectoryRequest 删除录制文件夹请求参数
@@ -171,6 +193,12 @@ type DeleteRecordDirectoryRequest struct {
 	Period string `json:"period"` // 流的录制日期，格式为2020-02-01,如果不是完整的日期，那么是删除录制文件夹，否则删除对应日期下的mp4文件
 }
 
+// DeleteRecordDirectoryResponse 删除录制文件夹响应
+type DeleteRecordDirectoryResponse struct {
+	Status
+	Path string `json:"path"` // 被删除的路径
+}
+
 // DeleteRecordDirectory 删除录制文件夹
 // 删除指定流的录制文件或文件夹
 // 参数:
@@ -180,7 +208,7 @@ type DeleteRecordDirectoryRequest struct {
 //   - Period: 流的录制日期，格式为2020-02-01,如果不是完整的日期，那么是删除录制文件夹，否则删除对应日期下的mp4文件
 //
 // 返回: 删除结果
-func (r *RecordAPI) DeleteRecordDirectory(ctx context.Context, req *DeleteRecordDirectoryRequest) (*BaseResponse, error) {
+func (r *RecordAPI) DeleteRecordDirectory(ctx context.Context, req *DeleteRecordDirectoryRequest) (*DeleteRecordDirectoryResponse, error) {
 	params := map[string]interface{}{
 		"vhost":  req.VHost,
 		"app":    req.App,
@@ -188,12 +216,12 @@ func (r *RecordAPI) DeleteRecordDirectory(ctx context.Context, req *DeleteRecord
 		"period": req.Period,
 	}
 
-	respBody, err := r.client.SendRequest(ctx, "GET", "/index/api/deleteRecordDirectory", params)
+	resp, err := doRequest[DeleteRecordDirectoryResponse](ctx, r.client, "GET", "/index/api/deleteRecordDirectory", params)
 	if err != nil {
-		return nil, fmt.Errorf("删除录制文件夹失败: %w", err)
+		return resp, fmt.Errorf("删除录制文件夹失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // GetSnapRequest 获取截图或生成实时截图请求参数
@@ -211,6 +239,8 @@ type GetSnapRequest struct {
 //   - ExpireSec: 截图的过期时间，该时间内产生的截图都会作为缓存返回
 //
 // 返回: 截图结果
+//
+// Deprecated: getSnap返回的是图片而不是JSON，该方法总是返回解析错误，请使用GetSnapImage、WriteSnap或SaveSnap
 func (r *RecordAPI) GetSnap(ctx context.Context, req *GetSnapRequest) (*BaseResponse, error) {
 	params := map[string]interface{}{
 		"url":         req.Url,
@@ -218,10 +248,171 @@ func (r *RecordAPI) GetSnap(ctx context.Context, req *GetSnapRequest) (*BaseResp
 		"expire_sec":  req.ExpireSec,
 	}
 
-	respBody, err := r.client.SendRequest(ctx, "GET", "/index/api/getSnap", params)
+	resp, err := doRequest[BaseResponse](ctx, r.client, "GET", "/index/api/getSnap", params)
+	if err != nil {
+		return resp, fmt.Errorf("获取截图失败: %w", err)
+	}
+
+	return resp, nil
+}
+
+// snapPath 截图接口路径
+const snapPath = "/index/api/getSnap"
+
+// ErrSnapPlaceholder 截图失败，ZLMediaKit返回的是默认的logo图片
+var ErrSnapPlaceholder = errors.New("截图失败，返回的是默认图片")
+
+// SnapImage 截图
+type SnapImage struct {
+	ContentType string // 图片类型，截图为image/jpeg
+	Data        []byte // 图片内容，WriteSnap返回时为nil
+	Size        int64  // 图片大小，单位字节
+	Placeholder bool   // 是否为截图失败时ZLMediaKit返回的默认图片，按图片内容不是jpeg判断
+}
+
+// GetSnapImage 获取截图或生成实时截图，返回图片内容
+// 截图失败时ZLMediaKit返回api.defaultSnap配置的默认图片(默认为www/logo.png)，此时Placeholder为true；
+// 截图总是jpeg格式，因此按图片内容而不是Content-Type判断，api.defaultSnap配置为jpeg图片时无法识别
+// 参数:
+//   - Url: 需要截图的 url，可以是本机的，也可以是远程主机的
+//   - TimeoutSec: 截图失败超时时间，防止 FFmpeg 一直等待截图
+//   - ExpireSec: 截图的过期时间，该时间内产生的截图都会作为缓存返回
+//
+// 返回: 截图
+func (r *RecordAPI) GetSnapImage(ctx context.Context, req *GetSnapRequest) (image *SnapImage, err error) {
+	params := snapParams(req)
+	ctx, span := r.client.startSpan(ctx, "GET", snapPath, params)
+	defer func(start time.Time) {
+		endSpan(span, start, err)
+	}(time.Now())
+
+	_, err = r.client.retry(ctx, snapPath, func() ([]byte, error) {
+		var buf bytes.Buffer
+		snap, err := r.copySnap(ctx, params, &buf)
+		if err != nil {
+			return nil, err
+		}
+		snap.Data = buf.Bytes()
+		image = snap
+		return snap.Data, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取截图失败: %w", err)
+	}
+
+	return image, nil
+}
+
+// WriteSnap 获取截图或生成实时截图，将图片内容写入w
+// 图片直接从响应复制到w，不会重试；截图失败时写入的是默认的logo图片，此时Placeholder为true
+// 参数:
+//   - w: 图片内容的写入目标，例如http.ResponseWriter
+//
+// 返回: 截图信息，Data为nil
+func (r *RecordAPI) WriteSnap(ctx context.Context, req *GetSnapRequest, w io.Writer) (image *SnapImage, err error) {
+	params := snapParams(req)
+	ctx, span := r.client.startSpan(ctx, "GET", snapPath, params)
+	defer func(start time.Time) {
+		endSpan(span, start, err)
+	}(time.Now())
+
+	image, err = r.copySnap(ctx, params, w)
 	if err != nil {
 		return nil, fmt.Errorf("获取截图失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return image, nil
+}
+
+// SaveSnap 获取截图或生成实时截图，保存到文件
+// 先写入同目录下的临时文件再重命名，截图失败返回默认图片时不保存，返回截图和ErrSnapPlaceholder
+// 参数:
+//   - path: 保存的文件路径，已存在时覆盖
+//
+// 返回: 截图
+func (r *RecordAPI) SaveSnap(ctx context.Context, req *GetSnapRequest, path string) (*SnapImage, error) {
+	image, err := r.GetSnapImage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if image.Placeholder {
+		return image, ErrSnapPlaceholder
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return image, fmt.Errorf("保存截图失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(image.Data); err != nil {
+		tmp.Close()
+		return image, fmt.Errorf("保存截图失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return image, fmt.Errorf("保存截图失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return image, fmt.Errorf("保存截图失败: %w", err)
+	}
+
+	return image, nil
+}
+
+// snapParams 截图接口的请求参数
+func snapParams(req *GetSnapRequest) map[string]interface{} {
+	return map[string]interface{}{
+		"url":         req.Url,
+		"timeout_sec": req.TimeoutSec,
+		"expire_sec":  req.ExpireSec,
+	}
+}
+
+// copySnap 请求一次截图接口并将图片复制到w
+// 参数错误或鉴权失败时ZLMediaKit返回JSON，转换为*APIError
+func (r *RecordAPI) copySnap(ctx context.Context, params map[string]interface{}, w io.Writer) (*SnapImage, error) {
+	apiURL, _, _, err := r.client.encodeRequest("GET", snapPath, params)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.do(ctx, "GET", apiURL, snapPath, nil, "", "image/*")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body := bufio.NewReader(resp.Body)
+	head, _ := body.Peek(512)
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType, _, _ = mime.ParseMediaType(http.DetectContentType(head))
+	}
+
+	if contentType == "application/json" || bytes.HasPrefix(bytes.TrimSpace(head), []byte("{")) {
+		respBody, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("读取响应体失败: %w", err)
+		}
+		if _, err := ParseResult[Status](respBody); err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				apiErr.Endpoint = snapPath
+			}
+			return nil, err
+		}
+		return nil, fmt.Errorf("截图接口返回的不是图片: %s", respBody)
+	}
+
+	n, err := io.Copy(w, body)
+	if err != nil {
+		return nil, fmt.Errorf("读取截图失败: %w", err)
+	}
+
+	// Content-Type可能是image/jpg等别名或带有参数，按图片内容判断是否为jpeg截图
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	return &SnapImage{
+		ContentType: contentType,
+		Size:        n,
+		Placeholder: sniffed != "image/jpeg",
+	}, nil
 }
@@ -0,0 +1,168 @@
+package zlmedia_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+var (
+	testJPEG = []byte("\xFF\xD8\xFF\xE0\x00\x10JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00\xFF\xD9")
+	testPNG  = []byte("\x89PNG\r\n\x1A\n\x00\x00\x00\x0DIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+)
+
+func TestGetSnapImage(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	recordAPI := zlmedia.NewRecordAPI(zlm.Client())
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		contentType     string
+		data            []byte
+		url             string
+		wantContentType string
+		wantPlaceholder bool
+		wantCode        int // 期望的APIError代码，0为成功
+	}{
+		{"jpeg截图", "image/jpeg", testJPEG, "rtsp://127.0.0.1/live/test", "image/jpeg", false, 0},
+		{"Content-Type为image/jpg", "image/jpg", testJPEG, "rtsp://127.0.0.1/live/test", "image/jpg", false, 0},
+		{"Content-Type带有参数", "image/jpeg; charset=binary", testJPEG, "rtsp://127.0.0.1/live/test", "image/jpeg", false, 0},
+		{"默认图片", "image/png", testPNG, "rtsp://127.0.0.1/live/test", "image/png", true, 0},
+		{"Content-Type为jpeg的默认图片", "image/jpeg", testPNG, "rtsp://127.0.0.1/live/test", "image/jpeg", true, 0},
+		{"缺少url", "image/jpeg", testJPEG, "", "", false, zlmedia.CodeInvalidArgs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm.SetSnap(tt.contentType, tt.data)
+
+			image, err := recordAPI.GetSnapImage(ctx, &zlmedia.GetSnapRequest{Url: tt.url, TimeoutSec: 10, ExpireSec: 1})
+			if tt.wantCode != 0 {
+				var apiErr *zlmedia.APIError
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.wantCode {
+					t.Fatalf("GetSnapImage() error = %v, want code %d", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if image.ContentType != tt.wantContentType || image.Placeholder != tt.wantPlaceholder {
+				t.Errorf("GetSnapImage() ContentType = %q, Placeholder = %v, want %q, %v", image.ContentType, image.Placeholder, tt.wantContentType, tt.wantPlaceholder)
+			}
+			if !bytes.Equal(image.Data, tt.data) || image.Size != int64(len(tt.data)) {
+				t.Errorf("GetSnapImage() Data = %q, Size = %d, want %q", image.Data, image.Size, tt.data)
+			}
+		})
+	}
+}
+
+func TestWriteSnap(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	recordAPI := zlmedia.NewRecordAPI(zlm.Client())
+	ctx := context.Background()
+
+	tests := []struct {
+		name            string
+		contentType     string
+		data            []byte
+		wantPlaceholder bool
+	}{
+		{"jpeg截图", "image/jpeg", testJPEG, false},
+		{"默认图片", "image/png", testPNG, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm.SetSnap(tt.contentType, tt.data)
+
+			var buf bytes.Buffer
+			image, err := recordAPI.WriteSnap(ctx, &zlmedia.GetSnapRequest{Url: "rtsp://127.0.0.1/live/test"}, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if image.Placeholder != tt.wantPlaceholder || image.Data != nil || image.Size != int64(len(tt.data)) {
+				t.Errorf("WriteSnap() = %+v", image)
+			}
+			if !bytes.Equal(buf.Bytes(), tt.data) {
+				t.Errorf("写入的内容 = %q, want %q", buf.Bytes(), tt.data)
+			}
+		})
+	}
+}
+
+func TestSaveSnap(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	recordAPI := zlmedia.NewRecordAPI(zlm.Client())
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		data     []byte
+		setup    func(dir string) string // 准备目录并返回保存路径
+		wantErr  error                   // 期望的错误，为nil时只检查wantFail
+		wantFail bool                    // 是否返回错误
+		wantSave bool                    // 是否保存了截图
+		wantDir  []string                // 保存后目录中的文件，用于检查临时文件已删除
+	}{
+		{"保存截图", testJPEG, func(dir string) string {
+			return filepath.Join(dir, "test.jpg")
+		}, nil, false, true, []string{"test.jpg"}},
+		{"覆盖已存在的文件", testJPEG, func(dir string) string {
+			path := filepath.Join(dir, "test.jpg")
+			os.WriteFile(path, []byte("old"), 0o644)
+			return path
+		}, nil, false, true, []string{"test.jpg"}},
+		{"默认图片不保存", testPNG, func(dir string) string {
+			return filepath.Join(dir, "test.jpg")
+		}, zlmedia.ErrSnapPlaceholder, true, false, []string{}},
+		{"目录不存在", testJPEG, func(dir string) string {
+			return filepath.Join(dir, "missing", "test.jpg")
+		}, nil, true, false, []string{}},
+		{"重命名失败", testJPEG, func(dir string) string {
+			path := filepath.Join(dir, "test.jpg")
+			os.Mkdir(path, 0o755)
+			return path
+		}, nil, true, false, []string{"test.jpg"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm.SetSnap("image/jpeg", tt.data)
+			dir := t.TempDir()
+			path := tt.setup(dir)
+
+			image, err := recordAPI.SaveSnap(ctx, &zlmedia.GetSnapRequest{Url: "rtsp://127.0.0.1/live/test"}, path)
+			if (err != nil) != tt.wantFail || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Fatalf("SaveSnap() error = %v, want %v", err, tt.wantErr)
+			}
+			if image != nil && image.Size != int64(len(tt.data)) {
+				t.Errorf("SaveSnap() Size = %d, want %d", image.Size, len(tt.data))
+			}
+
+			data, readErr := os.ReadFile(path)
+			if saved := readErr == nil && bytes.Equal(data, tt.data); saved != tt.wantSave {
+				t.Errorf("文件内容 = %q, want saved %v", data, tt.wantSave)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := []string{}
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			if !reflect.DeepEqual(names, tt.wantDir) {
+				t.Errorf("目录中的文件 = %v, want %v", names, tt.wantDir)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package zlmedia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// recordDayLayout 录制文件夹的日期格式
+const recordDayLayout = "2006-01-02"
+
+// RecordCatalogConfig 录制文件目录配置
+type RecordCatalogConfig struct {
+	MediaServerID string         // 节点id，只处理该节点的hook事件并记录到片段中，为空时处理所有事件
+	Location      *time.Location // ZLMediaKit录制文件夹日期和文件名使用的时区，默认为time.Local
+}
+
+// RecordCatalog 录制文件目录
+// 根据on_record_mp4事件记录每个录制完成的mp4文件，并可以通过GetMp4RecordFile补齐错过的文件，
+// 片段保存在RecordStore中，可以按流和时间范围查询
+type RecordCatalog struct {
+	record *RecordAPI
+	store  RecordStore
+	config RecordCatalogConfig
+}
+
+// NewRecordCatalog 创建录制文件目录
+// 参数:
+//   - store: 片段存储，为nil时使用MemoryRecordStore
+func NewRecordCatalog(client *Client, store RecordStore, config RecordCatalogConfig) *RecordCatalog {
+	if store == nil {
+		store = NewMemoryRecordStore()
+	}
+	if config.Location == nil {
+		config.Location = time.Local
+	}
+
+	return &RecordCatalog{
+		record: NewRecordAPI(client),
+		store:  store,
+		config: config,
+	}
+}
+
+// Store 获取片段存储
+func (c *RecordCatalog) Store() RecordStore {
+	return c.store
+}
+
+// Add 记录on_record_mp4事件中的录制文件
+func (c *RecordCatalog) Add(ctx context.Context, hook *OnRecordMp4Hook) error {
+	segment := RecordSegment{
+		MediaServerID: hook.MediaServerID,
+		Key:           hook.Key(),
+		FilePath:      hook.FilePath,
+		URL:           hook.URL,
+		StartTime:     time.Unix(hook.StartTime, 0),
+		Duration:      time.Duration(hook.TimeLen * float64(time.Second)),
+		Size:          hook.FileSize,
+	}
+	if segment.MediaServerID == "" {
+		segment.MediaServerID = c.config.MediaServerID
+	}
+	if err := c.store.Put(ctx, segment); err != nil {
+		return fmt.Errorf("记录录制文件%s失败: %w", hook.FilePath, err)
+	}
+	return nil
+}
+
+// Query 查询满足条件的录制文件片段，按开始时间排序
+// 参数:
+//   - query: 查询条件，与时间范围有重叠的片段都会返回
+//
+// 返回: 片段列表
+func (c *RecordCatalog) Query(ctx context.Context, query RecordQuery) ([]RecordSegment, error) {
+	segments, err := c.store.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("查询录制文件失败: %w", err)
+	}
+	return segments, nil
+}
+
+// Backfill 通过GetMp4RecordFile补齐流在时间范围内每一天的录制文件
+// 已记录的文件不会被覆盖；文件列表中没有时长和大小，时长按同一天下一个文件的开始时间估算，
+// 当天最后一个文件的时长为0，正在录制的文件会被跳过
+// 参数:
+//   - key: 流的唯一标识
+//   - start: 开始日期
+//   - end: 结束日期
+//
+// 返回: 新记录的片段数量
+func (c *RecordCatalog) Backfill(ctx context.Context, key StreamKey, start, end time.Time) (int, error) {
+	key.VHost = key.NormalizedVHost()
+	existing, err := c.store.Query(ctx, RecordQuery{MediaServerID: c.config.MediaServerID, Key: key})
+	if err != nil {
+		return 0, fmt.Errorf("查询录制文件失败: %w", err)
+	}
+	known := make(map[string]bool, len(existing))
+	for _, segment := range existing {
+		known[segment.FilePath] = true
+	}
+
+	added := 0
+	start = start.In(c.config.Location)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, c.config.Location)
+	for ; !day.After(end); day = day.AddDate(0, 0, 1) {
+		segments, err := c.listDay(ctx, key, day)
+		if err != nil {
+			return added, err
+		}
+
+		var missing []RecordSegment
+		for _, segment := range segments {
+			if !known[segment.FilePath] {
+				missing = append(missing, segment)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		if err := c.store.Put(ctx, missing...); err != nil {
+			return added, fmt.Errorf("记录录制文件失败: %w", err)
+		}
+		added += len(missing)
+	}
+	return added, nil
+}
+
+// listDay 获取流一天内的录制文件
+func (c *RecordCatalog) listDay(ctx context.Context, key StreamKey, day time.Time) ([]RecordSegment, error) {
+	period := day.Format(recordDayLayout)
+	resp, err := c.record.GetMp4RecordFile(ctx, &GetMp4RecordFileRequest{VHost: key.VHost, App: key.App, Stream: key.Stream, Period: period})
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []RecordSegment
+	for _, name := range resp.Data.Paths {
+		startTime, ok := c.parseFileTime(period, name)
+		if !ok {
+			continue
+		}
+		filePath := strings.TrimSuffix(resp.Data.RootPath, "/") + "/" + name
+		segments = append(segments, RecordSegment{
+			MediaServerID: c.config.MediaServerID,
+			Key:           key,
+			FilePath:      filePath,
+			URL:           recordURL(filePath),
+			StartTime:     startTime,
+		})
+	}
+	for i := 0; i+1 < len(segments); i++ {
+		if next := segments[i+1].StartTime; next.After(segments[i].StartTime) {
+			segments[i].Duration = next.Sub(segments[i].StartTime)
+		}
+	}
+	return segments, nil
+}
+
+// parseFileTime 解析录制文件名中的开始时间，文件名格式为15-04-05.mp4或15-04-05-序号.mp4
+// 以.开头的文件正在录制，不解析
+func (c *RecordCatalog) parseFileTime(period, name string) (time.Time, bool) {
+	if !strings.HasSuffix(name, ".mp4") || strings.HasPrefix(name, ".") || len(name) < len("15-04-05") {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(recordDayLayout+" 15-04-05", period+" "+name[:len("15-04-05")], c.config.Location)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// recordURL 根据文件路径推算点播相对url路径，ZLMediaKit默认以www为http根目录
+func recordURL(filePath string) string {
+	filePath = path.Clean(filePath)
+	if rel, ok := strings.CutPrefix(filePath, "www/"); ok {
+		return rel
+	}
+	if _, rel, ok := strings.Cut(filePath, "/www/"); ok {
+		return rel
+	}
+	return ""
+}
+
+// Remove 删除满足条件的片段记录，不删除录制文件
+// 返回: 删除的数量
+func (c *RecordCatalog) Remove(ctx context.Context, query RecordQuery) (int, error) {
+	deleted, err := c.store.Delete(ctx, query)
+	if err != nil {
+		return deleted, fmt.Errorf("删除录制文件记录失败: %w", err)
+	}
+	return deleted, nil
+}
+
+// HookHandler 创建记录on_record_mp4事件的HookHandler
+// 记录录制文件后再调用next，next为nil时使用NopHookHandler
+func (c *RecordCatalog) HookHandler(next HookHandler) HookHandler {
+	if next == nil {
+		next = NopHookHandler{}
+	}
+	return &recordCatalogHookHandler{HookHandler: next, catalog: c}
+}
+
+// recordCatalogHookHandler 记录录制文件的HookHandler
+type recordCatalogHookHandler struct {
+	HookHandler
+	catalog *RecordCatalog
+}
+
+// OnRecordMp4 记录录制完成的mp4文件
+func (h *recordCatalogHookHandler) OnRecordMp4(ctx context.Context, hook *OnRecordMp4Hook) error {
+	var err error
+	if serverID := h.catalog.config.MediaServerID; serverID == "" || serverID == hook.MediaServerID {
+		err = h.catalog.Add(ctx, hook)
+	}
+	return errors.Join(err, h.HookHandler.OnRecordMp4(ctx, hook))
+}
@@ -0,0 +1,354 @@
+package zlmedia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrInvalidRecordWindow 录制时间窗格式错误
+var ErrInvalidRecordWindow = errors.New("录制时间窗格式错误")
+
+// RecordWindow 每周重复的录制时间窗
+type RecordWindow struct {
+	Weekdays []time.Weekday // 生效的星期，为空时每天生效
+	Start    string         // 开始时间，格式为15:04
+	End      string         // 结束时间，格式为15:04，可以为24:00；不晚于Start时表示跨越午夜到第二天结束
+}
+
+// RecordCron 由cron表达式触发的录制
+type RecordCron struct {
+	Expr     string        // 开始录制的cron表达式，例如"0 9 * * MON-FRI"
+	Duration time.Duration // 每次触发后的录制时长
+}
+
+// RecordSchedule 流的定时录制计划
+// 当前时间落在任意一个时间窗内，或在任意一个cron表达式触发后的Duration内时录制，否则停止录制
+type RecordSchedule struct {
+	Key            StreamKey      // 流的唯一标识
+	Type           int            // 0为hls，1为mp4
+	CustomizedPath string         // 录制文件保存根目录，置空使用默认目录
+	MaxSecond      *int           // mp4录制切片大小，单位秒，置空时采用配置文件默认值
+	Location       *time.Location // 时间窗和cron表达式使用的时区，默认为time.Local
+	Windows        []RecordWindow // 每周重复的时间窗
+	Crons          []RecordCron   // cron表达式
+}
+
+// recordScheduleKey 定时录制计划的唯一标识，同一个流的hls和mp4录制可以分别设置计划
+type recordScheduleKey struct {
+	key        StreamKey
+	recordType int
+}
+
+// compiledWindow 解析后的时间窗，时间为当天的分钟数
+type compiledWindow struct {
+	weekdays   [7]bool
+	start, end int
+}
+
+// compiledCron 解析后的cron录制
+type compiledCron struct {
+	cron     *CronSchedule
+	duration time.Duration
+}
+
+// compiledSchedule 解析后的定时录制计划
+type compiledSchedule struct {
+	RecordSchedule
+	windows []compiledWindow
+	crons   []compiledCron
+}
+
+// compile 解析时间窗和cron表达式
+func (s RecordSchedule) compile() (*compiledSchedule, error) {
+	s.Key = s.Key.Normalize()
+	if s.Location == nil {
+		s.Location = time.Local
+	}
+	compiled := &compiledSchedule{RecordSchedule: s}
+
+	for _, window := range s.Windows {
+		w := compiledWindow{}
+		var err error
+		if w.start, err = parseClock(window.Start); err != nil {
+			return nil, err
+		}
+		if w.end, err = parseClock(window.End); err != nil {
+			return nil, err
+		}
+		if w.start == 24*60 {
+			return nil, fmt.Errorf("%w: 开始时间不能为24:00", ErrInvalidRecordWindow)
+		}
+		for _, day := range window.Weekdays {
+			w.weekdays[day%7] = true
+		}
+		if len(window.Weekdays) == 0 {
+			w.weekdays = [7]bool{true, true, true, true, true, true, true}
+		}
+		compiled.windows = append(compiled.windows, w)
+	}
+
+	for _, c := range s.Crons {
+		cron, err := ParseCron(c.Expr)
+		if err != nil {
+			return nil, err
+		}
+		if c.Duration <= 0 {
+			return nil, fmt.Errorf("%w: %q的录制时长必须大于0", ErrInvalidRecordWindow, c.Expr)
+		}
+		compiled.crons = append(compiled.crons, compiledCron{cron: cron, duration: c.Duration})
+	}
+	return compiled, nil
+}
+
+// parseClock 解析15:04格式的时间，返回当天的分钟数
+func parseClock(clock string) (int, error) {
+	var hour, minute int
+	if n, err := fmt.Sscanf(clock, "%d:%d", &hour, &minute); err != nil || n != 2 || hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidRecordWindow, clock)
+	}
+	return hour*60 + minute, nil
+}
+
+// active 时间t是否需要录制
+func (s *compiledSchedule) active(t time.Time) bool {
+	t = t.In(s.Location)
+	minute := t.Hour()*60 + t.Minute()
+	today, yesterday := t.Weekday(), (t.Weekday()+6)%7
+	for _, w := range s.windows {
+		if w.start < w.end {
+			if w.weekdays[today] && minute >= w.start && minute < w.end {
+				return true
+			}
+			continue
+		}
+		// 跨越午夜的时间窗，前一天开始的部分在今天结束
+		if (w.weekdays[today] && minute >= w.start) || (w.weekdays[yesterday] && minute < w.end) {
+			return true
+		}
+	}
+
+	for _, c := range s.crons {
+		// 从当前分钟向前查找Duration内是否有触发时间
+		start := t.Truncate(time.Minute)
+		for at := start; t.Sub(at) < c.duration; at = at.Add(-time.Minute) {
+			if c.cron.Matches(at) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RecordSchedulerConfig 定时录制调度器配置
+type RecordSchedulerConfig struct {
+	Interval      time.Duration                                 // 检查间隔，默认为30秒，应小于1分钟以便及时在时间窗边界开始和停止录制
+	MediaServerID string                                        // 只响应该服务器的on_stream_changed事件，为空时响应所有服务器
+	OnReconcile   func(result *RecordScheduleResult, err error) // 每次检查完成后的回调，可用于记录日志
+}
+
+// RecordScheduleResult 一次检查的结果
+type RecordScheduleResult struct {
+	Started []StreamKey // 开始录制的流
+	Stopped []StreamKey // 停止录制的流
+	Offline []StreamKey // 不在线的流，上线后会重新检查
+	Failed  []StreamKey // 开始或停止录制失败的流
+}
+
+// RecordScheduler 定时录制调度器
+// 按计划调用StartRecord/StopRecord，每次检查先通过IsRecording获取实际录制状态，只在与计划不一致时操作，
+// 开始录制后再次通过IsRecording确认；流重新上线(on_stream_changed)后立即检查，恢复断线前的录制
+// 调度器接管计划中的流的录制状态，计划外手动开始的录制也会被停止
+type RecordScheduler struct {
+	record  *RecordAPI
+	config  RecordSchedulerConfig
+	trigger chan struct{}
+
+	// reconcileMu 保证同一时间只有一次检查
+	reconcileMu sync.Mutex
+
+	mu        sync.Mutex
+	schedules map[recordScheduleKey]*compiledSchedule
+}
+
+// NewRecordScheduler 创建定时录制调度器
+func NewRecordScheduler(client *Client, config RecordSchedulerConfig) *RecordScheduler {
+	if config.Interval <= 0 {
+		config.Interval = 30 * time.Second
+	}
+
+	return &RecordScheduler{
+		record:    NewRecordAPI(client),
+		config:    config,
+		trigger:   make(chan struct{}, 1),
+		schedules: make(map[recordScheduleKey]*compiledSchedule),
+	}
+}
+
+// SetSchedules 设置定时录制计划，替换之前的计划
+// 以流和录制类型区分计划，重复的计划以最后一个为准；不再有计划的流保持当前录制状态；设置后会触发一次检查
+// 返回: 时间窗或cron表达式格式错误时返回错误，之前的计划保持不变
+func (r *RecordScheduler) SetSchedules(schedules []RecordSchedule) error {
+	compiled := make(map[recordScheduleKey]*compiledSchedule, len(schedules))
+	for _, schedule := range schedules {
+		c, err := schedule.compile()
+		if err != nil {
+			return fmt.Errorf("定时录制计划%s: %w", schedule.Key, err)
+		}
+		compiled[recordScheduleKey{key: c.Key, recordType: c.Type}] = c
+	}
+
+	r.mu.Lock()
+	r.schedules = compiled
+	r.mu.Unlock()
+
+	r.Trigger()
+	return nil
+}
+
+// Trigger 请求Run尽快执行一次检查，不会阻塞
+func (r *RecordScheduler) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run 立即检查一次，之后按Interval定期检查或在Trigger后检查，直到ctx结束
+// 检查失败不会中断运行，结果通过OnReconcile回调报告
+func (r *RecordScheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := r.Reconcile(ctx)
+		if r.config.OnReconcile != nil {
+			r.config.OnReconcile(result, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-r.trigger:
+		}
+	}
+}
+
+// Reconcile 按当前时间执行一次检查
+// 单个流的失败不会中断检查，所有失败会合并为一个错误返回
+func (r *RecordScheduler) Reconcile(ctx context.Context) (*RecordScheduleResult, error) {
+	r.reconcileMu.Lock()
+	defer r.reconcileMu.Unlock()
+
+	r.mu.Lock()
+	schedules := make([]*compiledSchedule, 0, len(r.schedules))
+	for _, schedule := range r.schedules {
+		schedules = append(schedules, schedule)
+	}
+	r.mu.Unlock()
+	sort.Slice(schedules, func(i, j int) bool {
+		if schedules[i].Key != schedules[j].Key {
+			return schedules[i].Key.String() < schedules[j].Key.String()
+		}
+		return schedules[i].Type < schedules[j].Type
+	})
+
+	now := time.Now()
+	result := &RecordScheduleResult{}
+	var errs []error
+	for _, schedule := range schedules {
+		key, want := schedule.Key, schedule.active(now)
+		changed, err := r.apply(ctx, schedule, want)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			result.Offline = append(result.Offline, key)
+		case err != nil:
+			result.Failed = append(result.Failed, key)
+			errs = append(errs, fmt.Errorf("定时录制%s: %w", key, err))
+		case changed && want:
+			result.Started = append(result.Started, key)
+		case changed:
+			result.Stopped = append(result.Stopped, key)
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// apply 使流的录制状态与计划一致
+// 返回: 是否开始或停止了录制，流不在线时返回ErrNotFound
+func (r *RecordScheduler) apply(ctx context.Context, schedule *compiledSchedule, want bool) (bool, error) {
+	key := schedule.Key
+	status := &IsRecordingRequest{Type: schedule.Type, VHost: key.VHost, App: key.App, Stream: key.Stream}
+	resp, err := r.record.IsRecording(ctx, status)
+	if err != nil {
+		return false, err
+	}
+	if resp.Recording == want {
+		return false, nil
+	}
+
+	if !want {
+		_, err := r.record.StopRecord(ctx, &StopRecordRequest{Type: schedule.Type, VHost: key.VHost, App: key.App, Stream: key.Stream})
+		return err == nil, err
+	}
+
+	if _, err := r.record.StartRecord(ctx, &StartRecordRequest{
+		Type:           schedule.Type,
+		VHost:          key.VHost,
+		App:            key.App,
+		Stream:         key.Stream,
+		CustomizedPath: schedule.CustomizedPath,
+		MaxSecond:      schedule.MaxSecond,
+	}); err != nil {
+		return false, err
+	}
+	if resp, err = r.record.IsRecording(ctx, status); err != nil {
+		return true, err
+	}
+	if !resp.Recording {
+		return true, errors.New("开始录制后录制状态仍为未录制")
+	}
+	return true, nil
+}
+
+// HookHandler 创建在计划中的流上线后触发检查的HookHandler
+// 事件先触发检查，再调用next，next为nil时使用NopHookHandler
+func (r *RecordScheduler) HookHandler(next HookHandler) HookHandler {
+	if next == nil {
+		next = NopHookHandler{}
+	}
+	return &recordSchedulerHookHandler{HookHandler: next, scheduler: r}
+}
+
+// recordSchedulerHookHandler 在流上线后触发检查的HookHandler
+type recordSchedulerHookHandler struct {
+	HookHandler
+	scheduler *RecordScheduler
+}
+
+// OnStreamChanged 流重新上线后ZLMediaKit不会恢复之前的录制，立即触发检查
+func (h *recordSchedulerHookHandler) OnStreamChanged(ctx context.Context, hook *OnStreamChangedHook) error {
+	serverID := h.scheduler.config.MediaServerID
+	if hook.Regist && (serverID == "" || serverID == hook.MediaServerID) && h.scheduler.scheduled(hook.Key()) {
+		h.scheduler.Trigger()
+	}
+	return h.HookHandler.OnStreamChanged(ctx, hook)
+}
+
+// scheduled 流是否有定时录制计划
+func (r *RecordScheduler) scheduled(key StreamKey) bool {
+	key = key.Normalize()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k := range r.schedules {
+		if k.key == key {
+			return true
+		}
+	}
+	return false
+}
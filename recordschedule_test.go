@@ -0,0 +1,111 @@
+package zlmedia_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+func TestRecordSchedulerReconcile(t *testing.T) {
+	key := zlmedia.StreamKey{VHost: zlmedia.DefaultVHost, App: "live", Stream: "test"}
+	today := time.Now().UTC().Weekday()
+	allToday := []zlmedia.RecordWindow{{Weekdays: []time.Weekday{today}, Start: "00:00", End: "24:00"}}
+	otherDays := []zlmedia.RecordWindow{{Weekdays: []time.Weekday{(today + 1) % 7, (today + 3) % 7}, Start: "08:00", End: "09:00"}}
+
+	tests := []struct {
+		name      string
+		windows   []zlmedia.RecordWindow
+		crons     []zlmedia.RecordCron
+		online    bool
+		recording bool // 检查前是否正在录制
+		fault     string
+		want      string // started/stopped/offline/failed
+		wantRec   bool   // 检查后是否正在录制
+	}{
+		{"时间窗内开始录制", allToday, nil, true, false, "", "[live/test]/[]/[]/[]", true},
+		{"时间窗内已在录制", allToday, nil, true, true, "", "[]/[]/[]/[]", true},
+		{"时间窗外停止录制", otherDays, nil, true, true, "", "[]/[live/test]/[]/[]", false},
+		{"时间窗外未录制", otherDays, nil, true, false, "", "[]/[]/[]/[]", false},
+		{"cron触发后的时长内", nil, []zlmedia.RecordCron{{Expr: "* * * * *", Duration: time.Minute}}, true, false, "", "[live/test]/[]/[]/[]", true},
+		{"流不在线", allToday, nil, false, false, "", "[]/[]/[live/test]/[]", false},
+		{"开始录制失败", allToday, nil, true, false, "/index/api/startRecord", "[]/[]/[]/[live/test]", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm := zlmtest.NewServer()
+			defer zlm.Close()
+			client := zlm.Client()
+			ctx := context.Background()
+			if tt.online {
+				zlm.PublishStream(key, zlmtest.OriginTypeRtmpPush, "rtmp")
+			}
+			if tt.recording {
+				if _, err := zlmedia.NewRecordAPI(client).StartRecord(ctx, &zlmedia.StartRecordRequest{Type: 1, VHost: key.VHost, App: key.App, Stream: key.Stream}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.fault != "" {
+				zlm.InjectFault(tt.fault, zlmtest.Fault{Code: zlmedia.CodeException, Msg: "boom"})
+			}
+
+			scheduler := zlmedia.NewRecordScheduler(client, zlmedia.RecordSchedulerConfig{})
+			if err := scheduler.SetSchedules([]zlmedia.RecordSchedule{{Key: key, Type: 1, Location: time.UTC, Windows: tt.windows, Crons: tt.crons}}); err != nil {
+				t.Fatal(err)
+			}
+			result, err := scheduler.Reconcile(ctx)
+			if (err != nil) != (tt.fault != "") {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			short := func(keys []zlmedia.StreamKey) []string {
+				s := make([]string, len(keys))
+				for i, k := range keys {
+					s[i] = k.App + "/" + k.Stream
+				}
+				return s
+			}
+			if got := fmt.Sprintf("%v/%v/%v/%v", short(result.Started), short(result.Stopped), short(result.Offline), short(result.Failed)); got != tt.want {
+				t.Errorf("Reconcile() = %s, want %s", got, tt.want)
+			}
+
+			if !tt.online {
+				return
+			}
+			zlm.ClearFaults()
+			resp, err := zlmedia.NewRecordAPI(client).IsRecording(ctx, &zlmedia.IsRecordingRequest{Type: 1, VHost: key.VHost, App: key.App, Stream: key.Stream})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Recording != tt.wantRec {
+				t.Errorf("Recording = %v, want %v", resp.Recording, tt.wantRec)
+			}
+		})
+	}
+}
+
+func TestRecordScheduleInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule zlmedia.RecordSchedule
+		wantErr  error
+	}{
+		{"时间格式错误", zlmedia.RecordSchedule{Windows: []zlmedia.RecordWindow{{Start: "8点", End: "09:00"}}}, zlmedia.ErrInvalidRecordWindow},
+		{"分钟超出范围", zlmedia.RecordSchedule{Windows: []zlmedia.RecordWindow{{Start: "08:60", End: "09:00"}}}, zlmedia.ErrInvalidRecordWindow},
+		{"结束时间超过24:00", zlmedia.RecordSchedule{Windows: []zlmedia.RecordWindow{{Start: "08:00", End: "24:01"}}}, zlmedia.ErrInvalidRecordWindow},
+		{"开始时间为24:00", zlmedia.RecordSchedule{Windows: []zlmedia.RecordWindow{{Start: "24:00", End: "08:00"}}}, zlmedia.ErrInvalidRecordWindow},
+		{"cron表达式错误", zlmedia.RecordSchedule{Crons: []zlmedia.RecordCron{{Expr: "* * *", Duration: time.Minute}}}, zlmedia.ErrInvalidCron},
+		{"cron录制时长为0", zlmedia.RecordSchedule{Crons: []zlmedia.RecordCron{{Expr: "* * * * *"}}}, zlmedia.ErrInvalidRecordWindow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := zlmedia.NewRecordScheduler(nil, zlmedia.RecordSchedulerConfig{})
+			if err := scheduler.SetSchedules([]zlmedia.RecordSchedule{tt.schedule}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetSchedules() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
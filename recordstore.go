@@ -0,0 +1,327 @@
+package zlmedia
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordSegment 录制文件片段
+type RecordSegment struct {
+	MediaServerID string        `json:"media_server_id,omitempty"` // 录制文件所在节点的id
+	Key           StreamKey     `json:"key"`                       // 流的唯一标识，虚拟主机已规范化
+	FilePath      string        `json:"file_path"`                 // 文件绝对路径
+	URL           string        `json:"url,omitempty"`             // http/rtsp/rtmp点播相对url路径
+	StartTime     time.Time     `json:"start_time"`                // 开始录制时间
+	Duration      time.Duration `json:"duration"`                  // 录制时长，未知时为0
+	Size          int64         `json:"size,omitempty"`            // 文件大小，单位字节，未知时为0
+}
+
+// EndTime 获取结束录制时间
+func (s RecordSegment) EndTime() time.Time {
+	return s.StartTime.Add(s.Duration)
+}
+
+// sameFile 是否为同一个录制文件，同一节点上的文件路径唯一
+// 节点id为空表示节点未知（例如未配置节点id时补齐的片段），与同一流中文件路径相同的片段视为同一个文件
+func (s RecordSegment) sameFile(other RecordSegment) bool {
+	if s.FilePath != other.FilePath {
+		return false
+	}
+	return s.MediaServerID == other.MediaServerID || s.MediaServerID == "" || other.MediaServerID == ""
+}
+
+// RecordQuery 录制文件片段查询条件
+type RecordQuery struct {
+	MediaServerID string    // 节点id，为空时匹配所有节点
+	Key           StreamKey // 流的唯一标识，为空的字段匹配任意值
+	Period        string    // 录制日期文件夹，格式为2006-01-02，为空时不限制
+	Start         time.Time // 时间范围的开始，零值表示不限制
+	End           time.Time // 时间范围的结束，零值表示不限制
+}
+
+// matches 片段是否满足查询条件，时间范围与片段有重叠即满足
+// 时长未知的片段只要开始时间在范围内即满足
+func (q RecordQuery) matches(segment RecordSegment) bool {
+	if !q.matchesKey(segment.Key) || (q.MediaServerID != "" && q.MediaServerID != segment.MediaServerID) {
+		return false
+	}
+	if q.Period != "" && !strings.Contains(segment.FilePath, "/"+q.Period+"/") {
+		return false
+	}
+	if !q.End.IsZero() && !segment.StartTime.Before(q.End) {
+		return false
+	}
+	if q.Start.IsZero() {
+		return true
+	}
+	if segment.Duration == 0 {
+		return !segment.StartTime.Before(q.Start)
+	}
+	return segment.EndTime().After(q.Start)
+}
+
+// matchesKey 流是否满足查询条件
+func (q RecordQuery) matchesKey(key StreamKey) bool {
+	return (q.Key.VHost == "" || q.Key.NormalizedVHost() == key.VHost) &&
+		(q.Key.App == "" || q.Key.App == key.App) &&
+		(q.Key.Stream == "" || q.Key.Stream == key.Stream)
+}
+
+// RecordStore 录制文件片段存储
+// 片段以流、节点id和文件路径为唯一标识，节点id为空的片段与同一流中文件路径相同的片段视为同一个，
+// 实现必须是并发安全的
+type RecordStore interface {
+	// Put 保存片段，已存在的片段会被替换，新片段的节点id为空时保留原有的节点id
+	Put(ctx context.Context, segments ...RecordSegment) error
+	// Query 查询满足条件的片段，按开始时间排序
+	Query(ctx context.Context, query RecordQuery) ([]RecordSegment, error)
+	// Delete 删除满足条件的片段，返回删除的数量
+	Delete(ctx context.Context, query RecordQuery) (int, error)
+}
+
+// MemoryRecordStore 内存中的录制文件片段存储，进程重启后丢失
+type MemoryRecordStore struct {
+	mu       sync.RWMutex
+	segments map[StreamKey][]RecordSegment // 每个流的片段按开始时间排序
+}
+
+// NewMemoryRecordStore 创建内存中的录制文件片段存储
+func NewMemoryRecordStore() *MemoryRecordStore {
+	return &MemoryRecordStore{segments: make(map[StreamKey][]RecordSegment)}
+}
+
+// Put 保存片段，已存在的片段会被替换，新片段的节点id为空时保留原有的节点id
+func (m *MemoryRecordStore) Put(_ context.Context, segments ...RecordSegment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, segment := range segments {
+		segment.Key.VHost = segment.Key.NormalizedVHost()
+		list := m.segments[segment.Key]
+		for i := range list {
+			if list[i].sameFile(segment) {
+				if segment.MediaServerID == "" {
+					segment.MediaServerID = list[i].MediaServerID
+				}
+				list = append(list[:i], list[i+1:]...)
+				break
+			}
+		}
+		i := sort.Search(len(list), func(i int) bool { return list[i].StartTime.After(segment.StartTime) })
+		list = append(list, RecordSegment{})
+		copy(list[i+1:], list[i:])
+		list[i] = segment
+		m.segments[segment.Key] = list
+	}
+	return nil
+}
+
+// Query 查询满足条件的片段，按开始时间排序
+func (m *MemoryRecordStore) Query(_ context.Context, query RecordQuery) ([]RecordSegment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []RecordSegment
+	for key, list := range m.segments {
+		if !query.matchesKey(key) {
+			continue
+		}
+		for _, segment := range list {
+			if query.matches(segment) {
+				result = append(result, segment)
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].StartTime.Before(result[j].StartTime) })
+	return result, nil
+}
+
+// Delete 删除满足条件的片段，返回删除的数量
+func (m *MemoryRecordStore) Delete(_ context.Context, query RecordQuery) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := 0
+	for key, list := range m.segments {
+		kept := list[:0]
+		for _, segment := range list {
+			if query.matches(segment) {
+				deleted++
+			} else {
+				kept = append(kept, segment)
+			}
+		}
+		if len(kept) == 0 {
+			delete(m.segments, key)
+		} else {
+			m.segments[key] = kept
+		}
+	}
+	return deleted, nil
+}
+
+// FileRecordStore 保存在本地文件中的录制文件片段存储
+// 片段以JSON Lines格式追加写入文件，查询使用内存中的索引，删除时重写整个文件
+type FileRecordStore struct {
+	memory *MemoryRecordStore
+
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// OpenFileRecordStore 打开或创建文件中的录制文件片段存储
+// 参数:
+//   - path: 文件路径，不存在时创建
+//
+// 返回: 已加载文件中所有片段的存储，不再使用时需要调用Close
+func OpenFileRecordStore(path string) (*FileRecordStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("打开录制索引文件失败: %w", err)
+	}
+
+	s := &FileRecordStore{memory: NewMemoryRecordStore(), path: path, file: file}
+	if err := s.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load 加载文件中的片段
+// 进程崩溃导致的最后一行不完整会被截断，避免之后追加的内容与其拼接成错误的行
+func (s *FileRecordStore) load() error {
+	reader := bufio.NewReader(s.file)
+	var segments []RecordSegment
+	var offset int64 // 最后一个完整行的结束位置
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(data) > 0 {
+				if err := s.file.Truncate(offset); err != nil {
+					return fmt.Errorf("截断录制索引文件失败: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取录制索引文件失败: %w", err)
+		}
+
+		var segment RecordSegment
+		if err := json.Unmarshal(data, &segment); err != nil {
+			return fmt.Errorf("解析录制索引文件第%d行失败: %w", line, err)
+		}
+		segments = append(segments, segment)
+		offset += int64(len(data))
+	}
+	return s.memory.Put(context.Background(), segments...)
+}
+
+// Put 保存片段并追加写入文件，已存在的片段会被替换，新片段的节点id为空时保留原有的节点id
+func (s *FileRecordStore) Put(ctx context.Context, segments ...RecordSegment) error {
+	var buf []byte
+	for _, segment := range segments {
+		segment.Key.VHost = segment.Key.NormalizedVHost()
+		data, err := json.Marshal(segment)
+		if err != nil {
+			return fmt.Errorf("序列化录制文件片段失败: %w", err)
+		}
+		buf = append(append(buf, data...), '\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return os.ErrClosed
+	}
+	if _, err := s.file.Write(buf); err != nil {
+		return fmt.Errorf("写入录制索引文件失败: %w", err)
+	}
+	return s.memory.Put(ctx, segments...)
+}
+
+// Query 查询满足条件的片段，按开始时间排序
+func (s *FileRecordStore) Query(ctx context.Context, query RecordQuery) ([]RecordSegment, error) {
+	return s.memory.Query(ctx, query)
+}
+
+// Delete 删除满足条件的片段并重写文件，返回删除的数量
+func (s *FileRecordStore) Delete(ctx context.Context, query RecordQuery) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	deleted, err := s.memory.Delete(ctx, query)
+	if err != nil || deleted == 0 {
+		return deleted, err
+	}
+	return deleted, s.rewriteLocked(ctx)
+}
+
+// rewriteLocked 将内存中的片段写入临时文件，再替换原文件
+func (s *FileRecordStore) rewriteLocked(ctx context.Context) error {
+	segments, err := s.memory.Query(ctx, RecordQuery{})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("重写录制索引文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, segment := range segments {
+		if err := encoder.Encode(segment); err != nil {
+			tmp.Close()
+			return fmt.Errorf("重写录制索引文件失败: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("重写录制索引文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("重写录制索引文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("重写录制索引文件失败: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("打开录制索引文件失败: %w", err)
+	}
+	s.file.Close()
+	s.file = file
+	return nil
+}
+
+// Close 关闭文件
+func (s *FileRecordStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,187 @@
+package zlmedia_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+// testSegment 生成录制文件片段
+func testSegment(serverID, name string, start time.Time, duration time.Duration) zlmedia.RecordSegment {
+	return zlmedia.RecordSegment{
+		MediaServerID: serverID,
+		Key:           zlmedia.StreamKey{App: "live", Stream: "cam"},
+		FilePath:      "/opt/media/www/record/live/cam/" + start.Format("2006-01-02") + "/" + name,
+		StartTime:     start,
+		Duration:      duration,
+	}
+}
+
+func TestFileRecordStoreLoad(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	first, err := json.Marshal(testSegment("node1", "10-00-00.mp4", start, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr bool
+	}{
+		{"空文件", "", 0, false},
+		{"完整的行", string(first) + "\n", 1, false},
+		{"最后一行不完整", string(first) + "\n" + string(first[:20]), 1, false},
+		{"最后一行缺少换行", string(first) + "\n" + string(first), 1, false},
+		{"中间的行错误", string(first[:20]) + "\n" + string(first) + "\n", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "records.jsonl")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			store, err := zlmedia.OpenFileRecordStore(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OpenFileRecordStore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			// 截断后追加的内容不能与不完整的行拼接，重新打开时所有片段都可以加载
+			if err := store.Put(context.Background(), testSegment("node1", "11-00-00.mp4", start.Add(time.Hour), time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			store.Close()
+
+			store, err = zlmedia.OpenFileRecordStore(path)
+			if err != nil {
+				t.Fatalf("重新打开失败: %v", err)
+			}
+			defer store.Close()
+			segments, err := store.Query(context.Background(), zlmedia.RecordQuery{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(segments) != tt.want+1 {
+				t.Errorf("len(segments) = %d, want %d", len(segments), tt.want+1)
+			}
+		})
+	}
+}
+
+func TestMemoryRecordStore(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	a := testSegment("node1", "10-00-00.mp4", start, time.Hour)
+	b := testSegment("node1", "11-00-00.mp4", start.Add(time.Hour), time.Hour)
+
+	tests := []struct {
+		name   string
+		put    []zlmedia.RecordSegment
+		query  zlmedia.RecordQuery
+		want   []string
+		server string
+	}{
+		{"按开始时间排序", []zlmedia.RecordSegment{b, a}, zlmedia.RecordQuery{}, []string{"10-00-00.mp4", "11-00-00.mp4"}, "node1"},
+		{"时间范围有重叠", []zlmedia.RecordSegment{a, b}, zlmedia.RecordQuery{Start: start.Add(30 * time.Minute), End: start.Add(time.Hour)}, []string{"10-00-00.mp4"}, "node1"},
+		{"替换相同的片段", []zlmedia.RecordSegment{a, a}, zlmedia.RecordQuery{}, []string{"10-00-00.mp4"}, "node1"},
+		{"节点未知的片段与已有片段相同", []zlmedia.RecordSegment{a, testSegment("", "10-00-00.mp4", start, 0)}, zlmedia.RecordQuery{}, []string{"10-00-00.mp4"}, "node1"},
+		{"已有片段的节点未知", []zlmedia.RecordSegment{testSegment("", "10-00-00.mp4", start, 0), a}, zlmedia.RecordQuery{}, []string{"10-00-00.mp4"}, "node1"},
+		{"不同节点的相同路径", []zlmedia.RecordSegment{a, testSegment("node2", "10-00-00.mp4", start, time.Hour)}, zlmedia.RecordQuery{MediaServerID: "node2"}, []string{"10-00-00.mp4"}, "node2"},
+		{"按流过滤", []zlmedia.RecordSegment{a}, zlmedia.RecordQuery{Key: zlmedia.StreamKey{Stream: "other"}}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := zlmedia.NewMemoryRecordStore()
+			if err := store.Put(context.Background(), tt.put...); err != nil {
+				t.Fatal(err)
+			}
+			segments, err := store.Query(context.Background(), tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, segment := range segments {
+				got = append(got, filepath.Base(segment.FilePath))
+				if segment.MediaServerID != tt.server {
+					t.Errorf("MediaServerID = %q, want %q", segment.MediaServerID, tt.server)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Query() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordCatalog(t *testing.T) {
+	key := zlmedia.StreamKey{App: "live", Stream: "cam"}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	hook := &zlmedia.OnRecordMp4Hook{
+		HookBase: zlmedia.HookBase{MediaServerID: "node1"},
+		App:      "live", Stream: "cam",
+		FilePath:  "./www/record/live/cam/2024-01-01/10-00-00.mp4",
+		StartTime: day.Add(10 * time.Hour).Unix(),
+		TimeLen:   3600,
+		FileSize:  1024,
+		URL:       "record/live/cam/2024-01-01/10-00-00.mp4",
+	}
+
+	tests := []struct {
+		name      string
+		serverID  string
+		hookFirst bool
+		want      int
+	}{
+		{"先补齐再收到hook", "", false, 2},
+		{"先收到hook再补齐", "", true, 2},
+		{"配置了节点id", "node1", false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm := zlmtest.NewServer()
+			defer zlm.Close()
+			zlm.AddRecordFile(key, "2024-01-01", "10-00-00.mp4")
+			zlm.AddRecordFile(key, "2024-01-01", "11-00-00.mp4")
+			zlm.AddRecordFile(key, "2024-01-01", ".12-00-00.mp4")
+
+			catalog := zlmedia.NewRecordCatalog(zlm.Client(), nil, zlmedia.RecordCatalogConfig{MediaServerID: tt.serverID, Location: time.UTC})
+			hooks := catalog.HookHandler(nil)
+			ctx := context.Background()
+			if tt.hookFirst {
+				if err := hooks.OnRecordMp4(ctx, hook); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := catalog.Backfill(ctx, key, day, day); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.hookFirst {
+				if err := hooks.OnRecordMp4(ctx, hook); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			segments, err := catalog.Query(ctx, zlmedia.RecordQuery{Key: key})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(segments) != tt.want {
+				t.Fatalf("len(segments) = %d, want %d: %+v", len(segments), tt.want, segments)
+			}
+			// hook中的时长和大小优先于补齐时估算的值
+			if first := segments[0]; first.MediaServerID != "node1" || first.Size != 1024 || first.URL != "record/live/cam/2024-01-01/10-00-00.mp4" {
+				t.Errorf("segments[0] = %+v", first)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package zlmedia
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry 多节点ZLMediaKit客户端注册表
+// 以节点ID管理多个Client，支持运行时添加、替换、删除，可并发使用
+type Registry struct {
+	mu      sync.RWMutex
+	clients map[string]*Client
+}
+
+// NewRegistry 创建客户端注册表
+func NewRegistry() *Registry {
+	return &Registry{clients: make(map[string]*Client)}
+}
+
+// Add 添加节点
+// 节点ID已存在时返回错误，需要覆盖时请使用Replace
+func (r *Registry) Add(nodeID string, client *Client) error {
+	if err := validateNode(nodeID, client); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clients[nodeID]; ok {
+		return fmt.Errorf("节点%s已存在", nodeID)
+	}
+	r.clients[nodeID] = client
+	return nil
+}
+
+// Replace 添加或替换节点
+// 参数校验与Add一致
+// 返回: 被替换的旧客户端，节点不存在时为nil
+func (r *Registry) Replace(nodeID string, client *Client) (*Client, error) {
+	if err := validateNode(nodeID, client); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.clients[nodeID]
+	r.clients[nodeID] = client
+	return old, nil
+}
+
+// validateNode 校验节点ID和客户端
+func validateNode(nodeID string, client *Client) error {
+	if nodeID == "" {
+		return fmt.Errorf("节点ID不能为空")
+	}
+	if client == nil {
+		return fmt.Errorf("节点%s的客户端不能为空", nodeID)
+	}
+	return nil
+}
+
+// Remove 删除节点
+// 返回被删除的客户端，节点不存在时返回nil
+func (r *Registry) Remove(nodeID string) *Client {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.clients[nodeID]
+	delete(r.clients, nodeID)
+	return old
+}
+
+// Get 根据节点ID获取客户端
+func (r *Registry) Get(nodeID string) (*Client, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	client, ok := r.clients[nodeID]
+	return client, ok
+}
+
+// NodeIDs 获取所有节点ID，按字典序排列
+func (r *Registry) NodeIDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.clients))
+	for id := range r.clients {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Len 获取节点数量
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.clients)
+}
+
+// Range 按节点ID顺序遍历所有节点，fn返回false时停止遍历
+// 遍历的是调用时的快照，fn中可以安全地修改注册表
+func (r *Registry) Range(fn func(nodeID string, client *Client) bool) {
+	for _, id := range r.NodeIDs() {
+		client, ok := r.Get(id)
+		if !ok {
+			continue
+		}
+		if !fn(id, client) {
+			return
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package zlmedia_test
+
+import (
+	"testing"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+func TestRegistry(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	client1, client2 := zlm.Client(), zlm.Client()
+
+	registry := zlmedia.NewRegistry()
+	if err := registry.Add("node1", client1); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		op      func() (*zlmedia.Client, error)
+		wantOld *zlmedia.Client
+		wantErr bool
+		wantLen int
+	}{
+		{"添加已存在的节点", func() (*zlmedia.Client, error) { return nil, registry.Add("node1", client2) }, nil, true, 1},
+		{"添加空节点ID", func() (*zlmedia.Client, error) { return nil, registry.Add("", client2) }, nil, true, 1},
+		{"添加nil客户端", func() (*zlmedia.Client, error) { return nil, registry.Add("node2", nil) }, nil, true, 1},
+		{"替换空节点ID", func() (*zlmedia.Client, error) { return registry.Replace("", client2) }, nil, true, 1},
+		{"替换为nil客户端", func() (*zlmedia.Client, error) { return registry.Replace("node1", nil) }, nil, true, 1},
+		{"替换节点", func() (*zlmedia.Client, error) { return registry.Replace("node1", client2) }, client1, false, 1},
+		{"替换不存在的节点", func() (*zlmedia.Client, error) { return registry.Replace("node2", client1) }, nil, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, err := tt.op()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if old != tt.wantOld {
+				t.Errorf("old = %p, want %p", old, tt.wantOld)
+			}
+			if got := registry.Len(); got != tt.wantLen {
+				t.Errorf("Len() = %d, want %d", got, tt.wantLen)
+			}
+			// 注册表中不会出现nil客户端
+			registry.Range(func(nodeID string, client *zlmedia.Client) bool {
+				if client == nil {
+					t.Errorf("节点%s的客户端为nil", nodeID)
+				}
+				return true
+			})
+		})
+	}
+	if client, _ := registry.Get("node1"); client != client2 {
+		t.Errorf("Get(node1) = %p, want %p", client, client2)
+	}
+}
@@ -0,0 +1,323 @@
+package zlmedia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 录制保留策略相关错误
+var (
+	ErrRetentionNoStreams = errors.New("未配置录制保留策略的流来源")
+	ErrRetentionNoCatalog = errors.New("按总大小保留录制文件需要配置RecordCatalog")
+	ErrRetentionNoEvents  = errors.New("只保留事件日期需要配置IsEventDay")
+)
+
+// recordPeriodPrefix 列出所有录制日期文件夹时使用的period前缀
+// ZLMediaKit要求period非空，按前缀匹配文件夹名，日期文件夹都以年份的第一位开头
+const recordPeriodPrefix = "2"
+
+// RetentionReason 录制文件夹被删除的原因
+type RetentionReason string
+
+// 录制文件夹被删除的原因
+const (
+	RetentionExpired  RetentionReason = "expired"   // 超过KeepDays
+	RetentionNoEvent  RetentionReason = "no_event"  // 没有事件标记
+	RetentionMaxBytes RetentionReason = "max_bytes" // 总大小超过MaxBytes
+)
+
+// RetentionRule 录制保留规则
+// 流匹配多条规则时使用最具体的规则：同时指定应用名和流id的规则优先于只指定应用名的规则，再优先于默认规则
+type RetentionRule struct {
+	VHost         string // 虚拟主机，为空时匹配所有虚拟主机
+	App           string // 应用名，为空时匹配所有应用
+	Stream        string // 流id，为空时匹配应用下的所有流
+	KeepDays      int    // 保留最近N天的录制文件夹，包含当天，0表示不限制
+	MaxBytes      int64  // 流录制文件总大小上限，超过时从最早的一天开始删除，0表示不限制
+	EventDaysOnly bool   // 只保留有事件标记的日期，当天除外
+}
+
+// matches 规则是否适用于流
+func (r RetentionRule) matches(key StreamKey) bool {
+	return (r.VHost == "" || StreamKey{VHost: r.VHost}.NormalizedVHost() == key.NormalizedVHost()) &&
+		(r.App == "" || r.App == key.App) &&
+		(r.Stream == "" || r.Stream == key.Stream)
+}
+
+// specificity 规则的具体程度
+func (r RetentionRule) specificity() int {
+	n := 0
+	if r.App != "" {
+		n += 2
+	}
+	if r.Stream != "" {
+		n += 4
+	}
+	if r.VHost != "" {
+		n++
+	}
+	return n
+}
+
+// RetentionConfig 录制保留策略配置
+type RetentionConfig struct {
+	Rules    []RetentionRule // 保留规则，没有匹配规则的流不会被清理
+	Interval time.Duration   // Run的执行间隔，默认为1小时
+	DryRun   bool            // 只生成报告，不删除录制文件夹
+	Location *time.Location  // ZLMediaKit录制文件夹日期使用的时区，默认为time.Local
+
+	// Catalog 录制文件目录，用于获取每天录制文件的大小，删除文件夹后同时删除其中的片段记录；
+	// 使用MaxBytes时必须设置，大小只包含目录中有记录的文件
+	Catalog *RecordCatalog
+	// Streams 获取需要清理的流，为nil时使用Catalog中记录过的流
+	Streams func(ctx context.Context) ([]StreamKey, error)
+	// IsEventDay 判断流在某一天是否有事件标记，day格式为2006-01-02，使用EventDaysOnly时必须设置
+	IsEventDay func(ctx context.Context, key StreamKey, day string) (bool, error)
+
+	OnEnforce func(report *RetentionReport, err error) // Run每次执行后调用，可以为nil
+}
+
+// RetentionDeletion 需要删除的录制文件夹
+type RetentionDeletion struct {
+	Key    StreamKey       // 流的唯一标识
+	Period string          // 录制日期文件夹，格式为2006-01-02
+	Bytes  int64           // 文件夹中已知的录制文件大小
+	Reason RetentionReason // 删除原因
+	Err    error           // 删除失败的原因，演练或删除成功时为nil
+}
+
+// RetentionReport 录制保留策略执行报告
+type RetentionReport struct {
+	DryRun    bool                // 是否为演练，演练时没有删除任何文件夹
+	Streams   int                 // 检查的流数量
+	Deletions []RetentionDeletion // 需要删除的录制文件夹，按流和日期排序
+	Bytes     int64               // 需要删除的已知录制文件大小
+}
+
+// RetentionService 录制保留策略服务
+// 通过GetMp4RecordFile列出每个流的录制日期文件夹，按规则选出需要删除的日期，
+// 再调用DeleteRecordDirectory删除，当天的文件夹正在录制，不会被删除
+type RetentionService struct {
+	record *RecordAPI
+	config RetentionConfig
+}
+
+// NewRetentionService 创建录制保留策略服务
+func NewRetentionService(client *Client, config RetentionConfig) *RetentionService {
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	if config.Location == nil {
+		config.Location = time.Local
+	}
+
+	return &RetentionService{
+		record: NewRecordAPI(client),
+		config: config,
+	}
+}
+
+// Run 立即执行一次保留策略，之后按Interval定期执行，直到ctx结束
+// 每次执行的结果通过OnEnforce报告
+func (s *RetentionService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := s.Enforce(ctx)
+		if s.config.OnEnforce != nil {
+			s.config.OnEnforce(report, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Plan 生成需要删除的录制文件夹报告，不删除任何文件夹
+func (s *RetentionService) Plan(ctx context.Context) (*RetentionReport, error) {
+	return s.plan(ctx, time.Now())
+}
+
+// Enforce 执行保留策略，DryRun为true时等同于Plan
+// 单个文件夹删除失败不会中断执行，失败原因记录在报告中，所有失败会合并为一个错误返回
+func (s *RetentionService) Enforce(ctx context.Context) (*RetentionReport, error) {
+	report, err := s.plan(ctx, time.Now())
+	if err != nil || s.config.DryRun {
+		return report, err
+	}
+
+	var errs []error
+	for i := range report.Deletions {
+		deletion := &report.Deletions[i]
+		if err := s.delete(ctx, deletion); err != nil {
+			deletion.Err = err
+			errs = append(errs, err)
+		}
+	}
+	return report, errors.Join(errs...)
+}
+
+// delete 删除录制文件夹及目录中的片段记录
+func (s *RetentionService) delete(ctx context.Context, deletion *RetentionDeletion) error {
+	key := deletion.Key
+	if _, err := s.record.DeleteRecordDirectory(ctx, &DeleteRecordDirectoryRequest{VHost: key.VHost, App: key.App, Stream: key.Stream, Period: deletion.Period}); err != nil {
+		return fmt.Errorf("删除%s的录制文件夹%s失败: %w", key, deletion.Period, err)
+	}
+	if s.config.Catalog != nil {
+		if _, err := s.config.Catalog.Remove(ctx, RecordQuery{Key: key, Period: deletion.Period}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// plan 按规则选出需要删除的录制文件夹
+func (s *RetentionService) plan(ctx context.Context, now time.Time) (*RetentionReport, error) {
+	report := &RetentionReport{DryRun: s.config.DryRun}
+	streams, err := s.streams(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	today := now.In(s.config.Location).Format(recordDayLayout)
+	for _, key := range streams {
+		rule, ok := s.rule(key)
+		if !ok {
+			continue
+		}
+		report.Streams++
+
+		deletions, err := s.planStream(ctx, key, rule, now, today)
+		if err != nil {
+			return report, err
+		}
+		for _, deletion := range deletions {
+			report.Deletions = append(report.Deletions, deletion)
+			report.Bytes += deletion.Bytes
+		}
+	}
+	return report, nil
+}
+
+// planStream 按规则选出流需要删除的录制文件夹
+func (s *RetentionService) planStream(ctx context.Context, key StreamKey, rule RetentionRule, now time.Time, today string) ([]RetentionDeletion, error) {
+	if rule.MaxBytes > 0 && s.config.Catalog == nil {
+		return nil, ErrRetentionNoCatalog
+	}
+	if rule.EventDaysOnly && s.config.IsEventDay == nil {
+		return nil, ErrRetentionNoEvents
+	}
+
+	resp, err := s.record.GetMp4RecordFile(ctx, &GetMp4RecordFileRequest{VHost: key.VHost, App: key.App, Stream: key.Stream, Period: recordPeriodPrefix})
+	if err != nil {
+		return nil, fmt.Errorf("获取%s的录制文件夹失败: %w", key, err)
+	}
+	var days []string
+	for _, day := range resp.Data.Paths {
+		if _, err := time.Parse(recordDayLayout, day); err == nil && day < today {
+			days = append(days, day)
+		}
+	}
+	sort.Strings(days)
+
+	sizes := make(map[string]int64, len(days))
+	total := int64(0)
+	if s.config.Catalog != nil {
+		segments, err := s.config.Catalog.Query(ctx, RecordQuery{Key: key})
+		if err != nil {
+			return nil, err
+		}
+		periods := append(append([]string(nil), days...), today)
+		for _, segment := range segments {
+			for _, day := range periods {
+				if (RecordQuery{Period: day}).matches(segment) {
+					sizes[day] += segment.Size
+					total += segment.Size
+					break
+				}
+			}
+		}
+	}
+
+	cutoff := ""
+	if rule.KeepDays > 0 {
+		cutoff = now.In(s.config.Location).AddDate(0, 0, 1-rule.KeepDays).Format(recordDayLayout)
+	}
+
+	var deletions []RetentionDeletion
+	for _, day := range days {
+		reason := RetentionReason("")
+		switch {
+		case cutoff != "" && day < cutoff:
+			reason = RetentionExpired
+		case rule.EventDaysOnly:
+			tagged, err := s.config.IsEventDay(ctx, key, day)
+			if err != nil {
+				return nil, fmt.Errorf("获取%s在%s的事件标记失败: %w", key, day, err)
+			}
+			if !tagged {
+				reason = RetentionNoEvent
+			}
+		}
+		// 从最早的一天开始删除，直到总大小不超过MaxBytes
+		if reason == "" && rule.MaxBytes > 0 && total > rule.MaxBytes {
+			reason = RetentionMaxBytes
+		}
+		if reason != "" {
+			deletions = append(deletions, RetentionDeletion{Key: key, Period: day, Bytes: sizes[day], Reason: reason})
+			total -= sizes[day]
+		}
+	}
+	return deletions, nil
+}
+
+// rule 获取流适用的最具体的规则
+func (s *RetentionService) rule(key StreamKey) (RetentionRule, bool) {
+	best, found := RetentionRule{}, false
+	for _, rule := range s.config.Rules {
+		if rule.matches(key) && (!found || rule.specificity() > best.specificity()) {
+			best, found = rule, true
+		}
+	}
+	return best, found
+}
+
+// streams 获取需要清理的流，按流的唯一标识排序
+func (s *RetentionService) streams(ctx context.Context) ([]StreamKey, error) {
+	var keys []StreamKey
+	switch {
+	case s.config.Streams != nil:
+		streams, err := s.config.Streams(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取需要清理的流失败: %w", err)
+		}
+		keys = streams
+	case s.config.Catalog != nil:
+		segments, err := s.config.Catalog.Query(ctx, RecordQuery{})
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[StreamKey]bool)
+		for _, segment := range segments {
+			if !seen[segment.Key] {
+				seen[segment.Key] = true
+				keys = append(keys, segment.Key)
+			}
+		}
+	default:
+		return nil, ErrRetentionNoStreams
+	}
+
+	for i := range keys {
+		keys[i] = keys[i].Normalize()
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	return keys, nil
+}
@@ -0,0 +1,122 @@
+package zlmedia_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+func TestRetentionService(t *testing.T) {
+	key := zlmedia.StreamKey{VHost: zlmedia.DefaultVHost, App: "live", Stream: "test"}
+	now := time.Now().UTC()
+	// 录制日期文件夹为距今天数，每天一个100字节的文件
+	ages := []int{0, 1, 2, 5, 10}
+	day := func(age int) string { return now.AddDate(0, 0, -age).Format("2006-01-02") }
+	label := make(map[string]int, len(ages))
+	for _, age := range ages {
+		label[day(age)] = age
+	}
+
+	tests := []struct {
+		name       string
+		rules      []zlmedia.RetentionRule
+		noCatalog  bool
+		eventDays  []int // 有事件标记的日期，为nil时不设置IsEventDay
+		dryRun     bool
+		want       string // 距今天数/删除原因
+		wantErr    error
+		wantRemain int // 执行后ZLMediaKit上剩余的日期文件夹数量
+	}{
+		{"不限制", []zlmedia.RetentionRule{{}}, false, nil, false, "", nil, 5},
+		{"保留最近3天", []zlmedia.RetentionRule{{KeepDays: 3}}, false, nil, false, "10/expired,5/expired", nil, 3},
+		{"演练不删除", []zlmedia.RetentionRule{{KeepDays: 3}}, false, nil, true, "10/expired,5/expired", nil, 5},
+		{"只保留事件日期", []zlmedia.RetentionRule{{EventDaysOnly: true}}, false, []int{2}, false, "10/no_event,5/no_event,1/no_event", nil, 2},
+		{"总大小超过上限", []zlmedia.RetentionRule{{MaxBytes: 250}}, false, nil, false, "10/max_bytes,5/max_bytes,2/max_bytes", nil, 2},
+		{"过期后再按大小删除", []zlmedia.RetentionRule{{KeepDays: 6, MaxBytes: 150}}, false, nil, false, "10/expired,5/max_bytes,2/max_bytes,1/max_bytes", nil, 1},
+		{"使用最具体的规则", []zlmedia.RetentionRule{{KeepDays: 1}, {App: "live", KeepDays: 2}, {App: "live", Stream: "test", KeepDays: 6}}, false, nil, false, "10/expired", nil, 4},
+		{"没有匹配的规则", []zlmedia.RetentionRule{{App: "other", KeepDays: 1}}, false, nil, false, "", nil, 5},
+		{"按大小保留需要目录", []zlmedia.RetentionRule{{MaxBytes: 250}}, true, nil, false, "", zlmedia.ErrRetentionNoCatalog, 5},
+		{"只保留事件日期需要事件标记", []zlmedia.RetentionRule{{EventDaysOnly: true}}, false, nil, false, "", zlmedia.ErrRetentionNoEvents, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm := zlmtest.NewServer()
+			defer zlm.Close()
+			ctx := context.Background()
+
+			catalog := zlmedia.NewRecordCatalog(zlm.Client(), nil, zlmedia.RecordCatalogConfig{Location: time.UTC})
+			for _, age := range ages {
+				zlm.AddRecordFile(key, day(age), "10-00-00.mp4")
+				segment := zlmedia.RecordSegment{
+					Key:       key,
+					FilePath:  fmt.Sprintf("/opt/media/www/record/live/test/%s/10-00-00.mp4", day(age)),
+					StartTime: now.AddDate(0, 0, -age),
+					Size:      100,
+				}
+				if err := catalog.Store().Put(ctx, segment); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			config := zlmedia.RetentionConfig{
+				Rules:    tt.rules,
+				DryRun:   tt.dryRun,
+				Location: time.UTC,
+				Catalog:  catalog,
+				Streams: func(ctx context.Context) ([]zlmedia.StreamKey, error) {
+					return []zlmedia.StreamKey{key}, nil
+				},
+			}
+			if tt.noCatalog {
+				config.Catalog = nil
+			}
+			if tt.eventDays != nil {
+				config.IsEventDay = func(ctx context.Context, _ zlmedia.StreamKey, d string) (bool, error) {
+					for _, age := range tt.eventDays {
+						if d == day(age) {
+							return true, nil
+						}
+					}
+					return false, nil
+				}
+			}
+
+			report, err := zlmedia.NewRetentionService(zlm.Client(), config).Enforce(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Enforce() error = %v, want %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, deletion := range report.Deletions {
+				got = append(got, fmt.Sprintf("%d/%s", label[deletion.Period], deletion.Reason))
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("Deletions = %s, want %s", strings.Join(got, ","), tt.want)
+			}
+			if report.Bytes != int64(len(got))*100 && !tt.noCatalog {
+				t.Errorf("Bytes = %d, want %d", report.Bytes, len(got)*100)
+			}
+
+			resp, err := zlmedia.NewRecordAPI(zlm.Client()).GetMp4RecordFile(ctx, &zlmedia.GetMp4RecordFileRequest{VHost: key.VHost, App: key.App, Stream: key.Stream, Period: "2"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(resp.Data.Paths); got != tt.wantRemain {
+				t.Errorf("剩余录制文件夹 = %d, want %d", got, tt.wantRemain)
+			}
+			// 删除文件夹时同时删除目录中的片段记录
+			segments, err := catalog.Query(ctx, zlmedia.RecordQuery{Key: key})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(segments) != tt.wantRemain {
+				t.Errorf("剩余片段记录 = %d, want %d", len(segments), tt.wantRemain)
+			}
+		})
+	}
+}
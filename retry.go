@@ -0,0 +1,144 @@
+package zlmedia
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RetryPolicy 请求重试策略
+// 只读接口(例如getMediaList、getServerConfig)失败时自动重试；
+// 修改类接口(例如addStreamProxy、startSendRtp)重试可能导致重复执行，只有ctx经WithRetryMutating处理后才会重试
+type RetryPolicy struct {
+	MaxAttempts    int                  // 最大尝试次数，包括首次请求，<=1时不重试
+	InitialBackoff time.Duration        // 首次重试前的等待时间，默认为100毫秒
+	MaxBackoff     time.Duration        // 最大等待时间，默认为5秒
+	Multiplier     float64              // 每次重试等待时间的增长倍数，默认为2
+	Jitter         float64              // 等待时间的随机抖动比例，取值0~1，默认为0.2，<0时不抖动
+	Retryable      func(err error) bool // 判断错误是否可以重试，默认为DefaultRetryable
+}
+
+// DefaultRetryable 默认的可重试判断
+// 网络错误、HTTP 429和5xx状态码可以重试；ctx取消或超时、ZLMediaKit返回的业务错误不重试
+func DefaultRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatus == http.StatusTooManyRequests || apiErr.HTTPStatus >= 500
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// withDefaults 填充默认值
+func (p RetryPolicy) withDefaults() *RetryPolicy {
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 5 * time.Second
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	switch {
+	case p.Jitter == 0:
+		p.Jitter = 0.2
+	case p.Jitter < 0:
+		p.Jitter = 0
+	case p.Jitter > 1:
+		p.Jitter = 1
+	}
+	if p.Retryable == nil {
+		p.Retryable = DefaultRetryable
+	}
+	return &p
+}
+
+// backoff 第attempt次请求失败后的等待时间，attempt从1开始
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+type retryMutatingKey struct{}
+
+// WithRetryMutating 返回允许重试修改类接口的ctx
+// 仅在调用方确认重复执行无副作用时使用，例如重复添加同一个拉流代理
+func WithRetryMutating(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryMutatingKey{}, true)
+}
+
+// readOnlyEndpoints 可以安全重试的只读接口
+var readOnlyEndpoints = map[string]bool{
+	"/index/api/getApiList":            true,
+	"/index/api/getThreadsLoad":        true,
+	"/index/api/getWorkThreadsLoad":    true,
+	"/index/api/getStatistic":          true,
+	"/index/api/getServerConfig":       true,
+	"/index/api/getMediaList":          true,
+	"/index/api/listStreamProxy":       true,
+	"/index/api/listStreamPusherProxy": true,
+	"/index/api/isRecording":           true,
+	"/index/api/getMp4RecordFile":      true,
+	"/index/api/getSnap":               true,
+	"/index/api/listRtpServer":         true,
+	"/index/api/getRtpInfo":            true,
+	"/index/api/getAllSession":         true,
+	"/index/api/getWebRTCApi":          true,
+}
+
+// retry 按重试策略执行send
+// send成功但ZLMediaKit返回了错误代码时，由Retryable判断是否重试；重试次数用尽时原样返回最后一次的响应
+func (c *Client) retry(ctx context.Context, path string, send func() ([]byte, error)) ([]byte, error) {
+	policy := c.config.RetryPolicy
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return send()
+	}
+	if mutating, _ := ctx.Value(retryMutatingKey{}).(bool); !readOnlyEndpoints[path] && !mutating {
+		return send()
+	}
+
+	for attempt := 1; ; attempt++ {
+		respBody, err := send()
+		retryErr := err
+		if err == nil {
+			retryErr = responseError(path, respBody)
+		}
+		if retryErr == nil || attempt >= policy.MaxAttempts || !policy.Retryable(retryErr) {
+			return respBody, err
+		}
+
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// responseError 检查响应中的错误代码，响应不是JSON(例如截图)时返回nil
+func responseError(path string, respBody []byte) error {
+	var status Status
+	if err := json.Unmarshal(respBody, &status); err != nil || status.Code == CodeSuccess {
+		return nil
+	}
+	return &APIError{Code: status.Code, Msg: status.Msg, Endpoint: path}
+}
@@ -0,0 +1,83 @@
+package zlmedia_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	const (
+		getMediaList   = "/index/api/getMediaList"
+		addStreamProxy = "/index/api/addStreamProxy"
+	)
+	getMedia := func(ctx context.Context, client *zlmedia.Client) error {
+		_, err := zlmedia.NewMediaAPI(client).GetMediaList(ctx, &zlmedia.GetMediaListRequest{})
+		return err
+	}
+	addProxy := func(ctx context.Context, client *zlmedia.Client) error {
+		_, err := zlmedia.NewProxyAPI(client).AddStreamProxy(ctx, &zlmedia.AddStreamProxyRequest{VHost: zlmedia.DefaultVHost, App: "live", Stream: "test", URL: "rtsp://a"})
+		return err
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		fault    zlmtest.Fault
+		mutating bool // 是否通过WithRetryMutating开启修改类接口的重试
+		call     func(ctx context.Context, client *zlmedia.Client) error
+		wantErr  bool
+		wantReqs int
+	}{
+		{"只读接口5xx后重试成功", getMediaList, zlmtest.Fault{HTTPStatus: http.StatusBadGateway, Times: 2}, false, getMedia, false, 3},
+		{"只读接口429后重试成功", getMediaList, zlmtest.Fault{HTTPStatus: http.StatusTooManyRequests, Times: 1}, false, getMedia, false, 2},
+		{"重试次数用尽", getMediaList, zlmtest.Fault{HTTPStatus: http.StatusServiceUnavailable}, false, getMedia, true, 3},
+		{"业务错误不重试", getMediaList, zlmtest.Fault{Code: zlmedia.CodeException, Msg: "boom"}, false, getMedia, true, 1},
+		{"HTTP 4xx不重试", getMediaList, zlmtest.Fault{HTTPStatus: http.StatusNotFound}, false, getMedia, true, 1},
+		{"修改类接口默认不重试", addStreamProxy, zlmtest.Fault{HTTPStatus: http.StatusBadGateway, Times: 1}, false, addProxy, true, 1},
+		{"修改类接口显式开启重试", addStreamProxy, zlmtest.Fault{HTTPStatus: http.StatusBadGateway, Times: 1}, true, addProxy, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm := zlmtest.NewServer()
+			defer zlm.Close()
+			config := zlm.Config()
+			config.RetryPolicy = &zlmedia.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Jitter: -1}
+			client := zlmedia.NewClient(config)
+			zlm.InjectFault(tt.path, tt.fault)
+
+			ctx := context.Background()
+			if tt.mutating {
+				ctx = zlmedia.WithRetryMutating(ctx)
+			}
+			if err := tt.call(ctx, client); (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := zlm.RequestCount(tt.path); got != tt.wantReqs {
+				t.Errorf("RequestCount(%s) = %d, want %d", tt.path, got, tt.wantReqs)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyStopsOnCancel(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	config := zlm.Config()
+	config.RetryPolicy = &zlmedia.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}
+	client := zlmedia.NewClient(config)
+	zlm.InjectFault("/index/api/getMediaList", zlmtest.Fault{HTTPStatus: http.StatusBadGateway})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := zlmedia.NewMediaAPI(client).GetMediaList(ctx, &zlmedia.GetMediaListRequest{}); err == nil {
+		t.Fatal("GetMediaList() error = nil, want context error")
+	}
+	if got := zlm.RequestCount("/index/api/getMediaList"); got != 1 {
+		t.Errorf("RequestCount = %d, want 1", got)
+	}
+}
@@ -15,11 +15,6 @@ func NewRTPAPI(client *Client) *RTPAPI {
 	return &RTPAPI{client: client}
 }
 
-// GetRTPAPI 获取RTP API实例
-func GetRTPAPI() *RTPAPI {
-	return NewRTPAPI(GetClient())
-}
-
 // OpenRtpServerRequest 创建GB28181 RTP接收端口请求参数
 type OpenRtpServerRequest struct {
 	Port       int    `json:"port"`                  // 接收端口，0则为随机端口
@@ -27,6 +22,13 @@ type OpenRtpServerRequest struct {
 	StreamID   string `json:"stream_id"`             // 该端口绑定的流id
 	ReUsePort  *int   `json:"re_use_port,omitempty"` // 是否重用端口，1为重用，0为不重用，默认为1
 	SsrcFilter *int   `json:"ssrc_filter,omitempty"` // 是否开启ssrc过滤，1为开启，0为关闭，默认为0
+	SSRC       string `json:"ssrc,omitempty"`        // 只接收该ssrc的RTP包，十进制字符串，为空则不限制
+}
+
+// OpenRtpServerResponse 创建GB28181 RTP接收端口响应
+type OpenRtpServerResponse struct {
+	Status
+	Port int `json:"port"` // 接收端口，方便获取随机端口号
 }
 
 // OpenRtpServer 创建GB28181 RTP接收端口
@@ -37,9 +39,10 @@ type OpenRtpServerRequest struct {
 //   - StreamID: 该端口绑定的流id
 //   - ReUsePort: 是否重用端口，1为重用，0为不重用，默认为1
 //   - SsrcFilter: 是否开启ssrc过滤，1为开启，0为关闭，默认为0
+//   - SSRC: 只接收该ssrc的RTP包，十进制字符串，为空则不限制
 //
 // 返回: 创建的RTP端口信息
-func (rtp *RTPAPI) OpenRtpServer(ctx context.Context, req *OpenRtpServerRequest) (*BaseResponse, error) {
+func (rtp *RTPAPI) OpenRtpServer(ctx context.Context, req *OpenRtpServerRequest) (*OpenRtpServerResponse, error) {
 	params := map[string]interface{}{
 		"port":      req.Port,
 		"stream_id": req.StreamID,
@@ -54,13 +57,16 @@ func (rtp *RTPAPI) OpenRtpServer(ctx context.Context, req *OpenRtpServerRequest)
 	if req.SsrcFilter != nil {
 		params["ssrc_filter"] = *req.SsrcFilter
 	}
+	if req.SSRC != "" {
+		params["ssrc"] = req.SSRC
+	}
 
-	respBody, err := rtp.client.SendRequest(ctx, "GET", "/index/api/openRtpServer", params)
+	resp, err := doRequest[OpenRtpServerResponse](ctx, rtp.client, "GET", "/index/api/openRtpServer", params)
 	if err != nil {
-		return nil, fmt.Errorf("创建RTP接收端口失败: %w", err)
+		return resp, fmt.Errorf("创建RTP接收端口失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // CloseRtpServerRequest 关闭GB28181 RTP接收端口请求参数
@@ -68,23 +74,29 @@ type CloseRtpServerRequest struct {
 	StreamID string `json:"stream_id"` // 调用openRtpServer接口时提供的流id
 }
 
+// CloseRtpServerResponse 关闭GB28181 RTP接收端口响应
+type CloseRtpServerResponse struct {
+	Status
+	Hit int `json:"hit"` // 是否找到记录并关闭
+}
+
 // CloseRtpServer 关闭GB28181 RTP接收端口
 // 关闭指定的RTP接收端口
 // 参数:
 //   - StreamID: 调用openRtpServer接口时提供的流id
 //
 // 返回: 关闭结果
-func (rtp *RTPAPI) CloseRtpServer(ctx context.Context, req *CloseRtpServerRequest) (*BaseResponse, error) {
+func (rtp *RTPAPI) CloseRtpServer(ctx context.Context, req *CloseRtpServerRequest) (*CloseRtpServerResponse, error) {
 	params := map[string]interface{}{
 		"stream_id": req.StreamID,
 	}
 
-	respBody, err := rtp.client.SendRequest(ctx, "GET", "/index/api/closeRtpServer", params)
+	resp, err := doRequest[CloseRtpServerResponse](ctx, rtp.client, "GET", "/index/api/closeRtpServer", params)
 	if err != nil {
-		return nil, fmt.Errorf("关闭RTP接收端口失败: %w", err)
+		return resp, fmt.Errorf("关闭RTP接收端口失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // ListRtpServerRequest 获取openRtpServer接口创建的所有RTP服务器请求参数
@@ -92,16 +104,22 @@ type ListRtpServerRequest struct {
 	// 无额外参数，只需要secret
 }
 
+// RtpServerInfo openRtpServer接口创建的RTP服务器信息
+type RtpServerInfo struct {
+	Port     int    `json:"port"`      // 绑定的端口号
+	StreamID string `json:"stream_id"` // 绑定的流id
+}
+
 // ListRtpServer 获取openRtpServer接口创建的所有RTP服务器
 // 获取所有RTP服务器的列表
 // 返回: RTP服务器列表信息
-func (rtp *RTPAPI) ListRtpServer(ctx context.Context, req *ListRtpServerRequest) (*BaseResponse, error) {
-	respBody, err := rtp.client.SendRequest(ctx, "GET", "/index/api/listRtpServer", nil)
+func (rtp *RTPAPI) ListRtpServer(ctx context.Context, req *ListRtpServerRequest) (*Response[[]RtpServerInfo], error) {
+	resp, err := doRequest[Response[[]RtpServerInfo]](ctx, rtp.client, "GET", "/index/api/listRtpServer", nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取RTP服务器列表失败: %w", err)
+		return resp, fmt.Errorf("获取RTP服务器列表失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // StartSendRtpRequest 作为GB28181客户端，启动ps-rtp推流请求参数
@@ -119,6 +137,12 @@ type StartSendRtpRequest struct {
 	OnlyAudio *int   `json:"only_audio,omitempty"` // 当use_ps为0时，有效。为1时，发送音频；为0时，发送视频；不传时默认为0
 }
 
+// StartSendRtpResponse 启动ps-rtp推流响应
+type StartSendRtpResponse struct {
+	Status
+	LocalPort int `json:"local_port"` // 使用的本地端口号
+}
+
 // StartSendRtp 作为GB28181客户端，启动ps-rtp推流
 // 启动RTP推流到指定的目标地址
 // 参数:
@@ -135,7 +159,7 @@ type StartSendRtpRequest struct {
 //   - OnlyAudio: 当use_ps为0时，有效。为1时，发送音频；为0时，发送视频；不传时默认为0
 //
 // 返回: 启动推流结果
-func (rtp *RTPAPI) StartSendRtp(ctx context.Context, req *StartSendRtpRequest) (*BaseResponse, error) {
+func (rtp *RTPAPI) StartSendRtp(ctx context.Context, req *StartSendRtpRequest) (*StartSendRtpResponse, error) {
 	params := map[string]interface{}{
 		"vhost":    req.VHost,
 		"app":      req.App,
@@ -161,12 +185,12 @@ func (rtp *RTPAPI) StartSendRtp(ctx context.Context, req *StartSendRtpRequest) (
 		params["only_audio"] = *req.OnlyAudio
 	}
 
-	respBody, err := rtp.client.SendRequest(ctx, "GET", "/index/api/startSendRtp", params)
+	resp, err := doRequest[StartSendRtpResponse](ctx, rtp.client, "GET", "/index/api/startSendRtp", params)
 	if err != nil {
-		return nil, fmt.Errorf("启动RTP推流失败: %w", err)
+		return resp, fmt.Errorf("启动RTP推流失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // StopSendRtpRequest 停止GB28181 ps-rtp推流请求参数
@@ -186,7 +210,7 @@ type StopSendRtpRequest struct {
 //   - Ssrc: rtp推流的ssrc
 //
 // 返回: 停止推流结果
-func (rtp *RTPAPI) StopSendRtp(ctx context.Context, req *StopSendRtpRequest) (*BaseResponse, error) {
+func (rtp *RTPAPI) StopSendRtp(ctx context.Context, req *StopSendRtpRequest) (*Status, error) {
 	params := map[string]interface{}{
 		"vhost":  req.VHost,
 		"app":    req.App,
@@ -194,12 +218,12 @@ func (rtp *RTPAPI) StopSendRtp(ctx context.Context, req *StopSendRtpRequest) (*B
 		"ssrc":   req.Ssrc,
 	}
 
-	respBody, err := rtp.client.SendRequest(ctx, "GET", "/index/api/stopSendRtp", params)
+	resp, err := doRequest[Status](ctx, rtp.client, "GET", "/index/api/stopSendRtp", params)
 	if err != nil {
-		return nil, fmt.Errorf("停止RTP推流失败: %w", err)
+		return resp, fmt.Errorf("停止RTP推流失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // GetRtpInfoRequest 获取rtp推流信息请求参数
@@ -207,21 +231,31 @@ type GetRtpInfoRequest struct {
 	StreamID string `json:"stream_id"` // 流id
 }
 
+// RtpInfoResponse 获取rtp推流信息响应
+type RtpInfoResponse struct {
+	Status
+	Exist     bool   `json:"exist"`                // 是否存在
+	PeerIP    string `json:"peer_ip,omitempty"`    // 推流客户端ip
+	PeerPort  int    `json:"peer_port,omitempty"`  // 推流客户端端口号
+	LocalIP   string `json:"local_ip,omitempty"`   // 本地监听的网卡ip
+	LocalPort int    `json:"local_port,omitempty"` // 本地监听端口号
+}
+
 // GetRtpInfo 获取rtp推流信息
 // 获取指定流的RTP推流信息
 // 参数:
 //   - StreamID: 流id
 //
 // 返回: RTP推流信息
-func (rtp *RTPAPI) GetRtpInfo(ctx context.Context, req *GetRtpInfoRequest) (*BaseResponse, error) {
+func (rtp *RTPAPI) GetRtpInfo(ctx context.Context, req *GetRtpInfoRequest) (*RtpInfoResponse, error) {
 	params := map[string]interface{}{
 		"stream_id": req.StreamID,
 	}
 
-	respBody, err := rtp.client.SendRequest(ctx, "GET", "/index/api/getRtpInfo", params)
+	resp, err := doRequest[RtpInfoResponse](ctx, rtp.client, "GET", "/index/api/getRtpInfo", params)
 	if err != nil {
-		return nil, fmt.Errorf("获取RTP推流信息失败: %w", err)
+		return resp, fmt.Errorf("获取RTP推流信息失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
@@ -0,0 +1,422 @@
+package zlmedia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrLeaseNotFound RTP接收端口没有租约
+var ErrLeaseNotFound = errors.New("RTP接收端口租约不存在")
+
+// rtpApp openRtpServer创建的流所属的应用名
+const rtpApp = "rtp"
+
+// RtpCloseReason RTP接收端口关闭的原因
+type RtpCloseReason string
+
+// RTP接收端口关闭的原因
+const (
+	RtpClosedByOwner    RtpCloseReason = "closed"           // 所有者调用Close或CloseOwner
+	RtpLeaseExpired     RtpCloseReason = "expired"          // 租期到期未续租
+	RtpStreamNotArrived RtpCloseReason = "not_arrived"      // 超过ArrivalTimeout仍未收到流
+	RtpServerTimeout    RtpCloseReason = "timeout"          // ZLMediaKit触发on_rtp_server_timeout
+	RtpServerRestarted  RtpCloseReason = "server_restarted" // ZLMediaKit重启，所有端口都已关闭
+	RtpServerGone       RtpCloseReason = "gone"             // Sync时ZLMediaKit中已不存在该端口
+)
+
+// RtpServerManagerConfig RTP接收端口管理器配置
+type RtpServerManagerConfig struct {
+	CheckInterval  time.Duration // 检查租期和收流状态的间隔，默认为5秒
+	ArrivalTimeout time.Duration // 创建后超过该时间仍未收到流则关闭端口，默认为30秒
+	OrphanTTL      time.Duration // Sync接管的未知端口的租期，到期前未被Claim则关闭，默认为ArrivalTimeout
+	StreamIDPrefix string        // 只管理流id以该前缀开头的端口，为空时管理节点上的所有端口
+	MediaServerID  string        // 只处理该节点的hook事件，为空时处理所有事件
+
+	OnClose func(lease RtpLease, reason RtpCloseReason) // 端口关闭或租约被删除后调用，可以为nil
+	OnError func(err error)                             // Run中Sync或检查失败时调用，可以为nil
+}
+
+// RtpLease RTP接收端口的租约
+type RtpLease struct {
+	StreamID  string    // 端口绑定的流id
+	Port      int       // 接收端口
+	Owner     string    // 所有者，例如服务名或会话id，Sync接管的端口为空
+	CreatedAt time.Time // 创建或接管的时间
+	ExpiresAt time.Time // 租期到期时间，零值表示不过期
+	ArrivedAt time.Time // 收到流的时间，零值表示尚未收到流
+}
+
+// Arrived 是否已收到流
+func (l RtpLease) Arrived() bool {
+	return !l.ArrivedAt.IsZero()
+}
+
+// RtpServerManager RTP接收端口管理器
+// 记录每个openRtpServer端口的所有者和租期，关闭租期到期或长时间未收到流的端口，
+// 启动时通过ListRtpServer接管已存在的端口，避免进程重启后端口泄漏
+type RtpServerManager struct {
+	client *Client
+	rtp    *RTPAPI
+	config RtpServerManagerConfig
+
+	mu          sync.Mutex
+	leases      map[string]*RtpLease
+	released    map[string]time.Time // 同步期间关闭的端口及关闭时间，避免进行中的Sync把端口重新接管
+	restartedAt time.Time            // 同步期间ZLMediaKit重启的时间，重启前获取的端口列表已过期
+	syncing     int                  // 进行中的Sync数量
+}
+
+// NewRtpServerManager 创建RTP接收端口管理器
+func NewRtpServerManager(client *Client, config RtpServerManagerConfig) *RtpServerManager {
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = 5 * time.Second
+	}
+	if config.ArrivalTimeout <= 0 {
+		config.ArrivalTimeout = 30 * time.Second
+	}
+	if config.OrphanTTL <= 0 {
+		config.OrphanTTL = config.ArrivalTimeout
+	}
+
+	return &RtpServerManager{
+		client:   client,
+		rtp:      NewRTPAPI(client),
+		config:   config,
+		leases:   make(map[string]*RtpLease),
+		released: make(map[string]time.Time),
+	}
+}
+
+// Open 创建RTP接收端口并记录租约
+// 参数:
+//   - req: openRtpServer请求参数
+//   - owner: 所有者
+//   - ttl: 租期，0表示不过期，只在未收到流或被关闭时释放
+//
+// 返回: 租约
+func (m *RtpServerManager) Open(ctx context.Context, req *OpenRtpServerRequest, owner string, ttl time.Duration) (*RtpLease, error) {
+	resp, err := m.rtp.OpenRtpServer(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	lease := &RtpLease{StreamID: req.StreamID, Port: resp.Port, Owner: owner, CreatedAt: now}
+	if ttl > 0 {
+		lease.ExpiresAt = now.Add(ttl)
+	}
+
+	m.mu.Lock()
+	m.leases[req.StreamID] = lease
+	copied := *lease
+	m.mu.Unlock()
+	return &copied, nil
+}
+
+// Renew 续租
+// 参数:
+//   - ttl: 从现在开始的租期，0表示不过期
+func (m *RtpServerManager) Renew(streamID string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lease, ok := m.leases[streamID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrLeaseNotFound, streamID)
+	}
+	lease.ExpiresAt = time.Time{}
+	if ttl > 0 {
+		lease.ExpiresAt = time.Now().Add(ttl)
+	}
+	return nil
+}
+
+// Claim 接管端口，例如进程重启后接管Sync发现的端口
+// 参数:
+//   - owner: 新的所有者
+//   - ttl: 从现在开始的租期，0表示不过期
+func (m *RtpServerManager) Claim(streamID, owner string, ttl time.Duration) error {
+	m.mu.Lock()
+	lease, ok := m.leases[streamID]
+	if ok {
+		lease.Owner = owner
+	}
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrLeaseNotFound, streamID)
+	}
+	return m.Renew(streamID, ttl)
+}
+
+// Close 关闭RTP接收端口并删除租约
+func (m *RtpServerManager) Close(ctx context.Context, streamID string) error {
+	return m.close(ctx, streamID, RtpClosedByOwner)
+}
+
+// CloseOwner 关闭所有者的所有RTP接收端口
+func (m *RtpServerManager) CloseOwner(ctx context.Context, owner string) error {
+	var errs []error
+	for _, lease := range m.Leases() {
+		if lease.Owner != owner {
+			continue
+		}
+		if err := m.Close(ctx, lease.StreamID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// close 调用CloseRtpServer关闭端口，成功后删除租约
+func (m *RtpServerManager) close(ctx context.Context, streamID string, reason RtpCloseReason) error {
+	if _, err := m.rtp.CloseRtpServer(ctx, &CloseRtpServerRequest{StreamID: streamID}); err != nil {
+		return fmt.Errorf("关闭RTP接收端口%s失败: %w", streamID, err)
+	}
+	m.release(streamID, reason)
+	return nil
+}
+
+// release 删除租约并调用OnClose
+func (m *RtpServerManager) release(streamID string, reason RtpCloseReason) {
+	m.mu.Lock()
+	lease, ok := m.leases[streamID]
+	delete(m.leases, streamID)
+	if m.syncing > 0 {
+		m.released[streamID] = time.Now()
+	}
+	m.mu.Unlock()
+
+	if ok && m.config.OnClose != nil {
+		m.config.OnClose(*lease, reason)
+	}
+}
+
+// Lease 获取流id的租约
+func (m *RtpServerManager) Lease(streamID string) (RtpLease, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lease, ok := m.leases[streamID]
+	if !ok {
+		return RtpLease{}, false
+	}
+	return *lease, true
+}
+
+// Leases 获取所有租约，按流id排序
+func (m *RtpServerManager) Leases() []RtpLease {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	leases := make([]RtpLease, 0, len(m.leases))
+	for _, lease := range m.leases {
+		leases = append(leases, *lease)
+	}
+	sort.Slice(leases, func(i, j int) bool { return leases[i].StreamID < leases[j].StreamID })
+	return leases
+}
+
+// Run 先Sync再按CheckInterval定期Check，直到ctx结束
+// Sync失败时在下一个周期重试，错误通过OnError报告
+func (m *RtpServerManager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.config.CheckInterval)
+	defer ticker.Stop()
+
+	synced := false
+	for {
+		if !synced {
+			if err := m.Sync(ctx); err != nil {
+				m.reportError(err)
+			} else {
+				synced = true
+			}
+		}
+		if synced {
+			if err := m.Check(ctx); err != nil {
+				m.reportError(err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync 根据ListRtpServer同步租约表
+// ZLMediaKit中存在但没有租约的端口以空所有者接管，租期为OrphanTTL；
+// 有租约但ZLMediaKit中已不存在的端口删除租约；
+// 同步期间被关闭或超时的端口不会被接管，同步期间ZLMediaKit重启时放弃本次结果
+func (m *RtpServerManager) Sync(ctx context.Context) error {
+	m.mu.Lock()
+	started := time.Now()
+	m.syncing++
+	m.mu.Unlock()
+	defer m.endSync()
+
+	list, err := m.rtp.ListRtpServer(ctx, &ListRtpServerRequest{})
+	if err != nil {
+		return fmt.Errorf("同步RTP接收端口失败: %w", err)
+	}
+
+	current := make(map[string]RtpServerInfo, len(list.Data))
+	for _, server := range list.Data {
+		if strings.HasPrefix(server.StreamID, m.config.StreamIDPrefix) {
+			current[server.StreamID] = server
+		}
+	}
+
+	var gone []string
+	m.mu.Lock()
+	if m.restartedAt.After(started) {
+		m.mu.Unlock()
+		return nil
+	}
+	for streamID, lease := range m.leases {
+		// 同步开始后创建的端口可能不在列表中
+		if _, ok := current[streamID]; !ok && lease.CreatedAt.Before(started) {
+			gone = append(gone, streamID)
+		}
+	}
+	now := time.Now()
+	for streamID, server := range current {
+		if _, ok := m.leases[streamID]; !ok && !m.released[streamID].After(started) {
+			m.leases[streamID] = &RtpLease{StreamID: streamID, Port: server.Port, CreatedAt: now, ExpiresAt: now.Add(m.config.OrphanTTL)}
+		}
+	}
+	m.mu.Unlock()
+
+	for _, streamID := range gone {
+		m.release(streamID, RtpServerGone)
+	}
+	return nil
+}
+
+// endSync 结束同步，没有进行中的Sync时删除记录不再需要
+func (m *RtpServerManager) endSync() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.syncing--
+	if m.syncing == 0 {
+		clear(m.released)
+		m.restartedAt = time.Time{}
+	}
+}
+
+// Check 关闭租期到期的端口，并通过GetRtpInfo检查尚未收到流的端口，超过ArrivalTimeout仍未收到流时关闭
+// 单个端口的失败不会中断检查，所有失败会合并为一个错误返回
+func (m *RtpServerManager) Check(ctx context.Context) error {
+	now := time.Now()
+	var errs []error
+	for _, lease := range m.Leases() {
+		if !lease.ExpiresAt.IsZero() && now.After(lease.ExpiresAt) {
+			if err := m.close(ctx, lease.StreamID, RtpLeaseExpired); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if lease.Arrived() {
+			continue
+		}
+
+		info, err := m.rtp.GetRtpInfo(ctx, &GetRtpInfoRequest{StreamID: lease.StreamID})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if info.Exist {
+			m.markArrived(lease.StreamID)
+			continue
+		}
+		if now.Sub(lease.CreatedAt) > m.config.ArrivalTimeout {
+			if err := m.close(ctx, lease.StreamID, RtpStreamNotArrived); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// markArrived 记录端口已收到流
+func (m *RtpServerManager) markArrived(streamID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if lease, ok := m.leases[streamID]; ok && !lease.Arrived() {
+		lease.ArrivedAt = time.Now()
+	}
+}
+
+// releaseAll 删除所有租约
+func (m *RtpServerManager) releaseAll(reason RtpCloseReason) {
+	m.mu.Lock()
+	if m.syncing > 0 {
+		m.restartedAt = time.Now()
+	}
+	m.mu.Unlock()
+
+	for _, lease := range m.Leases() {
+		m.release(lease.StreamID, reason)
+	}
+}
+
+// reportError 报告Run中的错误
+func (m *RtpServerManager) reportError(err error) {
+	if m.config.OnError != nil {
+		m.config.OnError(err)
+	}
+}
+
+// HookHandler 创建根据hook事件更新租约的HookHandler
+// on_stream_changed记录rtp流已到达，on_rtp_server_timeout删除超时端口的租约，on_server_started删除所有租约，
+// 再调用next，next为nil时使用NopHookHandler
+func (m *RtpServerManager) HookHandler(next HookHandler) HookHandler {
+	if next == nil {
+		next = NopHookHandler{}
+	}
+	return &rtpManagerHookHandler{HookHandler: next, manager: m}
+}
+
+// rtpManagerHookHandler 根据hook事件更新租约的HookHandler
+type rtpManagerHookHandler struct {
+	HookHandler
+	manager *RtpServerManager
+}
+
+// OnStreamChanged rtp流注册后不再需要轮询GetRtpInfo
+func (h *rtpManagerHookHandler) OnStreamChanged(ctx context.Context, hook *OnStreamChangedHook) error {
+	if h.matches(hook.MediaServerID) && hook.Regist && hook.App == rtpApp {
+		h.manager.markArrived(hook.Stream)
+	}
+	return h.HookHandler.OnStreamChanged(ctx, hook)
+}
+
+// OnRtpServerTimeout 端口长时间未收到数据已被ZLMediaKit关闭
+func (h *rtpManagerHookHandler) OnRtpServerTimeout(ctx context.Context, hook *OnRtpServerTimeoutHook) error {
+	if h.matches(hook.MediaServerID) {
+		h.manager.release(hook.StreamID, RtpServerTimeout)
+	}
+	return h.HookHandler.OnRtpServerTimeout(ctx, hook)
+}
+
+// OnServerStarted 服务器重启后所有RTP接收端口都已关闭
+func (h *rtpManagerHookHandler) OnServerStarted(ctx context.Context, hook *OnServerStartedHook) error {
+	if h.matches(hook.MediaServerID()) {
+		h.manager.releaseAll(RtpServerRestarted)
+	}
+	return h.HookHandler.OnServerStarted(ctx, hook)
+}
+
+// matches 事件是否来自管理器对应的节点
+func (h *rtpManagerHookHandler) matches(mediaServerID string) bool {
+	serverID := h.manager.config.MediaServerID
+	return serverID == "" || serverID == mediaServerID
+}
@@ -0,0 +1,154 @@
+package zlmedia_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+// closeRecorder 记录OnClose回调，格式为流id/原因
+type closeRecorder struct {
+	mu     sync.Mutex
+	closed []string
+}
+
+func (r *closeRecorder) onClose(lease zlmedia.RtpLease, reason zlmedia.RtpCloseReason) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = append(r.closed, fmt.Sprintf("%s/%s", lease.StreamID, reason))
+}
+
+func (r *closeRecorder) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return strings.Join(r.closed, ",")
+}
+
+// openOrphan 不经过管理器直接创建RTP接收端口，模拟进程重启前创建的端口
+func openOrphan(t *testing.T, zlm *zlmtest.Server, streamID string) {
+	t.Helper()
+	if _, err := zlmedia.NewRTPAPI(zlm.Client()).OpenRtpServer(context.Background(), &zlmedia.OpenRtpServerRequest{StreamID: streamID}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRtpServerManagerSync(t *testing.T) {
+	tests := []struct {
+		name       string
+		duringSync func(ctx context.Context, hooks zlmedia.HookHandler) error // 同步期间收到的hook事件
+		wantLeases string
+		wantClosed string
+	}{
+		{"接管未知端口并删除不存在的端口", nil, "orphan:,owned:owner", "gone/gone"},
+		{"同步期间有租约的端口超时", func(ctx context.Context, hooks zlmedia.HookHandler) error {
+			return hooks.OnRtpServerTimeout(ctx, &zlmedia.OnRtpServerTimeoutHook{StreamID: "owned"})
+		}, "orphan:", "owned/timeout,gone/gone"},
+		{"同步期间未知端口超时", func(ctx context.Context, hooks zlmedia.HookHandler) error {
+			return hooks.OnRtpServerTimeout(ctx, &zlmedia.OnRtpServerTimeoutHook{StreamID: "orphan"})
+		}, "owned:owner", "gone/gone"},
+		{"同步期间服务器重启", func(ctx context.Context, hooks zlmedia.HookHandler) error {
+			return hooks.OnServerStarted(ctx, &zlmedia.OnServerStartedHook{})
+		}, "", "gone/server_restarted,owned/server_restarted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm := zlmtest.NewServer()
+			defer zlm.Close()
+			recorder := &closeRecorder{}
+			manager := zlmedia.NewRtpServerManager(zlm.Client(), zlmedia.RtpServerManagerConfig{OnClose: recorder.onClose})
+			hooks := manager.HookHandler(nil)
+			ctx := context.Background()
+
+			// gone有租约但ZLMediaKit中已不存在，orphan在ZLMediaKit中存在但没有租约，owned两边都存在
+			if _, err := manager.Open(ctx, &zlmedia.OpenRtpServerRequest{StreamID: "gone"}, "owner", 0); err != nil {
+				t.Fatal(err)
+			}
+			zlm.Restart()
+			openOrphan(t, zlm, "orphan")
+			if _, err := manager.Open(ctx, &zlmedia.OpenRtpServerRequest{StreamID: "owned"}, "owner", 0); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.duringSync != nil {
+				zlm.InjectFault("/index/api/listRtpServer", zlmtest.Fault{Delay: 50 * time.Millisecond, Times: 1})
+			}
+			done := make(chan error, 1)
+			go func() { done <- manager.Sync(ctx) }()
+			if tt.duringSync != nil {
+				for zlm.RequestCount("/index/api/listRtpServer") == 0 {
+					time.Sleep(time.Millisecond)
+				}
+				if err := tt.duringSync(ctx, hooks); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+
+			var leases []string
+			for _, lease := range manager.Leases() {
+				leases = append(leases, lease.StreamID+":"+lease.Owner)
+			}
+			if got := strings.Join(leases, ","); got != tt.wantLeases {
+				t.Errorf("Leases() = %q, want %q", got, tt.wantLeases)
+			}
+			if got := recorder.String(); got != tt.wantClosed {
+				t.Errorf("OnClose = %q, want %q", got, tt.wantClosed)
+			}
+		})
+	}
+}
+
+func TestRtpServerManagerCheck(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	recorder := &closeRecorder{}
+	manager := zlmedia.NewRtpServerManager(zlm.Client(), zlmedia.RtpServerManagerConfig{
+		ArrivalTimeout: time.Nanosecond,
+		OnClose:        recorder.onClose,
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		streamID string
+		ttl      time.Duration
+		arrived  bool
+		want     bool // Check后租约是否保留
+	}{
+		{"expired", time.Nanosecond, true, false},
+		{"arrived", time.Hour, true, true},
+		{"not-arrived", time.Hour, false, false},
+		{"forever", 0, true, true},
+	}
+	for _, tt := range tests {
+		if _, err := manager.Open(ctx, &zlmedia.OpenRtpServerRequest{StreamID: tt.streamID}, "owner", tt.ttl); err != nil {
+			t.Fatal(err)
+		}
+		if tt.arrived {
+			zlm.PublishStream(zlmedia.StreamKey{VHost: zlmedia.DefaultVHost, App: "rtp", Stream: tt.streamID}, zlmtest.OriginTypeRtpPush, "rtsp")
+		}
+	}
+	time.Sleep(time.Millisecond)
+
+	if err := manager.Check(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if _, ok := manager.Lease(tt.streamID); ok != tt.want {
+			t.Errorf("Lease(%s) = %v, want %v", tt.streamID, ok, tt.want)
+		}
+	}
+	if got, want := recorder.String(), "expired/expired,not-arrived/not_arrived"; got != want {
+		t.Errorf("OnClose = %q, want %q", got, want)
+	}
+	if got := len(zlm.RtpServers()); got != 2 {
+		t.Errorf("len(RtpServers()) = %d, want 2", got)
+	}
+}
@@ -0,0 +1,729 @@
+package zlmedia
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSDP sdp格式错误或不满足WebRTC要求
+var ErrInvalidSDP = errors.New("sdp不合法")
+
+// SDPDirection 媒体方向
+type SDPDirection string
+
+// 媒体方向
+const (
+	SDPSendRecv SDPDirection = "sendrecv" // 收发，未指定方向时的默认值
+	SDPSendOnly SDPDirection = "sendonly" // 只发送
+	SDPRecvOnly SDPDirection = "recvonly" // 只接收
+	SDPInactive SDPDirection = "inactive" // 不收发
+)
+
+// sends 方向是否包含发送
+func (d SDPDirection) sends() bool {
+	return d == SDPSendRecv || d == SDPSendOnly
+}
+
+// receives 方向是否包含接收
+func (d SDPDirection) receives() bool {
+	return d == SDPSendRecv || d == SDPRecvOnly
+}
+
+// SDPField sdp中除v=、o=、s=、m=、a=以外的一行，例如c=、b=、t=
+type SDPField struct {
+	Type  byte   // 行类型，例如'c'
+	Value string // 等号后的内容
+}
+
+// SDPAttribute sdp中的a=属性行
+type SDPAttribute struct {
+	Key   string // 属性名，例如rtpmap
+	Value string // 冒号后的属性值，标志属性(例如a=rtcp-mux)为空
+}
+
+// String 返回a=后的内容
+func (a SDPAttribute) String() string {
+	if a.Value == "" {
+		return a.Key
+	}
+	return a.Key + ":" + a.Value
+}
+
+// SDPAttributes 属性列表，保持原有顺序
+type SDPAttributes []SDPAttribute
+
+// Get 获取第一个指定名称的属性值
+// 返回: 属性值，属性不存在时返回false
+func (attrs SDPAttributes) Get(key string) (string, bool) {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// Values 获取所有指定名称的属性值
+func (attrs SDPAttributes) Values(key string) []string {
+	var values []string
+	for _, attr := range attrs {
+		if attr.Key == key {
+			values = append(values, attr.Value)
+		}
+	}
+	return values
+}
+
+// Set 设置属性值，替换第一个同名属性并删除其余同名属性，不存在时追加到末尾
+func (attrs *SDPAttributes) Set(key, value string) {
+	attrs.replace(func(attr SDPAttribute) bool { return attr.Key == key }, SDPAttribute{Key: key, Value: value})
+}
+
+// Delete 删除所有指定名称的属性
+func (attrs *SDPAttributes) Delete(key string) {
+	attrs.filter(func(attr SDPAttribute) bool { return attr.Key != key })
+}
+
+// replace 将第一个match返回true的属性替换为attr并删除其余匹配的属性，没有匹配时追加到末尾
+func (attrs *SDPAttributes) replace(match func(SDPAttribute) bool, attr SDPAttribute) {
+	replaced := false
+	kept := (*attrs)[:0]
+	for _, other := range *attrs {
+		if match(other) {
+			if replaced {
+				continue
+			}
+			other, replaced = attr, true
+		}
+		kept = append(kept, other)
+	}
+	if !replaced {
+		kept = append(kept, attr)
+	}
+	*attrs = kept
+}
+
+// filter 保留keep返回true的属性
+func (attrs *SDPAttributes) filter(keep func(SDPAttribute) bool) {
+	kept := (*attrs)[:0]
+	for _, attr := range *attrs {
+		if keep(attr) {
+			kept = append(kept, attr)
+		}
+	}
+	*attrs = kept
+}
+
+// SessionDescription sdp会话描述
+// 未建模的行会保存在Fields和Attributes中，解析后再序列化不会丢失内容
+type SessionDescription struct {
+	Version     int                 // v=，固定为0
+	Origin      string              // o=，例如"- 0 0 IN IP4 127.0.0.1"
+	SessionName string              // s=
+	Fields      []SDPField          // 会话级别的其他行，例如c=、b=、t=，按原顺序保存
+	Attributes  SDPAttributes       // 会话级别的a=属性
+	Media       []*MediaDescription // 媒体段
+}
+
+// MediaDescription sdp媒体段
+type MediaDescription struct {
+	Media      string        // 媒体类型，例如audio、video、application
+	Port       int           // 端口，0表示拒绝该媒体段
+	PortCount  int           // 端口数量，m=行中未指定时为0
+	Proto      string        // 传输协议，例如UDP/TLS/RTP/SAVPF
+	Formats    []string      // 格式列表，rtp媒体为payload type
+	Fields     []SDPField    // 媒体级别的其他行，例如c=、b=
+	Attributes SDPAttributes // 媒体级别的a=属性
+}
+
+// SDPCodec 媒体段中的编码格式
+type SDPCodec struct {
+	PayloadType  int      // payload type
+	Name         string   // 编码名称，例如H264、opus、rtx
+	ClockRate    int      // 时钟频率，例如90000
+	Channels     int      // 声道数，未指定时为0
+	Fmtp         string   // a=fmtp参数，例如"level-asymmetry-allowed=1;packetization-mode=1"
+	RTCPFeedback []string // a=rtcp-fb参数，例如"nack pli"
+}
+
+// staticPayloadTypes 没有rtpmap时使用的静态payload type，见RFC 3551
+var staticPayloadTypes = map[int]SDPCodec{
+	0: {PayloadType: 0, Name: "PCMU", ClockRate: 8000, Channels: 1},
+	8: {PayloadType: 8, Name: "PCMA", ClockRate: 8000, Channels: 1},
+	9: {PayloadType: 9, Name: "G722", ClockRate: 8000, Channels: 1},
+}
+
+// SDPFingerprint dtls证书指纹
+type SDPFingerprint struct {
+	Hash  string // 哈希算法，例如sha-256
+	Value string // 冒号分隔的十六进制指纹
+}
+
+// ICECandidate ice候选地址
+type ICECandidate struct {
+	Foundation     string   // 标识
+	Component      int      // 组件，1为rtp，2为rtcp
+	Transport      string   // 传输协议，udp或tcp
+	Priority       uint32   // 优先级
+	Address        string   // 地址，可以是ip或mdns主机名
+	Port           int      // 端口
+	Type           string   // 类型，host、srflx、prflx或relay
+	RelatedAddress string   // raddr，可以为空
+	RelatedPort    int      // rport
+	Extensions     []string // 其他扩展参数，按"名称 值"依次保存，例如tcptype passive
+}
+
+// ParseSDP 解析sdp
+// 参数:
+//   - raw: sdp内容，行分隔符可以是\r\n或\n
+//
+// 返回: 会话描述，格式错误时返回ErrInvalidSDP
+func ParseSDP(raw string) (*SessionDescription, error) {
+	sdp := &SessionDescription{}
+	var media *MediaDescription
+	seenVersion := false
+
+	for i, line := range strings.Split(raw, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		if len(line) < 2 || line[1] != '=' || line[0] < 'a' || line[0] > 'z' {
+			return nil, fmt.Errorf("%w: 第%d行格式错误: %q", ErrInvalidSDP, i+1, line)
+		}
+		typ, value := line[0], line[2:]
+		if !seenVersion && typ != 'v' {
+			return nil, fmt.Errorf("%w: 第一行需要为v=", ErrInvalidSDP)
+		}
+
+		switch {
+		case typ == 'v':
+			if seenVersion {
+				return nil, fmt.Errorf("%w: 第%d行重复的v=", ErrInvalidSDP, i+1)
+			}
+			version, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: 版本号错误: %q", ErrInvalidSDP, value)
+			}
+			sdp.Version = version
+			seenVersion = true
+		case typ == 'm':
+			m, err := parseMediaLine(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: 第%d行: %v", ErrInvalidSDP, i+1, err)
+			}
+			media = m
+			sdp.Media = append(sdp.Media, media)
+		case typ == 'a':
+			key, value, _ := strings.Cut(value, ":")
+			attr := SDPAttribute{Key: key, Value: value}
+			if media != nil {
+				media.Attributes = append(media.Attributes, attr)
+			} else {
+				sdp.Attributes = append(sdp.Attributes, attr)
+			}
+		case media != nil:
+			media.Fields = append(media.Fields, SDPField{Type: typ, Value: value})
+		case typ == 'o':
+			sdp.Origin = value
+		case typ == 's':
+			sdp.SessionName = value
+		default:
+			sdp.Fields = append(sdp.Fields, SDPField{Type: typ, Value: value})
+		}
+	}
+
+	if !seenVersion {
+		return nil, fmt.Errorf("%w: sdp为空", ErrInvalidSDP)
+	}
+	return sdp, nil
+}
+
+// parseMediaLine 解析m=行，格式为"<media> <port>[/<count>] <proto> <fmt>..."
+func parseMediaLine(value string) (*MediaDescription, error) {
+	parts := strings.Fields(value)
+	if len(parts) < 3 {
+		return nil, fmt.Errorf("m=行格式错误: %q", value)
+	}
+
+	portValue, countValue, hasCount := strings.Cut(parts[1], "/")
+	port, err := strconv.Atoi(portValue)
+	if err != nil {
+		return nil, fmt.Errorf("m=行端口错误: %q", parts[1])
+	}
+	media := &MediaDescription{Media: parts[0], Port: port, Proto: parts[2], Formats: parts[3:]}
+	if hasCount {
+		if media.PortCount, err = strconv.Atoi(countValue); err != nil {
+			return nil, fmt.Errorf("m=行端口数量错误: %q", parts[1])
+		}
+	}
+	return media, nil
+}
+
+// Marshal 序列化为sdp，行分隔符为\r\n
+func (s *SessionDescription) Marshal() string {
+	var b strings.Builder
+	writeLine := func(typ byte, value string) {
+		b.WriteByte(typ)
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteString("\r\n")
+	}
+
+	writeLine('v', strconv.Itoa(s.Version))
+	writeLine('o', s.Origin)
+	writeLine('s', s.SessionName)
+	for _, field := range s.Fields {
+		writeLine(field.Type, field.Value)
+	}
+	for _, attr := range s.Attributes {
+		writeLine('a', attr.String())
+	}
+
+	for _, m := range s.Media {
+		port := strconv.Itoa(m.Port)
+		if m.PortCount > 0 {
+			port += "/" + strconv.Itoa(m.PortCount)
+		}
+		writeLine('m', strings.Join(append([]string{m.Media, port, m.Proto}, m.Formats...), " "))
+		for _, field := range m.Fields {
+			writeLine(field.Type, field.Value)
+		}
+		for _, attr := range m.Attributes {
+			writeLine('a', attr.String())
+		}
+	}
+	return b.String()
+}
+
+// String 实现fmt.Stringer，同Marshal
+func (s *SessionDescription) String() string {
+	return s.Marshal()
+}
+
+// Direction 获取会话级别的媒体方向，未指定时返回空
+func (s *SessionDescription) Direction() SDPDirection {
+	return attributesDirection(s.Attributes)
+}
+
+// MediaDirection 获取媒体段的实际方向，依次使用媒体级别、会话级别的方向，都未指定时为sendrecv
+func (s *SessionDescription) MediaDirection(m *MediaDescription) SDPDirection {
+	if direction := m.Direction(); direction != "" {
+		return direction
+	}
+	if direction := s.Direction(); direction != "" {
+		return direction
+	}
+	return SDPSendRecv
+}
+
+// Fingerprint 获取媒体段的dtls证书指纹，媒体段未指定时使用会话级别的指纹
+func (s *SessionDescription) Fingerprint(m *MediaDescription) (SDPFingerprint, bool) {
+	if fingerprint, ok := m.Fingerprint(); ok {
+		return fingerprint, true
+	}
+	return attributesFingerprint(s.Attributes)
+}
+
+// ICECredentials 获取媒体段的ice-ufrag和ice-pwd，媒体段未指定时使用会话级别的值
+func (s *SessionDescription) ICECredentials(m *MediaDescription) (ufrag, pwd string) {
+	ufrag, ok := m.Attributes.Get("ice-ufrag")
+	if !ok {
+		ufrag, _ = s.Attributes.Get("ice-ufrag")
+	}
+	pwd, ok = m.Attributes.Get("ice-pwd")
+	if !ok {
+		pwd, _ = s.Attributes.Get("ice-pwd")
+	}
+	return ufrag, pwd
+}
+
+// FilterCodecs 在所有rtp媒体段中只保留keep返回true的编码格式
+// 返回: 删除的编码格式数量
+func (s *SessionDescription) FilterCodecs(keep func(m *MediaDescription, codec SDPCodec) bool) int {
+	removed := 0
+	for _, m := range s.Media {
+		removed += m.FilterCodecs(func(codec SDPCodec) bool { return keep(m, codec) })
+	}
+	return removed
+}
+
+// RewriteCandidates 修改所有媒体段中的ice候选地址，例如将内网地址替换为NAT映射后的公网地址
+// 参数:
+//   - rewrite: 可以直接修改候选地址，返回false时删除该候选地址
+func (s *SessionDescription) RewriteCandidates(rewrite func(m *MediaDescription, candidate *ICECandidate) bool) error {
+	for _, m := range s.Media {
+		err := m.RewriteCandidates(func(candidate *ICECandidate) bool { return rewrite(m, candidate) })
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateOffer 检查offer是否满足ZLMediaKit WebRTC的要求
+// 检查版本号、传输协议、ice-ufrag/ice-pwd、dtls证书指纹、编码格式，
+// 以及api为push时至少有一个发送的音视频媒体段，为play时至少有一个接收的音视频媒体段
+// 参数:
+//   - api: push(兼容publish)或play，为空或echo时不检查媒体方向
+//
+// 返回: 不满足要求时返回ErrInvalidSDP
+func (s *SessionDescription) ValidateOffer(api string) error {
+	if s.Version != 0 {
+		return fmt.Errorf("%w: 不支持的版本号%d", ErrInvalidSDP, s.Version)
+	}
+	if s.Origin == "" {
+		return fmt.Errorf("%w: 缺少o=", ErrInvalidSDP)
+	}
+	if len(s.Media) == 0 {
+		return fmt.Errorf("%w: 没有媒体段", ErrInvalidSDP)
+	}
+
+	sending, receiving := false, false
+	for i, m := range s.Media {
+		if m.Port == 0 {
+			if _, bundleOnly := m.Attributes.Get("bundle-only"); !bundleOnly {
+				continue // 被拒绝的媒体段
+			}
+		}
+
+		name := fmt.Sprintf("第%d个媒体段(%s)", i+1, m.Media)
+		if !strings.Contains(m.Proto, "SAVPF") && !strings.Contains(m.Proto, "DTLS/SCTP") {
+			return fmt.Errorf("%w: %s的传输协议%s不是WebRTC支持的协议", ErrInvalidSDP, name, m.Proto)
+		}
+		if ufrag, pwd := s.ICECredentials(m); ufrag == "" || pwd == "" {
+			return fmt.Errorf("%w: %s缺少ice-ufrag或ice-pwd", ErrInvalidSDP, name)
+		}
+		if _, ok := s.Fingerprint(m); !ok {
+			return fmt.Errorf("%w: %s缺少fingerprint", ErrInvalidSDP, name)
+		}
+		if len(m.Formats) == 0 {
+			return fmt.Errorf("%w: %s没有编码格式", ErrInvalidSDP, name)
+		}
+		if _, err := m.Candidates(); err != nil {
+			return err
+		}
+
+		if m.isRTP() {
+			direction := s.MediaDirection(m)
+			sending = sending || direction.sends()
+			receiving = receiving || direction.receives()
+		}
+	}
+
+	switch {
+	case (api == WebRTCTypePush || api == "publish") && !sending:
+		return fmt.Errorf("%w: 推流的offer需要至少一个sendonly或sendrecv的音视频媒体段", ErrInvalidSDP)
+	case api == WebRTCTypePlay && !receiving:
+		return fmt.Errorf("%w: 播放的offer需要至少一个recvonly或sendrecv的音视频媒体段", ErrInvalidSDP)
+	}
+	return nil
+}
+
+// isRTP 是否为rtp音视频媒体段
+func (m *MediaDescription) isRTP() bool {
+	return (m.Media == "audio" || m.Media == "video") && strings.Contains(m.Proto, "RTP")
+}
+
+// MID 获取媒体段的a=mid
+func (m *MediaDescription) MID() string {
+	mid, _ := m.Attributes.Get("mid")
+	return mid
+}
+
+// Direction 获取媒体段级别的媒体方向，未指定时返回空
+func (m *MediaDescription) Direction() SDPDirection {
+	return attributesDirection(m.Attributes)
+}
+
+// SetDirection 设置媒体段的媒体方向
+func (m *MediaDescription) SetDirection(direction SDPDirection) {
+	m.Attributes.replace(func(attr SDPAttribute) bool { return isDirection(attr.Key) }, SDPAttribute{Key: string(direction)})
+}
+
+// Fingerprint 获取媒体段级别的dtls证书指纹
+func (m *MediaDescription) Fingerprint() (SDPFingerprint, bool) {
+	return attributesFingerprint(m.Attributes)
+}
+
+// Codecs 获取媒体段的编码格式，按m=行中的顺序返回
+func (m *MediaDescription) Codecs() []SDPCodec {
+	codecs := make([]SDPCodec, 0, len(m.Formats))
+	index := make(map[int]int, len(m.Formats))
+	for _, format := range m.Formats {
+		pt, err := strconv.Atoi(format)
+		if err != nil {
+			continue
+		}
+		codec, ok := staticPayloadTypes[pt]
+		if !ok {
+			codec = SDPCodec{PayloadType: pt}
+		}
+		index[pt] = len(codecs)
+		codecs = append(codecs, codec)
+	}
+
+	for _, attr := range m.Attributes {
+		pt, rest, ok := splitPayloadType(attr)
+		if !ok {
+			continue
+		}
+		i, ok := index[pt]
+		if !ok {
+			continue
+		}
+		codec := &codecs[i]
+		switch attr.Key {
+		case "rtpmap":
+			parts := strings.Split(rest, "/")
+			codec.Name = parts[0]
+			if len(parts) > 1 {
+				codec.ClockRate, _ = strconv.Atoi(parts[1])
+			}
+			codec.Channels = 0
+			if len(parts) > 2 {
+				codec.Channels, _ = strconv.Atoi(parts[2])
+			}
+		case "fmtp":
+			codec.Fmtp = rest
+		case "rtcp-fb":
+			codec.RTCPFeedback = append(codec.RTCPFeedback, rest)
+		}
+	}
+	return codecs
+}
+
+// FilterCodecs 只保留keep返回true的编码格式，同时删除对应的rtpmap、fmtp、rtcp-fb属性，
+// 以及apt指向被删除格式的重传(rtx)等格式
+// 返回: 删除的编码格式数量
+func (m *MediaDescription) FilterCodecs(keep func(codec SDPCodec) bool) int {
+	codecs := m.Codecs()
+	removed := make(map[int]bool)
+	for _, codec := range codecs {
+		if !keep(codec) {
+			removed[codec.PayloadType] = true
+		}
+	}
+
+	// rtx等格式依赖apt指向的格式，被依赖的格式删除后一并删除
+	for changed := len(removed) > 0; changed; {
+		changed = false
+		for _, codec := range codecs {
+			if removed[codec.PayloadType] {
+				continue
+			}
+			if apt, ok := fmtpParam(codec.Fmtp, "apt"); ok {
+				if pt, err := strconv.Atoi(apt); err == nil && removed[pt] {
+					removed[codec.PayloadType] = true
+					changed = true
+				}
+			}
+		}
+	}
+	if len(removed) == 0 {
+		return 0
+	}
+
+	formats := m.Formats[:0]
+	for _, format := range m.Formats {
+		if pt, err := strconv.Atoi(format); err != nil || !removed[pt] {
+			formats = append(formats, format)
+		}
+	}
+	m.Formats = formats
+	m.Attributes.filter(func(attr SDPAttribute) bool {
+		pt, _, ok := splitPayloadType(attr)
+		return !ok || !removed[pt]
+	})
+	return len(removed)
+}
+
+// Candidates 获取媒体段的ice候选地址
+func (m *MediaDescription) Candidates() ([]ICECandidate, error) {
+	var candidates []ICECandidate
+	for _, value := range m.Attributes.Values("candidate") {
+		candidate, err := ParseICECandidate(value)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// SetCandidates 替换媒体段的所有ice候选地址，新的候选地址写在原第一个候选地址的位置
+func (m *MediaDescription) SetCandidates(candidates []ICECandidate) {
+	position := len(m.Attributes)
+	for i, attr := range m.Attributes {
+		if attr.Key == "candidate" {
+			position = i
+			break
+		}
+	}
+
+	attrs := make(SDPAttributes, 0, len(m.Attributes)+len(candidates))
+	for i, attr := range m.Attributes {
+		if i == position {
+			attrs = appendCandidates(attrs, candidates)
+		}
+		if attr.Key != "candidate" {
+			attrs = append(attrs, attr)
+		}
+	}
+	if position == len(m.Attributes) {
+		attrs = appendCandidates(attrs, candidates)
+	}
+	m.Attributes = attrs
+}
+
+// RewriteCandidates 修改媒体段的ice候选地址
+// 参数:
+//   - rewrite: 可以直接修改候选地址，返回false时删除该候选地址
+func (m *MediaDescription) RewriteCandidates(rewrite func(candidate *ICECandidate) bool) error {
+	candidates, err := m.Candidates()
+	if err != nil {
+		return err
+	}
+
+	kept := candidates[:0]
+	for i := range candidates {
+		if rewrite(&candidates[i]) {
+			kept = append(kept, candidates[i])
+		}
+	}
+	m.SetCandidates(kept)
+	return nil
+}
+
+// ParseICECandidate 解析ice候选地址
+// 参数:
+//   - value: a=candidate:后的内容，也可以带"candidate:"前缀，例如"1 1 udp 2130706431 10.0.0.1 8000 typ host"
+//
+// 返回: 候选地址，格式错误时返回ErrInvalidSDP
+func ParseICECandidate(value string) (ICECandidate, error) {
+	parts := strings.Fields(strings.TrimPrefix(value, "candidate:"))
+	if len(parts) < 8 || parts[6] != "typ" {
+		return ICECandidate{}, fmt.Errorf("%w: candidate格式错误: %q", ErrInvalidSDP, value)
+	}
+
+	component, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return ICECandidate{}, fmt.Errorf("%w: candidate组件错误: %q", ErrInvalidSDP, value)
+	}
+	priority, err := strconv.ParseUint(parts[3], 10, 32)
+	if err != nil {
+		return ICECandidate{}, fmt.Errorf("%w: candidate优先级错误: %q", ErrInvalidSDP, value)
+	}
+	port, err := strconv.Atoi(parts[5])
+	if err != nil {
+		return ICECandidate{}, fmt.Errorf("%w: candidate端口错误: %q", ErrInvalidSDP, value)
+	}
+	candidate := ICECandidate{
+		Foundation: parts[0],
+		Component:  component,
+		Transport:  parts[2],
+		Priority:   uint32(priority),
+		Address:    parts[4],
+		Port:       port,
+		Type:       parts[7],
+	}
+
+	rest := parts[8:]
+	for i := 0; i < len(rest); i += 2 {
+		if i+1 >= len(rest) {
+			return ICECandidate{}, fmt.Errorf("%w: candidate扩展参数%q缺少值", ErrInvalidSDP, rest[i])
+		}
+		switch rest[i] {
+		case "raddr":
+			candidate.RelatedAddress = rest[i+1]
+		case "rport":
+			if candidate.RelatedPort, err = strconv.Atoi(rest[i+1]); err != nil {
+				return ICECandidate{}, fmt.Errorf("%w: candidate rport错误: %q", ErrInvalidSDP, value)
+			}
+		default:
+			candidate.Extensions = append(candidate.Extensions, rest[i], rest[i+1])
+		}
+	}
+	return candidate, nil
+}
+
+// String 返回a=candidate:后的内容
+func (c ICECandidate) String() string {
+	parts := []string{
+		c.Foundation, strconv.Itoa(c.Component), c.Transport, strconv.FormatUint(uint64(c.Priority), 10),
+		c.Address, strconv.Itoa(c.Port), "typ", c.Type,
+	}
+	if c.RelatedAddress != "" {
+		parts = append(parts, "raddr", c.RelatedAddress, "rport", strconv.Itoa(c.RelatedPort))
+	}
+	return strings.Join(append(parts, c.Extensions...), " ")
+}
+
+// appendCandidates 将候选地址追加为a=candidate属性
+func appendCandidates(attrs SDPAttributes, candidates []ICECandidate) SDPAttributes {
+	for _, candidate := range candidates {
+		attrs = append(attrs, SDPAttribute{Key: "candidate", Value: candidate.String()})
+	}
+	return attrs
+}
+
+// attributesDirection 获取属性中的媒体方向
+func attributesDirection(attrs SDPAttributes) SDPDirection {
+	for _, attr := range attrs {
+		if isDirection(attr.Key) {
+			return SDPDirection(attr.Key)
+		}
+	}
+	return ""
+}
+
+// isDirection 属性名是否为媒体方向
+func isDirection(key string) bool {
+	switch SDPDirection(key) {
+	case SDPSendRecv, SDPSendOnly, SDPRecvOnly, SDPInactive:
+		return true
+	default:
+		return false
+	}
+}
+
+// attributesFingerprint 获取属性中的dtls证书指纹
+func attributesFingerprint(attrs SDPAttributes) (SDPFingerprint, bool) {
+	value, ok := attrs.Get("fingerprint")
+	if !ok {
+		return SDPFingerprint{}, false
+	}
+	hash, fingerprint, ok := strings.Cut(value, " ")
+	if !ok || fingerprint == "" {
+		return SDPFingerprint{}, false
+	}
+	return SDPFingerprint{Hash: hash, Value: fingerprint}, true
+}
+
+// splitPayloadType 拆分rtpmap、fmtp、rtcp-fb属性值中的payload type，例如"96 H264/90000"
+func splitPayloadType(attr SDPAttribute) (int, string, bool) {
+	switch attr.Key {
+	case "rtpmap", "fmtp", "rtcp-fb":
+	default:
+		return 0, "", false
+	}
+	ptValue, rest, _ := strings.Cut(attr.Value, " ")
+	pt, err := strconv.Atoi(ptValue)
+	if err != nil {
+		return 0, "", false
+	}
+	return pt, rest, true
+}
+
+// fmtpParam 获取fmtp中的参数，例如"apt=96"中的apt
+func fmtpParam(fmtp, name string) (string, bool) {
+	for _, param := range strings.Split(fmtp, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if ok && key == name {
+			return value, true
+		}
+	}
+	return "", false
+}
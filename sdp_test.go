@@ -0,0 +1,250 @@
+package zlmedia_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+func TestParseSDP(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		media   int
+		wantErr bool
+	}{
+		{"webrtc offer", testOffer("sendonly"), 1, false},
+		{"\\n换行", strings.ReplaceAll(testOffer("sendonly"), "\r\n", "\n"), 1, false},
+		{"只有会话级别", "v=0\r\no=- 0 0 IN IP4 0.0.0.0\r\ns=-\r\nt=0 0\r\n", 0, false},
+		{"空sdp", "", 0, true},
+		{"第一行不是v=", "o=- 0 0 IN IP4 0.0.0.0\r\nv=0\r\n", 0, true},
+		{"重复的v=", "v=0\r\nv=0\r\n", 0, true},
+		{"版本号错误", "v=x\r\n", 0, true},
+		{"行格式错误", "v=0\r\nbad line\r\n", 0, true},
+		{"m=行错误", "v=0\r\nm=video\r\n", 0, true},
+		{"m=行端口错误", "v=0\r\nm=video x UDP/TLS/RTP/SAVPF 96\r\n", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sdp, err := zlmedia.ParseSDP(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, zlmedia.ErrInvalidSDP) {
+					t.Fatalf("ParseSDP() error = %v, want ErrInvalidSDP", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSDP() error = %v", err)
+			}
+			if len(sdp.Media) != tt.media {
+				t.Errorf("len(Media) = %d, want %d", len(sdp.Media), tt.media)
+			}
+
+			// 序列化后再解析内容不变
+			again, err := zlmedia.ParseSDP(sdp.Marshal())
+			if err != nil {
+				t.Fatalf("ParseSDP(Marshal()) error = %v", err)
+			}
+			if again.Marshal() != sdp.Marshal() {
+				t.Errorf("Marshal() 不稳定:\n%s\n%s", sdp.Marshal(), again.Marshal())
+			}
+		})
+	}
+}
+
+func TestSDPRoundTrip(t *testing.T) {
+	raw := testOffer("sendonly")
+	sdp, err := zlmedia.ParseSDP(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sdp.Marshal(); got != raw {
+		t.Errorf("Marshal() = %q, want %q", got, raw)
+	}
+}
+
+func TestValidateOffer(t *testing.T) {
+	replace := func(old, new string) string {
+		return strings.Replace(testOffer("sendrecv"), old, new, 1)
+	}
+
+	tests := []struct {
+		name    string
+		sdp     string
+		api     string
+		wantErr bool
+	}{
+		{"推流sendonly", testOffer("sendonly"), zlmedia.WebRTCTypePush, false},
+		{"兼容publish", testOffer("sendonly"), "publish", false},
+		{"播放recvonly", testOffer("recvonly"), zlmedia.WebRTCTypePlay, false},
+		{"sendrecv都可以", testOffer("sendrecv"), zlmedia.WebRTCTypePlay, false},
+		{"echo不检查方向", testOffer("inactive"), zlmedia.WebRTCTypeEcho, false},
+		{"推流recvonly", testOffer("recvonly"), zlmedia.WebRTCTypePush, true},
+		{"播放sendonly", testOffer("sendonly"), zlmedia.WebRTCTypePlay, true},
+		{"传输协议不是WebRTC", replace("UDP/TLS/RTP/SAVPF", "RTP/AVP"), "", true},
+		{"缺少ice-pwd", replace("a=ice-pwd:abcdabcdabcdabcdabcdabcd\r\n", ""), "", true},
+		{"缺少ice", replace("a=ice-ufrag:abcd\r\na=ice-pwd:abcdabcdabcdabcdabcdabcd\r\n", ""), "", true},
+		{"缺少fingerprint", replace("a=fingerprint:sha-256 00:11:22:33\r\n", ""), "", true},
+		{"没有编码格式", replace("m=video 9 UDP/TLS/RTP/SAVPF 96", "m=video 9 UDP/TLS/RTP/SAVPF"), "", true},
+		{"候选地址错误", replace("a=rtpmap", "a=candidate:bad\r\na=rtpmap"), "", true},
+		{"被拒绝的媒体段不检查", replace("m=video 9 UDP/TLS/RTP/SAVPF 96", "m=video 0 RTP/AVP 96") + "m=audio 9 UDP/TLS/RTP/SAVPF 111\r\na=ice-ufrag:a\r\na=ice-pwd:b\r\na=fingerprint:sha-256 00\r\n", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sdp, err := zlmedia.ParseSDP(tt.sdp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = sdp.ValidateOffer(tt.api)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateOffer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, zlmedia.ErrInvalidSDP) {
+				t.Errorf("ValidateOffer() error = %v, want ErrInvalidSDP", err)
+			}
+		})
+	}
+}
+
+func TestFilterCodecs(t *testing.T) {
+	raw := strings.Join([]string{
+		"v=0", "o=- 0 0 IN IP4 0.0.0.0", "s=-",
+		"m=video 9 UDP/TLS/RTP/SAVPF 96 97 98 99",
+		"a=rtpmap:96 VP8/90000",
+		"a=rtpmap:97 rtx/90000",
+		"a=fmtp:97 apt=96",
+		"a=rtpmap:98 H264/90000",
+		"a=fmtp:98 packetization-mode=1",
+		"a=rtcp-fb:98 nack pli",
+		"a=rtpmap:99 rtx/90000",
+		"a=fmtp:99 apt=98",
+		"",
+	}, "\r\n")
+
+	tests := []struct {
+		name    string
+		keep    string
+		removed int
+		formats string
+	}{
+		{"删除VP8及其rtx", "H264", 2, "98 99"},
+		{"删除H264及其rtx", "VP8", 2, "96 97"},
+		{"全部保留", "", 0, "96 97 98 99"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sdp, err := zlmedia.ParseSDP(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			removed := sdp.Media[0].FilterCodecs(func(codec zlmedia.SDPCodec) bool {
+				return tt.keep == "" || codec.Name == tt.keep || codec.Name == "rtx"
+			})
+			if removed != tt.removed {
+				t.Errorf("FilterCodecs() = %d, want %d", removed, tt.removed)
+			}
+			if got := strings.Join(sdp.Media[0].Formats, " "); got != tt.formats {
+				t.Errorf("Formats = %q, want %q", got, tt.formats)
+			}
+			for _, codec := range sdp.Media[0].Codecs() {
+				if codec.Name == "" {
+					t.Errorf("编码格式%d缺少rtpmap", codec.PayloadType)
+				}
+			}
+		})
+	}
+}
+
+func TestParseICECandidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    zlmedia.ICECandidate
+		wantErr bool
+	}{
+		{"host", "1 1 udp 2130706431 10.0.0.1 8000 typ host",
+			zlmedia.ICECandidate{Foundation: "1", Component: 1, Transport: "udp", Priority: 2130706431, Address: "10.0.0.1", Port: 8000, Type: "host"}, false},
+		{"带前缀和raddr", "candidate:2 1 tcp 1518280447 203.0.113.1 8000 typ srflx raddr 10.0.0.1 rport 9000 tcptype passive",
+			zlmedia.ICECandidate{Foundation: "2", Component: 1, Transport: "tcp", Priority: 1518280447, Address: "203.0.113.1", Port: 8000, Type: "srflx",
+				RelatedAddress: "10.0.0.1", RelatedPort: 9000, Extensions: []string{"tcptype", "passive"}}, false},
+		{"字段不足", "1 1 udp", zlmedia.ICECandidate{}, true},
+		{"端口错误", "1 1 udp 1 10.0.0.1 x typ host", zlmedia.ICECandidate{}, true},
+		{"扩展参数缺少值", "1 1 udp 1 10.0.0.1 8000 typ host generation", zlmedia.ICECandidate{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := zlmedia.ParseICECandidate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseICECandidate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.String() != tt.want.String() {
+				t.Errorf("ParseICECandidate() = %q, want %q", got.String(), tt.want.String())
+			}
+			if again, _ := zlmedia.ParseICECandidate(got.String()); again.String() != got.String() {
+				t.Errorf("String() 不稳定: %q", got.String())
+			}
+		})
+	}
+}
+
+func TestNegotiateAnswer(t *testing.T) {
+	const answer = "v=0\r\no=- 0 0 IN IP4 0.0.0.0\r\ns=-\r\n"
+
+	tests := []struct {
+		name       string
+		code       int
+		id         string
+		sdp        string
+		wantErr    error
+		wantStatus int
+	}{
+		{"正常answer", 0, "rtc-1", answer, nil, http.StatusCreated},
+		{"answer为空", 0, "rtc-1", "", zlmedia.ErrInvalidAnswer, http.StatusBadGateway},
+		{"answer格式错误", 0, "rtc-1", "garbage", zlmedia.ErrInvalidAnswer, http.StatusBadGateway},
+		{"缺少会话id", 0, "", answer, zlmedia.ErrInvalidAnswer, http.StatusBadGateway},
+		{"鉴权失败", zlmedia.CodeAuthFailed, "", "", zlmedia.ErrAuthFailed, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 同一个结果分别以/index/api/webrtc的JSON和/index/api/whip的sdp格式返回
+			stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/index/api/webrtc" || tt.code != 0 {
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(zlmedia.WebRTCResponse{Status: zlmedia.Status{Code: tt.code}, ID: tt.id, SDP: tt.sdp, Type: "answer"})
+					return
+				}
+				w.Header().Set("Location", "/index/api/delete_webrtc?id="+tt.id+"&token=t")
+				w.Header().Set("Content-Type", "application/sdp")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(tt.sdp))
+			}))
+			defer stub.Close()
+			client := zlmedia.NewClient(zlmedia.Config{BaseURL: stub.URL})
+
+			_, err := zlmedia.NewWebRTCAPI(client).Negotiate(context.Background(), &zlmedia.WebRTCRequest{
+				Type: zlmedia.WebRTCTypePush, SDP: testOffer("sendonly"), App: "live", Stream: "test",
+			})
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Negotiate() error = %v, want %v", err, tt.wantErr)
+			}
+			if errors.Is(err, zlmedia.ErrInvalidSDP) {
+				t.Errorf("answer错误不能被当作offer错误: %v", err)
+			}
+
+			server := httptest.NewServer(zlmedia.NewWHIPHandler(client, zlmedia.WebRTCHandlerConfig{PathPrefix: "/whip/"}))
+			defer server.Close()
+			if resp := sendWebRTC(t, server, http.MethodPost, "/whip/live/test", testOffer("sendonly")); resp.StatusCode != tt.wantStatus {
+				t.Errorf("WHIP status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -15,11 +15,6 @@ func NewServerAPI(client *Client) *ServerAPI {
 	return &ServerAPI{client: client}
 }
 
-// GetServerAPI 获取服务器API实例
-func GetServerAPI() *ServerAPI {
-	return NewServerAPI(GetClient())
-}
-
 // GetApiListRequest 获取服务器api列表请求参数
 type GetApiListRequest struct {
 	// 无额外参数，只需要secret
@@ -28,13 +23,19 @@ type GetApiListRequest struct {
 // GetApiList 获取服务器api列表
 // 获取ZLMediaKit支持的所有API接口列表
 // 返回: API接口列表信息
-func (s *ServerAPI) GetApiList(ctx context.Context, req *GetApiListRequest) (*BaseResponse, error) {
-	respBody, err := s.client.SendRequest(ctx, "GET", "/index/api/getApiList", nil)
+func (s *ServerAPI) GetApiList(ctx context.Context, req *GetApiListRequest) (*Response[[]string], error) {
+	resp, err := doRequest[Response[[]string]](ctx, s.client, "GET", "/index/api/getApiList", nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取API列表失败: %w", err)
+		return resp, fmt.Errorf("获取API列表失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
+}
+
+// ThreadLoad 线程负载信息
+type ThreadLoad struct {
+	Delay int `json:"delay"` // 该线程延时，单位毫秒
+	Load  int `json:"load"`  // 该线程负载，0 ~ 100
 }
 
 // GetThreadsLoadRequest 获取网络线程负载请求参数
@@ -45,13 +46,13 @@ type GetThreadsLoadRequest struct {
 // GetThreadsLoad 获取网络线程负载
 // 获取ZLMediaKit网络线程的负载情况
 // 返回: 网络线程负载信息
-func (s *ServerAPI) GetThreadsLoad(ctx context.Context, req *GetThreadsLoadRequest) (*BaseResponse, error) {
-	respBody, err := s.client.SendRequest(ctx, "GET", "/index/api/getThreadsLoad", nil)
+func (s *ServerAPI) GetThreadsLoad(ctx context.Context, req *GetThreadsLoadRequest) (*Response[[]ThreadLoad], error) {
+	resp, err := doRequest[Response[[]ThreadLoad]](ctx, s.client, "GET", "/index/api/getThreadsLoad", nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取网络线程负载失败: %w", err)
+		return resp, fmt.Errorf("获取网络线程负载失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // GetStatisticRequest 获取主要对象个数请求参数
@@ -59,16 +60,36 @@ type GetStatisticRequest struct {
 	// 无额外参数，只需要secret
 }
 
+// Statistic 主要对象个数统计
+type Statistic struct {
+	Buffer                int `json:"Buffer"`
+	BufferLikeString      int `json:"BufferLikeString"`
+	BufferList            int `json:"BufferList"`
+	BufferRaw             int `json:"BufferRaw"`
+	Frame                 int `json:"Frame"`
+	FrameImp              int `json:"FrameImp"`
+	MediaSource           int `json:"MediaSource"`           // 流媒体源个数
+	MultiMediaSourceMuxer int `json:"MultiMediaSourceMuxer"` // 协议复用器个数
+	RtmpPacket            int `json:"RtmpPacket"`
+	RtpPacket             int `json:"RtpPacket"`
+	Socket                int `json:"Socket"`
+	TcpClient             int `json:"TcpClient"`
+	TcpServer             int `json:"TcpServer"`
+	TcpSession            int `json:"TcpSession"` // tcp会话个数
+	UdpServer             int `json:"UdpServer"`
+	UdpSession            int `json:"UdpSession"` // udp会话个数
+}
+
 // GetStatistic 获取主要对象个数
 // 获取ZLMediaKit中主要对象的统计信息，如流的数量等
 // 返回: 主要对象统计信息
-func (s *ServerAPI) GetStatistic(ctx context.Context, req *GetStatisticRequest) (*BaseResponse, error) {
-	respBody, err := s.client.SendRequest(ctx, "GET", "/index/api/getStatistic", nil)
+func (s *ServerAPI) GetStatistic(ctx context.Context, req *GetStatisticRequest) (*Response[Statistic], error) {
+	resp, err := doRequest[Response[Statistic]](ctx, s.client, "GET", "/index/api/getStatistic", nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取统计信息失败: %w", err)
+		return resp, fmt.Errorf("获取统计信息失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // GetWorkThreadsLoadRequest 获取后台线程负载请求参数
@@ -79,13 +100,13 @@ type GetWorkThreadsLoadRequest struct {
 // GetWorkThreadsLoad 获取后台线程负载
 // 获取ZLMediaKit后台工作线程的负载情况
 // 返回: 后台线程负载信息
-func (s *ServerAPI) GetWorkThreadsLoad(ctx context.Context, req *GetWorkThreadsLoadRequest) (*BaseResponse, error) {
-	respBody, err := s.client.SendRequest(ctx, "GET", "/index/api/getWorkThreadsLoad", nil)
+func (s *ServerAPI) GetWorkThreadsLoad(ctx context.Context, req *GetWorkThreadsLoadRequest) (*Response[[]ThreadLoad], error) {
+	resp, err := doRequest[Response[[]ThreadLoad]](ctx, s.client, "GET", "/index/api/getWorkThreadsLoad", nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取后台线程负载失败: %w", err)
+		return resp, fmt.Errorf("获取后台线程负载失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // GetServerConfigRequest 获取服务器配置请求参数
@@ -96,13 +117,13 @@ type GetServerConfigRequest struct {
 // GetServerConfig 获取服务器配置
 // 获取ZLMediaKit的完整配置信息
 // 返回: 服务器配置信息
-func (s *ServerAPI) GetServerConfig(ctx context.Context, req *GetServerConfigRequest) (*BaseResponse, error) {
-	respBody, err := s.client.SendRequest(ctx, "GET", "/index/api/getServerConfig", nil)
+func (s *ServerAPI) GetServerConfig(ctx context.Context, req *GetServerConfigRequest) (*Response[[]map[string]string], error) {
+	resp, err := doRequest[Response[[]map[string]string]](ctx, s.client, "GET", "/index/api/getServerConfig", nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取服务器配置失败: %w", err)
+		return resp, fmt.Errorf("获取服务器配置失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // SetServerConfigRequest 设置服务器配置请求参数
@@ -112,24 +133,30 @@ type SetServerConfigRequest struct {
 	Config map[string]string `json:"config"`
 }
 
+// SetServerConfigResponse 设置服务器配置响应
+type SetServerConfigResponse struct {
+	Status
+	Changed int `json:"changed"` // 配置项变更个数
+}
+
 // SetServerConfig 设置服务器配置
 // 动态修改ZLMediaKit的配置项
 // 参数:
 //   - Config: 配置项映射，键为"section.key"格式，值为配置值
 //
 // 返回: 设置结果
-func (s *ServerAPI) SetServerConfig(ctx context.Context, req *SetServerConfigRequest) (*BaseResponse, error) {
+func (s *ServerAPI) SetServerConfig(ctx context.Context, req *SetServerConfigRequest) (*SetServerConfigResponse, error) {
 	params := make(map[string]interface{})
 	for key, value := range req.Config {
 		params[key] = value
 	}
 
-	respBody, err := s.client.SendRequest(ctx, "GET", "/index/api/setServerConfig", params)
+	resp, err := doRequest[SetServerConfigResponse](ctx, s.client, "GET", "/index/api/setServerConfig", params)
 	if err != nil {
-		return nil, fmt.Errorf("设置服务器配置失败: %w", err)
+		return resp, fmt.Errorf("设置服务器配置失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // RestartServerRequest 重启服务器请求参数
@@ -140,11 +167,11 @@ type RestartServerRequest struct {
 // RestartServer 重启服务器
 // 重启ZLMediaKit服务器，注意这会中断所有正在进行的流
 // 返回: 重启结果
-func (s *ServerAPI) RestartServer(ctx context.Context, req *RestartServerRequest) (*BaseResponse, error) {
-	respBody, err := s.client.SendRequest(ctx, "GET", "/index/api/restartServer", nil)
+func (s *ServerAPI) RestartServer(ctx context.Context, req *RestartServerRequest) (*Status, error) {
+	resp, err := doRequest[Status](ctx, s.client, "GET", "/index/api/restartServer", nil)
 	if err != nil {
-		return nil, fmt.Errorf("重启服务器失败: %w", err)
+		return resp, fmt.Errorf("重启服务器失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
@@ -15,9 +15,14 @@ func NewSessionAPI(client *Client) *SessionAPI {
 	return &SessionAPI{client: client}
 }
 
-// GetSessionAPI 获取会话API实例
-func GetSessionAPI() *SessionAPI {
-	return NewSessionAPI(GetClient())
+// SessionInfo tcp会话信息
+type SessionInfo struct {
+	ID        string `json:"id"`         // 该tcp链接唯一id
+	LocalIP   string `json:"local_ip"`   // 本机网卡ip
+	LocalPort int    `json:"local_port"` // 本机端口号
+	PeerIP    string `json:"peer_ip"`    // 客户端ip
+	PeerPort  int    `json:"peer_port"`  // 客户端端口号
+	TypeID    string `json:"typeid"`     // 客户端TCPSession typeid
 }
 
 // GetAllSessionRequest 获取Session列表请求参数
@@ -33,7 +38,7 @@ type GetAllSessionRequest struct {
 //   - PeerIP: 筛选客户端ip
 //
 // 返回: Session列表信息
-func (s *SessionAPI) GetAllSession(ctx context.Context, req *GetAllSessionRequest) (*BaseResponse, error) {
+func (s *SessionAPI) GetAllSession(ctx context.Context, req *GetAllSessionRequest) (*Response[[]SessionInfo], error) {
 	params := make(map[string]interface{})
 	if req.LocalPort != nil {
 		params["local_port"] = *req.LocalPort
@@ -42,12 +47,12 @@ func (s *SessionAPI) GetAllSession(ctx context.Context, req *GetAllSessionReques
 		params["peer_ip"] = req.PeerIP
 	}
 
-	respBody, err := s.client.SendRequest(ctx, "GET", "/index/api/getAllSession", params)
+	resp, err := doRequest[Response[[]SessionInfo]](ctx, s.client, "GET", "/index/api/getAllSession", params)
 	if err != nil {
-		return nil, fmt.Errorf("获取Session列表失败: %w", err)
+		return resp, fmt.Errorf("获取Session列表失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // KickSessionRequest 断开tcp连接请求参数
@@ -61,17 +66,17 @@ type KickSessionRequest struct {
 //   - ID: 客户端唯一id，可以通过getAllSession接口获取
 //
 // 返回: 断开连接结果
-func (s *SessionAPI) KickSession(ctx context.Context, req *KickSessionRequest) (*BaseResponse, error) {
+func (s *SessionAPI) KickSession(ctx context.Context, req *KickSessionRequest) (*Status, error) {
 	params := map[string]interface{}{
 		"id": req.ID,
 	}
 
-	respBody, err := s.client.SendRequest(ctx, "GET", "/index/api/kick_session", params)
+	resp, err := doRequest[Status](ctx, s.client, "GET", "/index/api/kick_session", params)
 	if err != nil {
-		return nil, fmt.Errorf("断开连接失败: %w", err)
+		return resp, fmt.Errorf("断开连接失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
 
 // KickSessionsRequest 批量断开tcp连接请求参数
@@ -80,6 +85,12 @@ type KickSessionsRequest struct {
 	PeerIP    string `json:"peer_ip,omitempty"`    // 筛选客户端ip
 }
 
+// KickSessionsResponse 批量断开tcp连接响应
+type KickSessionsResponse struct {
+	Status
+	CountHit int `json:"count_hit"` // 筛选命中客户端个数
+}
+
 // KickSessions 批量断开tcp连接
 // 批量断开符合条件的TCP连接会话
 // 参数:
@@ -87,7 +98,7 @@ type KickSessionsRequest struct {
 //   - PeerIP: 筛选客户端ip
 //
 // 返回: 批量断开连接结果
-func (s *SessionAPI) KickSessions(ctx context.Context, req *KickSessionsRequest) (*BaseResponse, error) {
+func (s *SessionAPI) KickSessions(ctx context.Context, req *KickSessionsRequest) (*KickSessionsResponse, error) {
 	params := make(map[string]interface{})
 	if req.LocalPort != nil {
 		params["local_port"] = *req.LocalPort
@@ -96,10 +107,10 @@ func (s *SessionAPI) KickSessions(ctx context.Context, req *KickSessionsRequest)
 		params["peer_ip"] = req.PeerIP
 	}
 
-	respBody, err := s.client.SendRequest(ctx, "GET", "/index/api/kick_sessions", params)
+	resp, err := doRequest[KickSessionsResponse](ctx, s.client, "GET", "/index/api/kick_sessions", params)
 	if err != nil {
-		return nil, fmt.Errorf("批量断开连接失败: %w", err)
+		return resp, fmt.Errorf("批量断开连接失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
 }
@@ -0,0 +1,126 @@
+package zlmedia
+
+import (
+	"errors"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrNoAvailableNode 没有可用的节点
+var ErrNoAvailableNode = errors.New("没有可用的ZLMediaKit节点")
+
+// Strategy 节点选择策略
+// nodes为当前所有健康节点的负载信息，按节点ID排序，且至少包含一个节点
+type Strategy interface {
+	Pick(key StreamKey, nodes []NodeLoad) (string, error)
+}
+
+// LeastLoadStrategy 最小负载策略，选择Score最低的节点
+type LeastLoadStrategy struct{}
+
+// Pick 选择负载最低的节点，负载相同时选择流数量较少的节点
+func (LeastLoadStrategy) Pick(key StreamKey, nodes []NodeLoad) (string, error) {
+	if len(nodes) == 0 {
+		return "", ErrNoAvailableNode
+	}
+
+	best := nodes[0]
+	bestScore := best.Score()
+	for _, node := range nodes[1:] {
+		score := node.Score()
+		if score < bestScore || (score == bestScore && node.Statistic.MediaSource < best.Statistic.MediaSource) {
+			best, bestScore = node, score
+		}
+	}
+	return best.NodeID, nil
+}
+
+// RoundRobinStrategy 轮询策略，依次选择每个节点
+type RoundRobinStrategy struct {
+	next atomic.Uint64
+}
+
+// NewRoundRobinStrategy 创建轮询策略
+func NewRoundRobinStrategy() *RoundRobinStrategy {
+	return &RoundRobinStrategy{}
+}
+
+// Pick 按顺序选择下一个节点
+func (s *RoundRobinStrategy) Pick(key StreamKey, nodes []NodeLoad) (string, error) {
+	if len(nodes) == 0 {
+		return "", ErrNoAvailableNode
+	}
+
+	n := s.next.Add(1) - 1
+	return nodes[n%uint64(len(nodes))].NodeID, nil
+}
+
+// ConsistentHashStrategy 一致性哈希策略，按vhost/app/stream将同一个流固定分配到同一个节点
+// 节点增减时只有少部分流会被重新分配
+type ConsistentHashStrategy struct {
+	replicas int
+
+	mu      sync.Mutex
+	ringKey string
+	hashes  []uint32
+	owners  map[uint32]string
+}
+
+// NewConsistentHashStrategy 创建一致性哈希策略
+// replicas为每个节点在哈希环上的虚拟节点数，<=0时默认为160
+func NewConsistentHashStrategy(replicas int) *ConsistentHashStrategy {
+	if replicas <= 0 {
+		replicas = 160
+	}
+	return &ConsistentHashStrategy{replicas: replicas}
+}
+
+// Pick 选择哈希环上顺时针方向离流最近的节点
+func (s *ConsistentHashStrategy) Pick(key StreamKey, nodes []NodeLoad) (string, error) {
+	if len(nodes) == 0 {
+		return "", ErrNoAvailableNode
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buildRing(nodes)
+
+	hash := crc32.ChecksumIEEE([]byte(key.String()))
+	i := sort.Search(len(s.hashes), func(i int) bool { return s.hashes[i] >= hash })
+	if i == len(s.hashes) {
+		i = 0
+	}
+	return s.owners[s.hashes[i]], nil
+}
+
+// buildRing 节点集合变化时重建哈希环
+func (s *ConsistentHashStrategy) buildRing(nodes []NodeLoad) {
+	ids := make([]string, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.NodeID
+	}
+	ringKey := strings.Join(ids, "\n")
+	if ringKey == s.ringKey && s.owners != nil {
+		return
+	}
+
+	s.ringKey = ringKey
+	s.hashes = make([]uint32, 0, len(ids)*s.replicas)
+	s.owners = make(map[uint32]string, len(ids)*s.replicas)
+	for _, id := range ids {
+		for r := 0; r < s.replicas; r++ {
+			hash := crc32.ChecksumIEEE([]byte(id + "#" + strconv.Itoa(r)))
+			if _, ok := s.owners[hash]; ok {
+				continue
+			}
+			s.owners[hash] = id
+			s.hashes = append(s.hashes, hash)
+		}
+	}
+	sort.Slice(s.hashes, func(i, j int) bool { return s.hashes[i] < s.hashes[j] })
+}
@@ -0,0 +1,47 @@
+package zlmedia
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DefaultVHost ZLMediaKit默认虚拟主机
+const DefaultVHost = "__defaultVhost__"
+
+// StreamKey 流的唯一标识，由虚拟主机、应用名和流id组成
+type StreamKey struct {
+	VHost  string `json:"vhost"`  // 虚拟主机，例如__defaultVhost__，为空时视为默认虚拟主机
+	App    string `json:"app"`    // 应用名，例如live
+	Stream string `json:"stream"` // 流id，例如test
+}
+
+// NormalizedVHost 获取虚拟主机，为空时返回DefaultVHost
+func (k StreamKey) NormalizedVHost() string {
+	if k.VHost == "" {
+		return DefaultVHost
+	}
+	return k.VHost
+}
+
+// String 返回vhost/app/stream格式的字符串
+func (k StreamKey) String() string {
+	return fmt.Sprintf("%s/%s/%s", k.NormalizedVHost(), k.App, k.Stream)
+}
+
+// Normalize 返回虚拟主机为空时替换为DefaultVHost的StreamKey，可用作map的key
+func (k StreamKey) Normalize() StreamKey {
+	k.VHost = k.NormalizedVHost()
+	return k
+}
+
+// sortedStreamKeys 获取map的key并按vhost/app/stream排序，用于产生稳定的顺序
+func sortedStreamKeys[V any](m map[StreamKey]V) []StreamKey {
+	keys := make([]StreamKey, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
@@ -0,0 +1,81 @@
+package zlmedia
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName OpenTelemetry tracer名称
+const tracerName = "github.com/edwardpan/zlmedia_restapi_go"
+
+// 追踪span的属性名称
+const (
+	AttrEndpoint   = attribute.Key("zlmedia.endpoint")    // 接口路径，例如/index/api/getMediaList
+	AttrVHost      = attribute.Key("zlmedia.vhost")       // 虚拟主机
+	AttrApp        = attribute.Key("zlmedia.app")         // 应用名
+	AttrStream     = attribute.Key("zlmedia.stream")      // 流id
+	AttrStreamID   = attribute.Key("zlmedia.stream_id")   // RTP流id
+	AttrCode       = attribute.Key("zlmedia.code")        // ZLMediaKit返回的错误代码
+	AttrHTTPStatus = attribute.Key("zlmedia.http_status") // HTTP状态码，仅在不是2xx时设置
+	AttrDuration   = attribute.Key("zlmedia.duration_ms") // 请求耗时，单位毫秒
+	AttrBaseURL    = attribute.Key("zlmedia.base_url")    // ZLMediaKit API的基础URL
+)
+
+// newTracer 根据配置创建tracer，未配置TracerProvider时使用otel全局TracerProvider
+func newTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(tracerName)
+}
+
+// startSpan 为API调用创建span，ctx中的追踪信息作为父span
+func (c *Client) startSpan(ctx context.Context, method, path string, params map[string]interface{}) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		AttrEndpoint.String(path),
+		AttrBaseURL.String(c.config.BaseURL),
+		attribute.String("http.request.method", method),
+	}
+	for key, attr := range map[string]attribute.Key{
+		"vhost":     AttrVHost,
+		"app":       AttrApp,
+		"stream":    AttrStream,
+		"stream_id": AttrStreamID,
+	} {
+		if value, ok := params[key].(string); ok && value != "" {
+			attrs = append(attrs, attr.String(value))
+		}
+	}
+
+	return c.tracer.Start(ctx, "ZLMediaKit "+path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endSpan 记录API调用结果并结束span
+// 成功时错误代码为0，未能获取到ZLMediaKit响应时不记录错误代码
+func endSpan(span trace.Span, start time.Time, err error) {
+	span.SetAttributes(AttrDuration.Int64(time.Since(start).Milliseconds()))
+
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		span.SetAttributes(AttrCode.Int(CodeSuccess))
+	case errors.As(err, &apiErr) && apiErr.HTTPStatus != 0:
+		span.SetAttributes(AttrHTTPStatus.Int(apiErr.HTTPStatus))
+	case errors.As(err, &apiErr):
+		span.SetAttributes(AttrCode.Int(apiErr.Code))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,110 @@
+package zlmedia_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+func TestTracing(t *testing.T) {
+	tests := []struct {
+		name       string
+		fault      *zlmtest.Fault
+		retry      bool
+		wantStatus codes.Code
+		wantAttrs  map[attribute.Key]attribute.Value // 为Value{}时代表不应设置该属性
+	}{
+		{"成功", nil, false, codes.Unset, map[attribute.Key]attribute.Value{
+			zlmedia.AttrCode:       attribute.IntValue(zlmedia.CodeSuccess),
+			zlmedia.AttrHTTPStatus: {},
+		}},
+		{"ZLMediaKit返回错误代码", &zlmtest.Fault{Code: zlmedia.CodeException, Msg: "boom"}, false, codes.Error, map[attribute.Key]attribute.Value{
+			zlmedia.AttrCode:       attribute.IntValue(zlmedia.CodeException),
+			zlmedia.AttrHTTPStatus: {},
+		}},
+		{"HTTP错误", &zlmtest.Fault{HTTPStatus: http.StatusBadGateway}, false, codes.Error, map[attribute.Key]attribute.Value{
+			zlmedia.AttrCode:       {},
+			zlmedia.AttrHTTPStatus: attribute.IntValue(http.StatusBadGateway),
+		}},
+		{"重试只产生一个span", &zlmtest.Fault{HTTPStatus: http.StatusBadGateway, Times: 1}, true, codes.Unset, map[attribute.Key]attribute.Value{
+			zlmedia.AttrCode: attribute.IntValue(zlmedia.CodeSuccess),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm := zlmtest.NewServer()
+			defer zlm.Close()
+			if tt.fault != nil {
+				zlm.InjectFault("/index/api/getMediaList", *tt.fault)
+			}
+
+			exporter := tracetest.NewInMemoryExporter()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+			defer provider.Shutdown(context.Background())
+			config := zlm.Config()
+			config.TracerProvider = provider
+			if tt.retry {
+				config.RetryPolicy = &zlmedia.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+			}
+			client := zlmedia.NewClient(config)
+
+			// 调用方的span作为API调用span的父span
+			ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+			_, _ = zlmedia.NewMediaAPI(client).GetMediaList(ctx, &zlmedia.GetMediaListRequest{App: "live", Stream: "test"})
+			parent.End()
+
+			spans := exporter.GetSpans()
+			if len(spans) != 2 {
+				t.Fatalf("len(spans) = %d, want 2", len(spans))
+			}
+			span := spans[0]
+			if span.Name != "ZLMediaKit /index/api/getMediaList" || span.SpanKind != trace.SpanKindClient {
+				t.Errorf("span = %s/%s, want ZLMediaKit /index/api/getMediaList/client", span.Name, span.SpanKind)
+			}
+			if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+				t.Errorf("父span = %s, want %s", span.Parent.SpanID(), parent.SpanContext().SpanID())
+			}
+			if span.Status.Code != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", span.Status.Code, tt.wantStatus)
+			}
+
+			attrs := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+			for _, attr := range span.Attributes {
+				attrs[attr.Key] = attr.Value
+			}
+			want := map[attribute.Key]attribute.Value{
+				zlmedia.AttrEndpoint: attribute.StringValue("/index/api/getMediaList"),
+				zlmedia.AttrApp:      attribute.StringValue("live"),
+				zlmedia.AttrStream:   attribute.StringValue("test"),
+				zlmedia.AttrBaseURL:  attribute.StringValue(zlm.URL()),
+				zlmedia.AttrVHost:    {},
+			}
+			for key, value := range tt.wantAttrs {
+				want[key] = value
+			}
+			for key, value := range want {
+				got, ok := attrs[key]
+				if value.Type() == attribute.INVALID {
+					if ok {
+						t.Errorf("%s = %v, want unset", key, got.Emit())
+					}
+				} else if !ok || got != value {
+					t.Errorf("%s = %v, want %v", key, got.Emit(), value.Emit())
+				}
+			}
+			if _, ok := attrs[zlmedia.AttrDuration]; !ok {
+				t.Errorf("%s未设置", zlmedia.AttrDuration)
+			}
+		})
+	}
+}
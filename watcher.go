@@ -0,0 +1,370 @@
+package zlmedia
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StreamEventType 流事件类型
+type StreamEventType string
+
+// 流事件类型
+const (
+	StreamOnline       StreamEventType = "online"               // 流上线，第一个协议注册
+	StreamOffline      StreamEventType = "offline"              // 流下线，所有协议注销
+	ReaderCountChanged StreamEventType = "reader_count_changed" // 观看总人数变化
+)
+
+// StreamEventSource 流事件来源
+type StreamEventSource string
+
+// 流事件来源
+const (
+	EventSourceHook StreamEventSource = "hook" // 来自on_stream_changed事件
+	EventSourcePoll StreamEventSource = "poll" // 来自getMediaList轮询
+)
+
+// StreamEvent 流生命周期事件
+type StreamEvent struct {
+	Type            StreamEventType   // 事件类型
+	Key             StreamKey         // 流的唯一标识，虚拟主机已规范化
+	Schemas         []string          // 事件发生后流已注册的协议，按名称排序
+	ReaderCount     int               // 事件发生后的观看总人数
+	PrevReaderCount int               // 事件发生前的观看总人数
+	Media           *MediaInfo        // 触发事件的流信息，流下线时为nil
+	Source          StreamEventSource // 事件来源
+	Time            time.Time         // 事件产生的时间
+}
+
+// WatcherConfig 流监视器配置
+type WatcherConfig struct {
+	PollInterval time.Duration   // getMediaList轮询间隔，默认为10秒
+	OnError      func(err error) // Run中轮询失败时调用，可以为nil
+}
+
+// watchedStream 监视中的流
+type watchedStream struct {
+	medias      map[string]MediaInfo // key为协议
+	readerCount int
+	hookAt      time.Time // 最近一次由hook更新的时间
+}
+
+// schemas 获取已注册的协议，按名称排序
+func (s *watchedStream) schemas() []string {
+	schemas := make([]string, 0, len(s.medias))
+	for schema := range s.medias {
+		schemas = append(schemas, schema)
+	}
+	sort.Strings(schemas)
+	return schemas
+}
+
+// watcherSubscription 事件订阅
+type watcherSubscription struct {
+	ch   chan StreamEvent
+	done chan struct{}
+	once sync.Once
+}
+
+// Watcher 流生命周期监视器
+// 合并on_stream_changed事件与定期的getMediaList轮询结果，产生流上线、下线和观看人数变化事件，
+// 错过的hook事件会在下一次轮询时补齐
+type Watcher struct {
+	client *Client
+	config WatcherConfig
+
+	mu         sync.Mutex
+	streams    map[StreamKey]*watchedStream
+	removed    map[StreamKey]time.Time // 由hook删除的流及删除时间，避免进行中的轮询把流重新加回来
+	refreshing int                     // 进行中的轮询数量
+	queue      []StreamEvent           // 等待投递的事件，按产生顺序排列
+	delivering bool                    // 是否有调用方正在投递事件
+
+	subMu sync.RWMutex
+	subs  map[*watcherSubscription]struct{}
+}
+
+// NewWatcher 创建流监视器
+func NewWatcher(client *Client, config WatcherConfig) *Watcher {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 10 * time.Second
+	}
+
+	return &Watcher{
+		client:  client,
+		config:  config,
+		streams: make(map[StreamKey]*watchedStream),
+		removed: make(map[StreamKey]time.Time),
+		subs:    make(map[*watcherSubscription]struct{}),
+	}
+}
+
+// Subscribe 订阅流事件
+// 事件按产生顺序投递，投递时不持有监视器的锁，订阅者可以在读取事件时调用Online等方法；
+// 订阅者读取过慢时，正在投递事件的调用方会被阻塞，其它调用方产生的事件在队列中等待，buffer为通道的缓冲大小
+// 返回: 事件通道和取消订阅函数，取消订阅后通道会被关闭
+func (w *Watcher) Subscribe(buffer int) (<-chan StreamEvent, func()) {
+	sub := &watcherSubscription{
+		ch:   make(chan StreamEvent, buffer),
+		done: make(chan struct{}),
+	}
+
+	w.subMu.Lock()
+	w.subs[sub] = struct{}{}
+	w.subMu.Unlock()
+
+	cancel := func() {
+		sub.once.Do(func() {
+			// 先通知正在投递的事件放弃，再移除订阅，避免与emit互相等待
+			close(sub.done)
+			w.subMu.Lock()
+			delete(w.subs, sub)
+			w.subMu.Unlock()
+			close(sub.ch)
+		})
+	}
+	return sub.ch, cancel
+}
+
+// enqueue 将事件加入投递队列，调用方需持有w.mu
+func (w *Watcher) enqueue(event StreamEvent) {
+	w.queue = append(w.queue, event)
+}
+
+// deliver 按产生顺序投递队列中的事件
+// 同一时间只有一个调用方投递，其它调用方加入队列后直接返回，投递时不持有w.mu
+func (w *Watcher) deliver() {
+	w.mu.Lock()
+	if w.delivering {
+		w.mu.Unlock()
+		return
+	}
+	w.delivering = true
+	for len(w.queue) > 0 {
+		events := w.queue
+		w.queue = nil
+		w.mu.Unlock()
+		for _, event := range events {
+			w.emit(event)
+		}
+		w.mu.Lock()
+	}
+	w.delivering = false
+	w.mu.Unlock()
+}
+
+// emit 向所有订阅者投递事件，只由deliver调用以保证事件顺序
+func (w *Watcher) emit(event StreamEvent) {
+	w.subMu.RLock()
+	defer w.subMu.RUnlock()
+
+	for sub := range w.subs {
+		select {
+		case sub.ch <- event:
+		case <-sub.done:
+		}
+	}
+}
+
+// Online 获取当前在线的流，按vhost/app/stream排序
+func (w *Watcher) Online() []StreamKey {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return sortedStreamKeys(w.streams)
+}
+
+// Run 立即轮询一次流列表，之后按PollInterval定期轮询，直到ctx结束
+// 轮询失败不会中断监视，错误通过OnError报告，等待下一次轮询
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Refresh(ctx); err != nil && ctx.Err() == nil && w.config.OnError != nil {
+			w.config.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh 轮询流列表，与当前状态比较并产生差异事件
+// 轮询期间收到hook事件的流以hook为准，不会被本次轮询结果覆盖，包括被hook删除的流
+func (w *Watcher) Refresh(ctx context.Context) error {
+	w.mu.Lock()
+	start := time.Now()
+	w.refreshing++
+	w.mu.Unlock()
+	defer w.endRefresh()
+
+	resp, err := NewMediaAPI(w.client).GetMediaList(ctx, &GetMediaListRequest{})
+	if err != nil {
+		return fmt.Errorf("轮询流列表失败: %w", err)
+	}
+
+	defer w.deliver()
+	current := make(map[StreamKey]map[string]MediaInfo)
+	for _, media := range resp.Data {
+		key := media.Key().Normalize()
+		if current[key] == nil {
+			current[key] = make(map[string]MediaInfo)
+		}
+		current[key][media.Schema] = media
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	for key, medias := range current {
+		stream, ok := w.streams[key]
+		if ok && stream.hookAt.After(start) || !ok && w.removed[key].After(start) {
+			continue
+		}
+		if !ok {
+			stream = &watchedStream{}
+			w.streams[key] = stream
+		}
+		prevReaderCount := stream.readerCount
+		stream.medias = medias
+		stream.readerCount = totalReaderCount(medias)
+
+		media := firstMedia(medias)
+		if !ok {
+			w.enqueue(w.newEvent(StreamOnline, key, stream, 0, &media, EventSourcePoll, now))
+		} else if stream.readerCount != prevReaderCount {
+			w.enqueue(w.newEvent(ReaderCountChanged, key, stream, prevReaderCount, &media, EventSourcePoll, now))
+		}
+	}
+
+	for key, stream := range w.streams {
+		if _, ok := current[key]; ok || stream.hookAt.After(start) {
+			continue
+		}
+		delete(w.streams, key)
+		w.enqueue(w.newEvent(StreamOffline, key, &watchedStream{}, stream.readerCount, nil, EventSourcePoll, now))
+	}
+	return nil
+}
+
+// endRefresh 结束轮询，没有进行中的轮询时删除记录不再需要
+func (w *Watcher) endRefresh() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.refreshing--
+	if w.refreshing == 0 {
+		clear(w.removed)
+	}
+}
+
+// HandleStreamChanged 处理on_stream_changed事件
+// 第一个协议注册时产生上线事件，最后一个协议注销时产生下线事件
+func (w *Watcher) HandleStreamChanged(hook *OnStreamChangedHook) {
+	key := hook.Key().Normalize()
+
+	defer w.deliver()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	stream, ok := w.streams[key]
+	if hook.Regist {
+		if !ok {
+			stream = &watchedStream{medias: make(map[string]MediaInfo)}
+			w.streams[key] = stream
+		}
+		stream.medias[hook.Schema] = hook.MediaInfo
+		stream.hookAt = now
+		delete(w.removed, key)
+		if !ok {
+			stream.readerCount = totalReaderCount(stream.medias)
+			w.enqueue(w.newEvent(StreamOnline, key, stream, 0, &hook.MediaInfo, EventSourceHook, now))
+		}
+		return
+	}
+
+	if !ok {
+		return
+	}
+	delete(stream.medias, hook.Schema)
+	stream.hookAt = now
+	if len(stream.medias) == 0 {
+		delete(w.streams, key)
+		if w.refreshing > 0 {
+			w.removed[key] = now
+		}
+		w.enqueue(w.newEvent(StreamOffline, key, stream, stream.readerCount, nil, EventSourceHook, now))
+	}
+}
+
+// newEvent 创建流事件
+func (w *Watcher) newEvent(eventType StreamEventType, key StreamKey, stream *watchedStream, prevReaderCount int, media *MediaInfo, source StreamEventSource, now time.Time) StreamEvent {
+	readerCount := stream.readerCount
+	if eventType == StreamOffline {
+		readerCount = 0
+	}
+	return StreamEvent{
+		Type:            eventType,
+		Key:             key,
+		Schemas:         stream.schemas(),
+		ReaderCount:     readerCount,
+		PrevReaderCount: prevReaderCount,
+		Media:           media,
+		Source:          source,
+		Time:            now,
+	}
+}
+
+// HookHandler 创建处理on_stream_changed事件的HookHandler
+// 事件先交给监视器处理，再调用next，next为nil时使用NopHookHandler
+func (w *Watcher) HookHandler(next HookHandler) HookHandler {
+	if next == nil {
+		next = NopHookHandler{}
+	}
+	return &watcherHookHandler{HookHandler: next, watcher: w}
+}
+
+// watcherHookHandler 将on_stream_changed事件转发给监视器的HookHandler
+type watcherHookHandler struct {
+	HookHandler
+	watcher *Watcher
+}
+
+// OnStreamChanged 处理流注册或注销事件
+func (h *watcherHookHandler) OnStreamChanged(ctx context.Context, hook *OnStreamChangedHook) error {
+	h.watcher.HandleStreamChanged(hook)
+	return h.HookHandler.OnStreamChanged(ctx, hook)
+}
+
+// totalReaderCount 获取流的观看总人数
+func totalReaderCount(medias map[string]MediaInfo) int {
+	total := 0
+	for _, media := range medias {
+		if media.TotalReaderCount > total {
+			total = media.TotalReaderCount
+		}
+	}
+	return total
+}
+
+// firstMedia 获取协议名称最小的流信息，用于产生稳定的事件
+func firstMedia(medias map[string]MediaInfo) MediaInfo {
+	var first MediaInfo
+	found := false
+	for schema, media := range medias {
+		if !found || schema < first.Schema {
+			first = media
+			found = true
+		}
+	}
+	return first
+}
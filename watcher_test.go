@@ -0,0 +1,186 @@
+package zlmedia_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+// streamChanged 生成on_stream_changed事件
+func streamChanged(key zlmedia.StreamKey, regist bool) *zlmedia.OnStreamChangedHook {
+	hook := &zlmedia.OnStreamChangedHook{Regist: regist}
+	hook.Schema, hook.App, hook.Stream = "rtmp", key.App, key.Stream
+	return hook
+}
+
+// drainEvents 读取通道中已有的事件，格式为type/source
+func drainEvents(events <-chan zlmedia.StreamEvent) string {
+	var got []string
+	for {
+		select {
+		case event := <-events:
+			got = append(got, fmt.Sprintf("%s/%s", event.Type, event.Source))
+		default:
+			return strings.Join(got, ",")
+		}
+	}
+}
+
+func TestWatcherRefresh(t *testing.T) {
+	key := zlmedia.StreamKey{App: "live", Stream: "test"}
+	regist, unregist := true, false
+
+	tests := []struct {
+		name       string
+		online     bool  // 轮询前监视器中流是否在线
+		published  bool  // 轮询结果中是否有流
+		duringPoll *bool // 轮询期间收到的hook事件
+		want       string
+		wantOnline int
+	}{
+		{"轮询补齐错过的上线", false, true, nil, "online/poll", 1},
+		{"轮询补齐错过的下线", true, false, nil, "offline/poll", 0},
+		{"状态一致", true, true, nil, "", 1},
+		{"轮询期间hook上线", false, false, &regist, "online/hook", 1},
+		{"轮询期间hook下线", true, true, &unregist, "offline/hook", 0},
+		{"轮询结果与hook上线重复", false, true, &regist, "online/hook", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zlm := zlmtest.NewServer()
+			defer zlm.Close()
+			watcher := zlmedia.NewWatcher(zlm.Client(), zlmedia.WatcherConfig{})
+			events, cancel := watcher.Subscribe(16)
+			defer cancel()
+
+			if tt.online {
+				watcher.HandleStreamChanged(streamChanged(key, true))
+				drainEvents(events)
+			}
+			if tt.published {
+				zlm.PublishStream(key, zlmtest.OriginTypeRtmpPush, "rtmp")
+			}
+
+			if tt.duringPoll != nil {
+				// 轮询结果在hook事件之前产生，到达时已经过期
+				zlm.InjectFault("/index/api/getMediaList", zlmtest.Fault{Delay: 50 * time.Millisecond, Times: 1})
+			}
+			done := make(chan error, 1)
+			go func() { done <- watcher.Refresh(context.Background()) }()
+			if tt.duringPoll != nil {
+				for zlm.RequestCount("/index/api/getMediaList") == 0 {
+					time.Sleep(time.Millisecond)
+				}
+				watcher.HandleStreamChanged(streamChanged(key, *tt.duringPoll))
+			}
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+
+			if got := drainEvents(events); got != tt.want {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+			if got := len(watcher.Online()); got != tt.wantOnline {
+				t.Errorf("len(Online()) = %d, want %d", got, tt.wantOnline)
+			}
+
+			// 下一次轮询以ZLMediaKit的最新状态为准，不会产生重复的事件
+			if tt.duringPoll != nil && *tt.duringPoll {
+				zlm.PublishStream(key, zlmtest.OriginTypeRtmpPush, "rtmp")
+			} else if tt.duringPoll != nil {
+				zlm.UnpublishStream(key)
+			}
+			if err := watcher.Refresh(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if got := drainEvents(events); got != "" {
+				t.Errorf("第二次轮询 events = %q, want none", got)
+			}
+		})
+	}
+}
+
+func TestWatcherSubscriberCallsOnline(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	for _, stream := range []string{"a", "b", "c"} {
+		zlm.PublishStream(zlmedia.StreamKey{App: "live", Stream: stream}, zlmtest.OriginTypeRtmpPush, "rtmp")
+	}
+	watcher := zlmedia.NewWatcher(zlm.Client(), zlmedia.WatcherConfig{})
+	events, cancel := watcher.Subscribe(0)
+	defer cancel()
+
+	// 订阅者在读取事件时查询在线的流，不能阻塞事件的投递
+	received := make(chan int, 1)
+	go func() {
+		n := 0
+		for range events {
+			n++
+			watcher.Online()
+			if n == 4 {
+				received <- n
+			}
+		}
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		if err := watcher.Refresh(context.Background()); err != nil {
+			done <- err
+			return
+		}
+		watcher.HandleStreamChanged(streamChanged(zlmedia.StreamKey{App: "live", Stream: "d"}, true))
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("订阅者调用Online()时监视器死锁")
+	}
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("订阅者没有收到全部事件")
+	}
+	if got := len(watcher.Online()); got != 4 {
+		t.Errorf("len(Online()) = %d, want 4", got)
+	}
+}
+
+func TestWatcherRunReportsErrors(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	zlm.InjectFault("/index/api/getMediaList", zlmtest.Fault{Code: zlmedia.CodeException, Msg: "boom", Times: 1})
+
+	errs := make(chan error, 1)
+	watcher := zlmedia.NewWatcher(zlm.Client(), zlmedia.WatcherConfig{
+		PollInterval: time.Hour,
+		OnError:      func(err error) { errs <- err },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- watcher.Run(ctx) }()
+
+	select {
+	case err := <-errs:
+		var apiErr *zlmedia.APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != zlmedia.CodeException {
+			t.Errorf("OnError(%v), want APIError with CodeException", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("轮询失败时没有调用OnError")
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+}
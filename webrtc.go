@@ -1,8 +1,15 @@
 package zlmedia
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
 // WebRTCAPI WebRTC管理相关API
@@ -15,11 +22,6 @@ func NewWebRTCAPI(client *Client) *WebRTCAPI {
 	return &WebRTCAPI{client: client}
 }
 
-// GetWebRTCAPI 获取WebRTC API实例
-func GetWebRTCAPI() *WebRTCAPI {
-	return NewWebRTCAPI(GetClient())
-}
-
 // GetWebRTCApiRequest 获取WebRTC API请求参数
 type GetWebRTCApiRequest struct {
 	// 无额外参数，只需要secret
@@ -29,58 +31,277 @@ type GetWebRTCApiRequest struct {
 // 获取WebRTC相关的API信息
 // 返回: WebRTC API信息
 func (w *WebRTCAPI) GetWebRTCApi(ctx context.Context, req *GetWebRTCApiRequest) (*BaseResponse, error) {
-	respBody, err := w.client.SendRequest(ctx, "GET", "/index/api/getWebRTCApi", nil)
+	resp, err := doRequest[BaseResponse](ctx, w.client, "GET", "/index/api/getWebRTCApi", nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取WebRTC API失败: %w", err)
+		return resp, fmt.Errorf("获取WebRTC API失败: %w", err)
 	}
 
-	return ParseResponse(respBody)
+	return resp, nil
+}
+
+// WebRTC会话类型，对应/index/api/webrtc的type参数
+const (
+	WebRTCTypePush = "push" // 推流
+	WebRTCTypePlay = "play" // 播放
+	WebRTCTypeEcho = "echo" // 回显测试
+)
+
+// ErrInvalidAnswer ZLMediaKit返回的会话id或answer不合法
+var ErrInvalidAnswer = errors.New("ZLMediaKit返回的answer不合法")
+
+// webrtcPath WebRTC offer/answer交换接口路径
+const webrtcPath = "/index/api/webrtc"
+
+// webrtcReservedParams 由请求字段设置的url参数，其他参数中的同名参数会被丢弃
+var webrtcReservedParams = map[string]bool{
+	"type": true, "vhost": true, "app": true, "stream": true, "secret": true,
 }
 
 // WebRTCRequest WebRTC请求参数
+// ZLMediaKit要求会话类型和流放在url参数中，offer sdp作为请求体
 type WebRTCRequest struct {
-	Api    string                 `json:"api"`              // WebRTC API类型，play或publish
-	Type   string                 `json:"type"`             // 候选者类型，answer或offer
-	SDP    string                 `json:"sdp"`              // SDP内容
+	Type   string                 `json:"type"`             // 会话类型，push、play或echo
+	Api    string                 `json:"api,omitempty"`    // Deprecated: 使用Type，Type为空或offer时使用该字段，publish等同于push
+	SDP    string                 `json:"sdp"`              // offer sdp
 	VHost  string                 `json:"vhost"`            // 虚拟主机，例如__defaultVhost__
 	App    string                 `json:"app"`              // 应用名，例如live
 	Stream string                 `json:"stream"`           // 流id，例如test
-	Params map[string]interface{} `json:"params,omitempty"` // 其他参数
+	Params map[string]interface{} `json:"params,omitempty"` // 其他url参数，会转发给on_publish/on_play鉴权，不能覆盖以上字段
+}
+
+// sessionType 获取会话类型，兼容旧的Api字段
+func (r *WebRTCRequest) sessionType() string {
+	if r.Type != "" && r.Type != "offer" {
+		return r.Type
+	}
+	if r.Api == "publish" {
+		return WebRTCTypePush
+	}
+	return r.Api
+}
+
+// query 构建url参数，其他参数中与固定字段同名的参数会被丢弃
+// 不包含secret，ZLMediaKit的WebRTC接口不校验secret，url参数会原样出现在hook事件的params中
+func (r *WebRTCRequest) query() url.Values {
+	values := url.Values{}
+	for k, v := range r.Params {
+		if v != nil && !webrtcReservedParams[k] {
+			values.Set(k, fmt.Sprintf("%v", v))
+		}
+	}
+	for k, v := range map[string]string{"vhost": r.VHost, "app": r.App, "stream": r.Stream} {
+		if v != "" {
+			values.Set(k, v)
+		}
+	}
+	values.Set("type", r.sessionType())
+	return values
+}
+
+// WebRTCResponse WebRTC响应
+type WebRTCResponse struct {
+	Status
+	ID   string `json:"id,omitempty"`   // 会话id
+	SDP  string `json:"sdp,omitempty"`  // answer sdp
+	Type string `json:"type,omitempty"` // sdp类型，一般为answer
 }
 
 // WebRTC WebRTC接口
 // 处理WebRTC的offer/answer交换
 // 参数:
-//   - Api: WebRTC API类型，play或publish
-//   - Type: 候选者类型，answer或offer
-//   - SDP: SDP内容
+//   - Type: 会话类型，push、play或echo
+//   - SDP: offer sdp
 //   - VHost: 虚拟主机，例如__defaultVhost__
 //   - App: 应用名，例如live
 //   - Stream: 流id，例如test
-//   - Params: 其他参数
+//   - Params: 其他url参数，不能覆盖以上字段
 //
 // 返回: WebRTC响应信息
-func (w *WebRTCAPI) WebRTC(ctx context.Context, req *WebRTCRequest) (*BaseResponse, error) {
-	params := map[string]interface{}{
-		"api":    req.Api,
-		"type":   req.Type,
-		"sdp":    req.SDP,
-		"vhost":  req.VHost,
-		"app":    req.App,
-		"stream": req.Stream,
-	}
-
-	// 添加其他参数
-	if req.Params != nil {
-		for k, v := range req.Params {
-			params[k] = v
+func (w *WebRTCAPI) WebRTC(ctx context.Context, req *WebRTCRequest) (resp *WebRTCResponse, err error) {
+	ctx, span := w.client.startSpan(ctx, "POST", webrtcPath, map[string]interface{}{"vhost": req.VHost, "app": req.App, "stream": req.Stream})
+	defer func(start time.Time) {
+		endSpan(span, start, err)
+	}(time.Now())
+
+	_, respBody, err := w.postOffer(ctx, webrtcPath, req.query(), req.SDP, "application/json")
+	if err != nil {
+		return nil, fmt.Errorf("WebRTC请求失败: %w", err)
+	}
+	resp, err = ParseResult[WebRTCResponse](respBody)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			apiErr.Endpoint = webrtcPath
 		}
+		return resp, fmt.Errorf("WebRTC请求失败: %w", err)
 	}
 
-	respBody, err := w.client.SendRequest(ctx, "POST", "/index/api/webrtc", params)
+	return resp, nil
+}
+
+// postOffer 以offer sdp为请求体发送一次POST请求，WebRTC接口会触发鉴权和建连，不会重试
+// 返回: 响应头和响应体
+func (w *WebRTCAPI) postOffer(ctx context.Context, path string, query url.Values, offer, accept string) (http.Header, []byte, error) {
+	apiURL := w.client.config.BaseURL + path + "?" + query.Encode()
+	resp, err := w.client.do(ctx, "POST", apiURL, path, []byte(offer), sdpContentType, accept)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取响应体失败: %w", err)
+	}
+	return resp.Header, respBody, nil
+}
+
+// WebRTCAnswer 解析后的WebRTC answer
+type WebRTCAnswer struct {
+	ID    string              // 会话id
+	Token string              // 结束会话时DeleteWebRTC需要的令牌，仅WHIP和WHEP返回
+	Type  string              // sdp类型，一般为answer
+	SDP   *SessionDescription // answer sdp，可以在返回给客户端前修改，例如替换NAT后的候选地址
+}
+
+// Negotiate 检查offer后进行WebRTC offer/answer交换，并解析answer
+// 参数:
+//   - req: 请求参数，SDP为offer，Type用于检查offer的媒体方向
+//
+// 返回: 解析后的answer，offer不合法时返回ErrInvalidSDP且不会请求ZLMediaKit，
+// 返回的会话id为空或answer不合法时返回ErrInvalidAnswer
+func (w *WebRTCAPI) Negotiate(ctx context.Context, req *WebRTCRequest) (*WebRTCAnswer, error) {
+	if err := validateOffer(req.SDP, req.sessionType()); err != nil {
+		return nil, err
+	}
+
+	resp, err := w.WebRTC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return newWebRTCAnswer(resp.ID, "", resp.Type, resp.SDP)
+}
+
+// newWebRTCAnswer 检查ZLMediaKit返回的会话id和answer
+// 返回: 会话id为空或answer为空、格式错误时返回ErrInvalidAnswer
+func newWebRTCAnswer(id, token, typ, sdp string) (*WebRTCAnswer, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: 缺少会话id", ErrInvalidAnswer)
+	}
+	if strings.TrimSpace(sdp) == "" {
+		return nil, fmt.Errorf("%w: answer为空", ErrInvalidAnswer)
+	}
+	answer, err := ParseSDP(sdp)
+	if err != nil {
+		// 不包装ErrInvalidSDP，answer错误是ZLMediaKit的问题，不是offer的问题
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAnswer, err)
+	}
+
+	return &WebRTCAnswer{ID: id, Token: token, Type: typ, SDP: answer}, nil
+}
+
+// validateOffer 解析并检查offer
+func validateOffer(sdp, sessionType string) error {
+	offer, err := ParseSDP(sdp)
+	if err != nil {
+		return fmt.Errorf("解析offer失败: %w", err)
+	}
+	if err := offer.ValidateOffer(sessionType); err != nil {
+		return fmt.Errorf("检查offer失败: %w", err)
+	}
+	return nil
+}
+
+// WHIP/WHEP以及结束会话接口路径
+const (
+	whipPath         = "/index/api/whip"
+	whepPath         = "/index/api/whep"
+	deleteWebRTCPath = "/index/api/delete_webrtc"
+)
+
+// WHIP 检查offer后通过ZLMediaKit的WHIP接口推流，并解析answer
+// 与Negotiate不同，返回的answer包含结束会话时DeleteWebRTC需要的令牌
+// 参数:
+//   - req: 请求参数，SDP为offer，Type被忽略
+//
+// 返回: 解析后的answer，offer不合法时返回ErrInvalidSDP且不会请求ZLMediaKit，
+// 返回的会话id为空或answer不合法时返回ErrInvalidAnswer
+func (w *WebRTCAPI) WHIP(ctx context.Context, req *WebRTCRequest) (*WebRTCAnswer, error) {
+	return w.exchange(ctx, whipPath, WebRTCTypePush, req)
+}
+
+// WHEP 检查offer后通过ZLMediaKit的WHEP接口播放，并解析answer
+// 与Negotiate不同，返回的answer包含结束会话时DeleteWebRTC需要的令牌
+// 参数:
+//   - req: 请求参数，SDP为offer，Type被忽略
+//
+// 返回: 解析后的answer，offer不合法时返回ErrInvalidSDP且不会请求ZLMediaKit，
+// 返回的会话id为空或answer不合法时返回ErrInvalidAnswer
+func (w *WebRTCAPI) WHEP(ctx context.Context, req *WebRTCRequest) (*WebRTCAnswer, error) {
+	return w.exchange(ctx, whepPath, WebRTCTypePlay, req)
+}
+
+// exchange 请求WHIP/WHEP接口
+// 成功时ZLMediaKit返回201、answer sdp，以及包含会话id和令牌的delete_webrtc地址Location；参数错误时返回JSON
+func (w *WebRTCAPI) exchange(ctx context.Context, path, sessionType string, req *WebRTCRequest) (answer *WebRTCAnswer, err error) {
+	if err := validateOffer(req.SDP, sessionType); err != nil {
+		return nil, err
+	}
+
+	ctx, span := w.client.startSpan(ctx, "POST", path, map[string]interface{}{"vhost": req.VHost, "app": req.App, "stream": req.Stream})
+	defer func(start time.Time) {
+		endSpan(span, start, err)
+	}(time.Now())
+
+	query := req.query()
+	query.Del("type")
+	header, respBody, err := w.postOffer(ctx, path, query, req.SDP, sdpContentType)
 	if err != nil {
 		return nil, fmt.Errorf("WebRTC请求失败: %w", err)
 	}
+	if bytes.HasPrefix(bytes.TrimSpace(respBody), []byte("{")) {
+		if _, err := ParseResult[Status](respBody); err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				apiErr.Endpoint = path
+			}
+			return nil, fmt.Errorf("WebRTC请求失败: %w", err)
+		}
+	}
+
+	location, err := url.Parse(header.Get("Location"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: Location格式错误: %v", ErrInvalidAnswer, err)
+	}
+
+	return newWebRTCAnswer(location.Query().Get("id"), location.Query().Get("token"), "answer", string(respBody))
+}
+
+// DeleteWebRTCRequest 结束WebRTC会话请求参数
+type DeleteWebRTCRequest struct {
+	ID    string `json:"id"`    // 会话id
+	Token string `json:"token"` // WHIP/WHEP返回的令牌
+}
+
+// DeleteWebRTC 结束通过WHIP或WHEP创建的WebRTC会话
+// 参数:
+//   - ID: 会话id
+//   - Token: WHIP/WHEP返回的令牌
+//
+// 返回: 会话不存在或令牌错误时返回HTTPStatus为401的*APIError
+func (w *WebRTCAPI) DeleteWebRTC(ctx context.Context, req *DeleteWebRTCRequest) (err error) {
+	ctx, span := w.client.startSpan(ctx, "DELETE", deleteWebRTCPath, nil)
+	defer func(start time.Time) {
+		endSpan(span, start, err)
+	}(time.Now())
+
+	query := url.Values{"id": {req.ID}, "token": {req.Token}}
+	resp, err := w.client.do(ctx, "DELETE", w.client.config.BaseURL+deleteWebRTCPath+"?"+query.Encode(), deleteWebRTCPath, nil, "", "*/*")
+	if err != nil {
+		return fmt.Errorf("结束WebRTC会话失败: %w", err)
+	}
+	resp.Body.Close()
 
-	return ParseResponse(respBody)
+	return nil
 }
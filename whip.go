@@ -0,0 +1,363 @@
+package zlmedia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// WHIP/WHEP请求的sdp媒体类型
+const sdpContentType = "application/sdp"
+
+// maxOfferSize offer sdp的最大字节数
+const maxOfferSize = 64 << 10
+
+// WebRTCHandlerConfig WHIP/WHEP处理器配置
+type WebRTCHandlerConfig struct {
+	// 处理器挂载的路径前缀，例如/whip/，请求路径去掉前缀后为[vhost/]app/stream
+	PathPrefix string
+
+	// 允许跨域访问的Origin，为空时不返回CORS响应头，*表示允许所有
+	AllowOrigin string
+
+	// 返回给客户端前修改answer，例如将候选地址替换为NAT映射后的公网地址，可以为nil
+	RewriteAnswer func(r *http.Request, answer *SessionDescription)
+
+	// 处理器对应节点的id，HookHandler只处理该节点的事件，为空时处理所有事件
+	MediaServerID string
+}
+
+// WebRTCResource WHIP/WHEP会话资源
+type WebRTCResource struct {
+	ID  string    // ZLMediaKit返回的WebRTC会话id
+	API string    // publish或play
+	Key StreamKey // 推流或播放的流
+
+	token string // 结束会话时delete_webrtc需要的令牌
+}
+
+// String 返回资源的描述，便于日志输出
+func (r WebRTCResource) String() string {
+	return fmt.Sprintf("%s %s (%s)", r.API, r.Key, r.ID)
+}
+
+// WebRTCHandler WHIP(推流)或WHEP(播放)的http.Handler
+// POST [vhost/]app/stream 以application/sdp格式提交offer，通过ZLMediaKit的WHIP/WHEP接口创建会话，
+// 返回201、answer sdp以及Location资源地址；DELETE Location资源地址通过delete_webrtc结束会话
+type WebRTCHandler struct {
+	api    string
+	webrtc *WebRTCAPI
+	config WebRTCHandlerConfig
+
+	mu        sync.Mutex
+	resources map[string]*WebRTCResource
+}
+
+// NewWHIPHandler 创建WHIP推流处理器
+func NewWHIPHandler(client *Client, config WebRTCHandlerConfig) *WebRTCHandler {
+	return newWebRTCHandler("publish", client, config)
+}
+
+// NewWHEPHandler 创建WHEP播放处理器
+func NewWHEPHandler(client *Client, config WebRTCHandlerConfig) *WebRTCHandler {
+	return newWebRTCHandler("play", client, config)
+}
+
+// newWebRTCHandler 创建WHIP/WHEP处理器
+func newWebRTCHandler(api string, client *Client, config WebRTCHandlerConfig) *WebRTCHandler {
+	if !strings.HasPrefix(config.PathPrefix, "/") {
+		config.PathPrefix = "/" + config.PathPrefix
+	}
+	if !strings.HasSuffix(config.PathPrefix, "/") {
+		config.PathPrefix += "/"
+	}
+
+	return &WebRTCHandler{
+		api:       api,
+		webrtc:    NewWebRTCAPI(client),
+		config:    config,
+		resources: make(map[string]*WebRTCResource),
+	}
+}
+
+// Resources 获取当前所有的会话资源
+func (h *WebRTCHandler) Resources() []WebRTCResource {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	resources := make([]WebRTCResource, 0, len(h.resources))
+	for _, resource := range h.resources {
+		resources = append(resources, *resource)
+	}
+	return resources
+}
+
+// ServeHTTP 实现http.Handler
+func (h *WebRTCHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.writeCORS(w)
+
+	segments, ok := h.splitPath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Accept-Post", sdpContentType)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPost:
+		key, ok := streamKeyFromSegments(segments)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		h.create(w, r, key)
+	case http.MethodDelete:
+		if len(segments) < 3 {
+			http.NotFound(w, r)
+			return
+		}
+		key, ok := streamKeyFromSegments(segments[:len(segments)-1])
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		h.delete(w, r, key, segments[len(segments)-1])
+	case http.MethodPatch:
+		// 不支持trickle ICE和ICE restart，客户端需要在offer中包含所有候选地址
+		w.Header().Set("Allow", "POST, DELETE, OPTIONS")
+		http.Error(w, "trickle ice is not supported", http.StatusMethodNotAllowed)
+	default:
+		w.Header().Set("Allow", "POST, DELETE, OPTIONS")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// create 处理offer，创建会话资源
+func (h *WebRTCHandler) create(w http.ResponseWriter, r *http.Request, key StreamKey) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != sdpContentType {
+		http.Error(w, "content type must be "+sdpContentType, http.StatusUnsupportedMediaType)
+		return
+	}
+	offer, err := io.ReadAll(io.LimitReader(r.Body, maxOfferSize+1))
+	if err != nil {
+		http.Error(w, "failed to read offer", http.StatusBadRequest)
+		return
+	}
+	if len(offer) == 0 || len(offer) > maxOfferSize {
+		http.Error(w, "invalid offer size", http.StatusBadRequest)
+		return
+	}
+
+	params, err := webrtcParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	exchange := h.webrtc.WHEP
+	if h.api == "publish" {
+		exchange = h.webrtc.WHIP
+	}
+	answer, err := exchange(r.Context(), &WebRTCRequest{
+		SDP:    string(offer),
+		VHost:  key.NormalizedVHost(),
+		App:    key.App,
+		Stream: key.Stream,
+		Params: params,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), webrtcErrorStatus(err))
+		return
+	}
+	if h.config.RewriteAnswer != nil {
+		h.config.RewriteAnswer(r, answer.SDP)
+	}
+
+	resource := &WebRTCResource{ID: answer.ID, API: h.api, Key: key.Normalize(), token: answer.Token}
+	h.mu.Lock()
+	h.resources[answer.ID] = resource
+	h.mu.Unlock()
+
+	location := strings.TrimSuffix(r.URL.EscapedPath(), "/") + "/" + url.PathEscape(answer.ID)
+	w.Header().Set("Location", location)
+	w.Header().Set("Content-Type", sdpContentType)
+	w.WriteHeader(http.StatusCreated)
+	io.WriteString(w, answer.SDP.Marshal())
+}
+
+// webrtcParams 获取转发给ZLMediaKit的url参数，供on_publish/on_play鉴权使用，不能覆盖会话类型和流
+// WHIP/WHEP客户端通过Authorization: Bearer <token>携带的令牌在url参数没有token时作为token参数转发；
+// hook事件的params中每个参数只能有一个值，参数重复时返回错误
+func webrtcParams(r *http.Request) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	for name, values := range r.URL.Query() {
+		if len(values) > 1 {
+			return nil, fmt.Errorf("url参数%s重复", name)
+		}
+		params[name] = values[0]
+	}
+
+	if _, ok := params["token"]; !ok {
+		scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+		if found && strings.EqualFold(scheme, "Bearer") && strings.TrimSpace(token) != "" {
+			params["token"] = strings.TrimSpace(token)
+		}
+	}
+	return params, nil
+}
+
+// delete 通过delete_webrtc结束会话资源，资源地址中的流需要与创建会话时一致
+// ZLMediaKit上的会话已经结束时delete_webrtc返回401，此时同样释放资源
+func (h *WebRTCHandler) delete(w http.ResponseWriter, r *http.Request, key StreamKey, id string) {
+	h.mu.Lock()
+	resource, ok := h.resources[id]
+	h.mu.Unlock()
+	if !ok || resource.Key != key.Normalize() {
+		http.NotFound(w, r)
+		return
+	}
+
+	err := h.webrtc.DeleteWebRTC(r.Context(), &DeleteWebRTCRequest{ID: resource.ID, Token: resource.token})
+	var apiErr *APIError
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.HTTPStatus == http.StatusUnauthorized) {
+		http.Error(w, err.Error(), webrtcErrorStatus(err))
+		return
+	}
+
+	h.remove(func(res *WebRTCResource) bool { return res == resource })
+	w.WriteHeader(http.StatusOK)
+}
+
+// remove 删除满足条件的会话资源
+func (h *WebRTCHandler) remove(match func(resource *WebRTCResource) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, resource := range h.resources {
+		if match(resource) {
+			delete(h.resources, id)
+		}
+	}
+}
+
+// HookHandler 创建在ZLMediaKit上的会话结束时删除会话资源的HookHandler
+// on_stream_changed注销流时删除该流的所有资源，on_stream_none_reader时删除该流的播放资源，
+// on_server_started时删除所有资源，再调用next，next为nil时使用NopHookHandler
+func (h *WebRTCHandler) HookHandler(next HookHandler) HookHandler {
+	if next == nil {
+		next = NopHookHandler{}
+	}
+	return &webrtcHookHandler{HookHandler: next, handler: h}
+}
+
+// webrtcHookHandler 根据hook事件删除会话资源的HookHandler
+type webrtcHookHandler struct {
+	HookHandler
+	handler *WebRTCHandler
+}
+
+// OnStreamChanged 流注销后推流和播放会话都已结束
+func (h *webrtcHookHandler) OnStreamChanged(ctx context.Context, hook *OnStreamChangedHook) error {
+	if h.matches(hook.MediaServerID) && !hook.Regist {
+		key := hook.Key().Normalize()
+		h.handler.remove(func(r *WebRTCResource) bool { return r.Key == key })
+	}
+	return h.HookHandler.OnStreamChanged(ctx, hook)
+}
+
+// OnStreamNoneReader 流无人观看时播放会话都已结束
+func (h *webrtcHookHandler) OnStreamNoneReader(ctx context.Context, hook *OnStreamNoneReaderHook) (*OnStreamNoneReaderResult, error) {
+	if h.matches(hook.MediaServerID) {
+		key := StreamKey{VHost: hook.VHost, App: hook.App, Stream: hook.Stream}.Normalize()
+		h.handler.remove(func(r *WebRTCResource) bool { return r.API == "play" && r.Key == key })
+	}
+	return h.HookHandler.OnStreamNoneReader(ctx, hook)
+}
+
+// OnServerStarted 服务器重启后所有会话都已结束
+func (h *webrtcHookHandler) OnServerStarted(ctx context.Context, hook *OnServerStartedHook) error {
+	if h.matches(hook.MediaServerID()) {
+		h.handler.remove(func(r *WebRTCResource) bool { return true })
+	}
+	return h.HookHandler.OnServerStarted(ctx, hook)
+}
+
+// matches 事件是否来自处理器对应的节点
+func (h *webrtcHookHandler) matches(mediaServerID string) bool {
+	serverID := h.handler.config.MediaServerID
+	return serverID == "" || serverID == mediaServerID
+}
+
+// splitPath 去掉路径前缀并按/分割，每一段都会被url解码
+func (h *WebRTCHandler) splitPath(path string) ([]string, bool) {
+	rest, ok := strings.CutPrefix(path, h.config.PathPrefix)
+	if !ok || rest == "" {
+		return nil, false
+	}
+
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil || unescaped == "" {
+			return nil, false
+		}
+		segments[i] = unescaped
+	}
+	return segments, true
+}
+
+// writeCORS 写入跨域响应头
+func (h *WebRTCHandler) writeCORS(w http.ResponseWriter) {
+	if h.config.AllowOrigin == "" {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", h.config.AllowOrigin)
+	w.Header().Set("Access-Control-Allow-Methods", "POST, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+	w.Header().Set("Access-Control-Expose-Headers", "Location")
+}
+
+// streamKeyFromSegments 将app/stream或vhost/app/stream转换为StreamKey
+func streamKeyFromSegments(segments []string) (StreamKey, bool) {
+	switch len(segments) {
+	case 2:
+		return StreamKey{App: segments[0], Stream: segments[1]}, true
+	case 3:
+		return StreamKey{VHost: segments[0], App: segments[1], Stream: segments[2]}, true
+	default:
+		return StreamKey{}, false
+	}
+}
+
+// webrtcErrorStatus 将ZLMediaKit错误转换为HTTP状态码
+func webrtcErrorStatus(err error) int {
+	if errors.Is(err, ErrInvalidAnswer) {
+		return http.StatusBadGateway
+	}
+	if errors.Is(err, ErrInvalidSDP) {
+		return http.StatusBadRequest
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatus != 0 {
+		return http.StatusBadGateway
+	}
+	switch apiErr.Code {
+	case CodeAuthFailed:
+		return http.StatusForbidden
+	case CodeInvalidArgs:
+		return http.StatusBadRequest
+	case CodeNotFound:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
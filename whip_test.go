@@ -0,0 +1,232 @@
+package zlmedia_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+	"github.com/edwardpan/zlmedia_restapi_go/zlmtest"
+)
+
+// testOffer 生成指定媒体方向的offer
+func testOffer(direction string) string {
+	return strings.Join([]string{
+		"v=0",
+		"o=- 1 2 IN IP4 127.0.0.1",
+		"s=-",
+		"t=0 0",
+		"m=video 9 UDP/TLS/RTP/SAVPF 96",
+		"c=IN IP4 0.0.0.0",
+		"a=mid:0",
+		"a=" + direction,
+		"a=ice-ufrag:abcd",
+		"a=ice-pwd:abcdabcdabcdabcdabcdabcd",
+		"a=fingerprint:sha-256 00:11:22:33",
+		"a=setup:actpass",
+		"a=rtpmap:96 H264/90000",
+		"",
+	}, "\r\n")
+}
+
+// sendWebRTC 向WHIP/WHEP处理器发送请求
+func sendWebRTC(t *testing.T, server *httptest.Server, method, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/sdp")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestWHIPHandler(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	handler := zlmedia.NewWHIPHandler(zlm.Client(), zlmedia.WebRTCHandlerConfig{PathPrefix: "/whip/"})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp := sendWebRTC(t, server, http.MethodPost, "/whip/live/test?app=evil&type=play&token=abc", testOffer("sendonly"))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST status = %d, want 201", resp.StatusCode)
+	}
+	location := resp.Header.Get("Location")
+	if !strings.HasPrefix(location, "/whip/live/test/") {
+		t.Fatalf("Location = %q", location)
+	}
+	if streams := zlm.Streams(); len(streams) == 0 || streams[0].App != "live" || streams[0].Stream != "test" {
+		t.Fatalf("url参数覆盖了推流的流: %+v", streams)
+	}
+	id := strings.TrimPrefix(location, "/whip/live/test/")
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		want     int
+		sessions int
+	}{
+		{"offer不合法", http.MethodPost, "/whip/live/other", "v=0\r\n", http.StatusBadRequest, 1},
+		{"推流的offer没有发送媒体", http.MethodPost, "/whip/live/other", testOffer("recvonly"), http.StatusBadRequest, 1},
+		{"流已存在", http.MethodPost, "/whip/live/test", testOffer("sendonly"), http.StatusInternalServerError, 1},
+		{"删除时流不一致", http.MethodDelete, "/whip/live/other/" + id, "", http.StatusNotFound, 1},
+		{"删除时虚拟主机不一致", http.MethodDelete, "/whip/example.com/live/test/" + id, "", http.StatusNotFound, 1},
+		{"删除会话", http.MethodDelete, location, "", http.StatusOK, 0},
+		{"重复删除", http.MethodDelete, location, "", http.StatusNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := sendWebRTC(t, server, tt.method, tt.path, tt.body)
+			if resp.StatusCode != tt.want {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.want)
+			}
+			if got := len(zlm.WebRTCSessions()); got != tt.sessions {
+				t.Errorf("ZLMediaKit会话数 = %d, want %d", got, tt.sessions)
+			}
+			if got := len(handler.Resources()); got != tt.sessions {
+				t.Errorf("资源数 = %d, want %d", got, tt.sessions)
+			}
+		})
+	}
+	if streams := zlm.Streams(); len(streams) != 0 {
+		t.Errorf("删除推流会话后流仍然存在: %+v", streams)
+	}
+
+	// ZLMediaKit上的会话已经结束时delete_webrtc返回401，资源同样被释放
+	resp = sendWebRTC(t, server, http.MethodPost, "/whip/live/test", testOffer("sendonly"))
+	zlm.Restart()
+	if resp := sendWebRTC(t, server, http.MethodDelete, resp.Header.Get("Location"), ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("删除已结束的会话 status = %d, want 200", resp.StatusCode)
+	}
+	if got := len(handler.Resources()); got != 0 {
+		t.Errorf("资源数 = %d, want 0", got)
+	}
+}
+
+func TestWHIPHandlerAuth(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	hookServer := httptest.NewServer(zlmedia.NewHookServer(zlmedia.NewAuthHookHandler(nil,
+		&zlmedia.StaticKeyAuthorizer{Param: "token", PublishKeys: []string{"abc"}}, nil)))
+	defer hookServer.Close()
+	_, err := zlmedia.NewServerAPI(zlm.Client()).SetServerConfig(context.Background(), &zlmedia.SetServerConfigRequest{
+		Config: map[string]string{"hook.enable": "1", "hook.on_publish": hookServer.URL + "/index/hook/on_publish"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(zlmedia.NewWHIPHandler(zlm.Client(), zlmedia.WebRTCHandlerConfig{PathPrefix: "/whip/"}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		want          int
+		wantLocation  string // Location的前缀
+	}{
+		{"Bearer令牌", "/whip/live/a", "Bearer abc", http.StatusCreated, "/whip/live/a/"},
+		{"Bearer大小写不敏感", "/whip/live/b", "bearer abc", http.StatusCreated, "/whip/live/b/"},
+		{"url参数中的令牌", "/whip/live/c?token=abc", "", http.StatusCreated, "/whip/live/c/"},
+		{"url参数优先于Bearer令牌", "/whip/live/d?token=wrong", "Bearer abc", http.StatusForbidden, ""},
+		{"Bearer令牌错误", "/whip/live/e", "Bearer wrong", http.StatusForbidden, ""},
+		{"缺少令牌", "/whip/live/f", "", http.StatusForbidden, ""},
+		{"其它认证方式", "/whip/live/g", "Basic abc", http.StatusForbidden, ""},
+		{"url参数重复", "/whip/live/h?token=abc&token=wrong", "", http.StatusBadRequest, ""},
+		{"Location保留路径转义", "/whip/live/a%20b", "Bearer abc", http.StatusCreated, "/whip/live/a%20b/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, server.URL+tt.path, strings.NewReader(testOffer("sendonly")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/sdp")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if location := resp.Header.Get("Location"); !strings.HasPrefix(location, tt.wantLocation) {
+				t.Errorf("Location = %q, want prefix %q", location, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestWHEPHandlerHooks(t *testing.T) {
+	zlm := zlmtest.NewServer()
+	defer zlm.Close()
+	key := zlmedia.StreamKey{App: "live", Stream: "test"}
+	zlm.PublishStream(key, zlmtest.OriginTypeRtmpPush, "rtmp")
+
+	handler := zlmedia.NewWHEPHandler(zlm.Client(), zlmedia.WebRTCHandlerConfig{PathPrefix: "/whep/", MediaServerID: "node1"})
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	hooks := handler.HookHandler(nil)
+	ctx := context.Background()
+
+	if resp := sendWebRTC(t, server, http.MethodPost, "/whep/live/missing", testOffer("recvonly")); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("播放不存在的流 status = %d, want 404", resp.StatusCode)
+	}
+
+	tests := []struct {
+		name string
+		hook func() error
+		want int
+	}{
+		{"其它节点的事件", func() error {
+			_, err := hooks.OnStreamNoneReader(ctx, &zlmedia.OnStreamNoneReaderHook{HookBase: zlmedia.HookBase{MediaServerID: "node2"}, App: "live", Stream: "test"})
+			return err
+		}, 1},
+		{"其它流无人观看", func() error {
+			_, err := hooks.OnStreamNoneReader(ctx, &zlmedia.OnStreamNoneReaderHook{HookBase: zlmedia.HookBase{MediaServerID: "node1"}, App: "live", Stream: "other"})
+			return err
+		}, 1},
+		{"流注册", func() error {
+			hook := &zlmedia.OnStreamChangedHook{Regist: true}
+			hook.MediaServerID, hook.App, hook.Stream = "node1", "live", "test"
+			return hooks.OnStreamChanged(ctx, hook)
+		}, 1},
+		{"无人观看", func() error {
+			_, err := hooks.OnStreamNoneReader(ctx, &zlmedia.OnStreamNoneReaderHook{HookBase: zlmedia.HookBase{MediaServerID: "node1"}, VHost: zlmedia.DefaultVHost, App: "live", Stream: "test"})
+			return err
+		}, 0},
+		{"流注销", func() error {
+			hook := &zlmedia.OnStreamChangedHook{}
+			hook.MediaServerID, hook.App, hook.Stream = "node1", "live", "test"
+			return hooks.OnStreamChanged(ctx, hook)
+		}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(handler.Resources()) == 0 {
+				if resp := sendWebRTC(t, server, http.MethodPost, "/whep/live/test", testOffer("recvonly")); resp.StatusCode != http.StatusCreated {
+					t.Fatalf("POST status = %d, want 201", resp.StatusCode)
+				}
+			}
+			if err := tt.hook(); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(handler.Resources()); got != tt.want {
+				t.Errorf("资源数 = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -7,12 +7,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Config ZLMediaKit客户端配置
@@ -20,24 +25,30 @@ type Config struct {
 	BaseURL string        // ZLMediaKit API的基础URL，例如：http://localhost:80
 	Secret  string        // API操作密钥(配置文件配置)
 	Timeout time.Duration // HTTP客户端超时设置，默认为10秒
+
+	HTTPClient  *http.Client      // 自定义HTTP客户端，为nil时按Timeout和Transport创建
+	Transport   http.RoundTripper // 自定义Transport，仅在HTTPClient为nil时使用
+	Middlewares []Middleware      // HTTP中间件，按顺序包装，第一个位于最外层，每次HTTP请求(包括重试)都会经过
+
+	// 重试策略，为nil时不重试
+	// 只读接口自动重试，修改类接口需要通过WithRetryMutating显式开启
+	RetryPolicy *RetryPolicy
+
+	// OpenTelemetry TracerProvider，为nil时使用otel全局TracerProvider
+	// 每次API调用都会创建一个span，记录接口路径、vhost/app/stream、ZLMediaKit错误代码和耗时
+	TracerProvider trace.TracerProvider
 }
 
 // Client ZLMediaKit客户端
 type Client struct {
-	config     Config
-	httpClient *http.Client
+	config Config
+	doer   Doer
+	tracer trace.Tracer
 }
 
-// 全局ZLMediaKit客户端实例
-var globalClient *Client
-
-// InitClient 初始化ZLMediaKit客户端
+// NewClient 创建ZLMediaKit客户端
 // 参考文档: https://docs.zlmediakit.com/zh/guide/media_server/restful_api.html
-func InitClient(config Config) *Client {
-	if globalClient != nil {
-		return globalClient
-	}
-
+func NewClient(config Config) *Client {
 	// 设置默认超时
 	if config.Timeout == 0 {
 		config.Timeout = 10 * time.Second
@@ -46,31 +57,48 @@ func InitClient(config Config) *Client {
 	// 确保baseURL不以/结尾
 	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
 
-	globalClient = &Client{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+	// 填充重试策略的默认值，同时避免调用方之后修改策略
+	if config.RetryPolicy != nil {
+		config.RetryPolicy = config.RetryPolicy.withDefaults()
 	}
 
-	return globalClient
-}
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   config.Timeout,
+			Transport: config.Transport,
+		}
+	}
 
-// GetClient 获取全局ZLMediaKit客户端实例
-func GetClient() *Client {
-	if globalClient == nil {
-		panic("ZLMediaKit客户端尚未初始化，请先调用InitClient")
+	return &Client{
+		config: config,
+		doer:   chainMiddlewares(httpClient, config.Middlewares),
+		tracer: newTracer(config.TracerProvider),
 	}
-	return globalClient
+}
+
+// BaseURL 获取客户端连接的ZLMediaKit API基础URL
+func (c *Client) BaseURL() string {
+	return c.config.BaseURL
 }
 
 // SendRequest 发送HTTP请求到ZLMediaKit API
+// 配置了RetryPolicy时按策略自动重试，参见RetryPolicy
 func (c *Client) SendRequest(ctx context.Context, method, path string, params map[string]interface{}) ([]byte, error) {
-	// 构建URL
-	apiURL := fmt.Sprintf("%s%s", c.config.BaseURL, path)
+	apiURL, reqBody, contentType, err := c.encodeRequest(method, path, params)
+	if err != nil {
+		return nil, err
+	}
 
-	var reqBody io.Reader
-	var contentType string
+	return c.retry(ctx, path, func() ([]byte, error) {
+		return c.send(ctx, method, apiURL, path, reqBody, contentType)
+	})
+}
+
+// encodeRequest 构建请求URL和请求体，GET请求的参数放在URL中，POST请求的参数放在body中
+func (c *Client) encodeRequest(method, path string, params map[string]interface{}) (apiURL string, reqBody []byte, contentType string, err error) {
+	// 构建URL
+	apiURL = fmt.Sprintf("%s%s", c.config.BaseURL, path)
 
 	if method == "GET" {
 		// GET请求，参数放在URL中
@@ -101,20 +129,48 @@ func (c *Client) SendRequest(ctx context.Context, method, path string, params ma
 
 			jsonData, err := json.Marshal(params)
 			if err != nil {
-				return nil, fmt.Errorf("序列化请求体失败: %w", err)
+				return "", nil, "", fmt.Errorf("序列化请求体失败: %w", err)
 			}
-			reqBody = bytes.NewBuffer(jsonData)
+			reqBody = jsonData
 			contentType = "application/json"
 		} else {
 			// 只发送secret参数
 			values := url.Values{}
 			values.Set("secret", c.config.Secret)
-			reqBody = strings.NewReader(values.Encode())
+			reqBody = []byte(values.Encode())
 			contentType = "application/x-www-form-urlencoded"
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, apiURL, reqBody)
+	return apiURL, reqBody, contentType, nil
+}
+
+// send 发送一次HTTP请求
+func (c *Client) send(ctx context.Context, method, apiURL, path string, reqBody []byte, contentType string) ([]byte, error) {
+	resp, err := c.do(ctx, method, apiURL, path, reqBody, contentType, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应体
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应体失败: %w", err)
+	}
+
+	return respBody, nil
+}
+
+// do 发送一次HTTP请求，状态码不是2xx时返回*APIError
+// 返回: 状态码为2xx的响应，调用方需要关闭响应体
+func (c *Client) do(ctx context.Context, method, apiURL, path string, reqBody []byte, contentType, accept string) (*http.Response, error) {
+	var body io.Reader
+	if reqBody != nil {
+		body = bytes.NewReader(reqBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("创建HTTP请求失败: %w", err)
 	}
@@ -123,27 +179,26 @@ func (c *Client) SendRequest(ctx context.Context, method, path string, params ma
 	if contentType != "" {
 		req.Header.Set("Content-Type", contentType)
 	}
-	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept", accept)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	// 发送请求
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doer.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("发送HTTP请求失败: %w", err)
 	}
-	defer resp.Body.Close()
-
-	// 读取响应体
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应体失败: %w", err)
-	}
 
 	// 检查响应状态码
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API请求失败，状态码: %d，响应: %s", resp.StatusCode, string(respBody))
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("读取响应体失败: %w", err)
+		}
+		return nil, &APIError{Endpoint: path, HTTPStatus: resp.StatusCode, Msg: string(respBody)}
 	}
 
-	return respBody, nil
+	return resp, nil
 }
 
 // BaseResponse ZLMediaKit API的基础响应结构
@@ -153,7 +208,64 @@ type BaseResponse struct {
 	Data map[string]interface{} `json:"data,omitempty"` // 返回数据，可能为空
 }
 
+// Status ZLMediaKit API响应的状态部分，所有响应结构都内嵌该结构
+type Status struct {
+	Code int    `json:"code"`          // 错误代码，0代表成功
+	Msg  string `json:"msg,omitempty"` // 不固定存在，可能为空
+}
+
+// Response ZLMediaKit API的泛型响应结构
+// 用于返回数据位于data字段中的接口，例如 Response[[]MediaInfo]
+type Response[T any] struct {
+	Status
+	Data T `json:"data,omitempty"` // 返回数据，可能为空
+}
+
+// ParseResult 将ZLMediaKit API响应解析为指定的响应类型
+// T需要内嵌Status，例如 Response[[]MediaInfo] 或 OpenRtpServerResponse
+// 错误代码不为0时返回已解析的响应和*APIError
+func ParseResult[T any](respBody []byte) (*T, error) {
+	var status Status
+	if err := json.Unmarshal(respBody, &status); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	var result T
+	if status.Code != 0 {
+		// 失败时data字段的结构与成功时不一定相同，尽力解析即可
+		_ = json.Unmarshal(respBody, &result)
+		return &result, &APIError{Code: status.Code, Msg: status.Msg}
+	}
+
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	return &result, nil
+}
+
+// doRequest 发送请求并将响应解析为指定的响应类型，同时记录追踪span
+func doRequest[T any](ctx context.Context, c *Client, method, path string, params map[string]interface{}) (result *T, err error) {
+	ctx, span := c.startSpan(ctx, method, path, params)
+	defer func(start time.Time) {
+		endSpan(span, start, err)
+	}(time.Now())
+
+	respBody, err := c.SendRequest(ctx, method, path, params)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err = ParseResult[T](respBody)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		apiErr.Endpoint = path
+	}
+	return result, err
+}
+
 // ParseResponse 解析ZLMediaKit API响应
+// 仅适用于data字段为对象的接口，其它接口请使用ParseResult
 func ParseResponse(respBody []byte) (*BaseResponse, error) {
 	var response BaseResponse
 	if err := json.Unmarshal(respBody, &response); err != nil {
@@ -161,7 +273,7 @@ func ParseResponse(respBody []byte) (*BaseResponse, error) {
 	}
 
 	if response.Code != 0 {
-		return &response, fmt.Errorf("API返回错误，代码: %d，消息: %s", response.Code, response.Msg)
+		return &response, &APIError{Code: response.Code, Msg: response.Msg}
 	}
 
 	return &response, nil
@@ -0,0 +1,809 @@
+package zlmtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// 产生源类型，与ZLMediaKit的MediaOriginType一致
+const (
+	OriginTypeUnknown    = 0
+	OriginTypeRtmpPush   = 1
+	OriginTypeRtspPush   = 2
+	OriginTypeRtpPush    = 3
+	OriginTypePull       = 4
+	OriginTypeFFmpegPull = 5
+	OriginTypeMp4Vod     = 6
+	OriginTypeDeviceChn  = 7
+	OriginTypeRtcPush    = 8
+)
+
+// originTypeNames 产生源类型名称
+var originTypeNames = map[int]string{
+	OriginTypeUnknown:    "unknown",
+	OriginTypeRtmpPush:   "rtmp_push",
+	OriginTypeRtspPush:   "rtsp_push",
+	OriginTypeRtpPush:    "rtp_push",
+	OriginTypePull:       "pull",
+	OriginTypeFFmpegPull: "ffmpeg_pull",
+	OriginTypeMp4Vod:     "mp4_vod",
+	OriginTypeDeviceChn:  "device_chn",
+	OriginTypeRtcPush:    "rtc_push",
+}
+
+// snapJPEG 截图接口返回的最小JPEG图片
+var snapJPEG = []byte{
+	0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x10, 'J', 'F', 'I', 'F', 0x00, 0x01, 0x01, 0x00, 0x00, 0x01,
+	0x00, 0x01, 0x00, 0x00, 0xFF, 0xD9,
+}
+
+// registerHandlers 注册所有接口
+func (s *Server) registerHandlers() {
+	s.handlers = map[string]handlerFunc{
+		"/index/api/getApiList":            s.getApiList,
+		"/index/api/getThreadsLoad":        s.getThreadsLoad,
+		"/index/api/getWorkThreadsLoad":    s.getWorkThreadsLoad,
+		"/index/api/getStatistic":          s.getStatistic,
+		"/index/api/getServerConfig":       s.getServerConfig,
+		"/index/api/setServerConfig":       s.setServerConfig,
+		"/index/api/restartServer":         s.restartServer,
+		"/index/api/getMediaList":          s.getMediaList,
+		"/index/api/close_stream":          s.closeStream,
+		"/index/api/close_streams":         s.closeStreams,
+		"/index/api/addStreamProxy":        s.addStreamProxy,
+		"/index/api/delStreamProxy":        s.delStreamProxy,
+		"/index/api/listStreamProxy":       s.listStreamProxy,
+		"/index/api/addStreamPusherProxy":  s.addStreamPusherProxy,
+		"/index/api/delStreamPusherProxy":  s.delStreamPusherProxy,
+		"/index/api/listStreamPusherProxy": s.listStreamPusherProxy,
+		"/index/api/isRecording":           s.isRecording,
+		"/index/api/startRecord":           s.startRecord,
+		"/index/api/stopRecord":            s.stopRecord,
+		"/index/api/getMp4RecordFile":      s.getMp4RecordFile,
+		"/index/api/deleteRecordDirectory": s.deleteRecordDirectory,
+		"/index/api/getSnap":               s.getSnap,
+		"/index/api/openRtpServer":         s.openRtpServer,
+		"/index/api/closeRtpServer":        s.closeRtpServer,
+		"/index/api/listRtpServer":         s.listRtpServer,
+		"/index/api/startSendRtp":          s.startSendRtp,
+		"/index/api/stopSendRtp":           s.stopSendRtp,
+		"/index/api/getRtpInfo":            s.getRtpInfo,
+		"/index/api/getAllSession":         s.getAllSession,
+		"/index/api/kick_session":          s.kickSession,
+		"/index/api/kick_sessions":         s.kickSessions,
+	}
+	s.webrtcHandlers = map[string]webrtcHandlerFunc{
+		"/index/api/webrtc":        s.webrtc,
+		"/index/api/whip":          s.whip,
+		"/index/api/whep":          s.whep,
+		"/index/api/delete_webrtc": s.deleteWebRTC,
+	}
+}
+
+// success 成功响应
+func success() zlmedia.Status {
+	return zlmedia.Status{Code: zlmedia.CodeSuccess}
+}
+
+// failure 失败响应
+func failure(code int, msg string) zlmedia.Status {
+	return zlmedia.Status{Code: code, Msg: msg}
+}
+
+// data 数据位于data字段的成功响应
+func data[T any](v T) zlmedia.Response[T] {
+	return zlmedia.Response[T]{Data: v}
+}
+
+// tupleKey 生成vhost/app/stream格式的key，vhost为空时使用默认虚拟主机
+func tupleKey(vhost, app, stream string) string {
+	return zlmedia.StreamKey{VHost: vhost, App: app, Stream: stream}.String()
+}
+
+// requireParams 检查必填参数，缺失时返回参数不合法的响应
+func requireParams(params url.Values, names ...string) (zlmedia.Status, bool) {
+	for _, name := range names {
+		if params.Get(name) == "" {
+			return failure(zlmedia.CodeInvalidArgs, fmt.Sprintf("缺少必要参数:%s", name)), false
+		}
+	}
+	return zlmedia.Status{}, true
+}
+
+// intParam 获取整数参数，不存在或格式错误时返回def
+func intParam(params url.Values, name string, def int) int {
+	value, err := strconv.Atoi(params.Get(name))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// boolParam 获取布尔参数，接受1/0和true/false，不存在时返回def
+func boolParam(params url.Values, name string, def bool) bool {
+	switch strings.ToLower(params.Get(name)) {
+	case "1", "true":
+		return true
+	case "0", "false":
+		return false
+	}
+	return def
+}
+
+// addStream 注册流，每个协议生成一条媒体信息，调用方需持有锁
+func (s *Server) addStream(key zlmedia.StreamKey, originType int, originURL string, schemas []string) {
+	now := time.Now().Unix()
+	for _, schema := range schemas {
+		s.medias[schema+"/"+key.String()] = &zlmedia.MediaInfo{
+			Schema:        schema,
+			VHost:         key.NormalizedVHost(),
+			App:           key.App,
+			Stream:        key.Stream,
+			OriginType:    originType,
+			OriginTypeStr: originTypeNames[originType],
+			OriginURL:     originURL,
+			CreateStamp:   now,
+			Tracks: []zlmedia.Track{
+				{CodecID: 0, CodecIDName: "H264", CodecType: 0, Ready: true, FPS: 25, Width: 1920, Height: 1080, Loss: -1},
+				{CodecID: 2, CodecIDName: "mpeg4-generic", CodecType: 1, Ready: true, Channels: 1, SampleBit: 16, SampleRate: 8000, Loss: -1},
+			},
+		}
+	}
+}
+
+// removeStream 注销流的所有协议，返回是否存在，调用方需持有锁
+func (s *Server) removeStream(key zlmedia.StreamKey) bool {
+	found := false
+	for mediaKey, media := range s.medias {
+		if media.VHost == key.NormalizedVHost() && media.App == key.App && media.Stream == key.Stream {
+			delete(s.medias, mediaKey)
+			found = true
+		}
+	}
+	delete(s.records, key.String())
+	return found
+}
+
+// hasStream 判断流是否存在，调用方需持有锁
+func (s *Server) hasStream(key zlmedia.StreamKey) bool {
+	for _, media := range s.medias {
+		if media.VHost == key.NormalizedVHost() && media.App == key.App && media.Stream == key.Stream {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultSchemas 根据协议开关生成流的协议列表，未指定的开关使用服务器配置
+func (s *Server) defaultSchemas(params url.Values) []string {
+	schemas := []string{}
+	for _, item := range []struct{ param, schema string }{
+		{"enable_rtsp", "rtsp"},
+		{"enable_rtmp", "rtmp"},
+		{"enable_ts", "ts"},
+		{"enable_fmp4", "fmp4"},
+		{"enable_hls", "hls"},
+	} {
+		if boolParam(params, item.param, s.config["protocol."+item.param] == "1") {
+			schemas = append(schemas, item.schema)
+		}
+	}
+	return schemas
+}
+
+// allocPort 分配随机端口，调用方需持有锁
+func (s *Server) allocPort() (int, bool) {
+	used := make(map[int]bool)
+	for _, server := range s.rtpServers {
+		used[server.Port] = true
+	}
+	for _, port := range s.sendRtps {
+		used[port] = true
+	}
+
+	total := s.rtpPortMax - s.rtpPortMin + 1
+	for i := 0; i < total; i++ {
+		port := s.nextPort
+		s.nextPort++
+		if s.nextPort > s.rtpPortMax {
+			s.nextPort = s.rtpPortMin
+		}
+		if !used[port] {
+			return port, true
+		}
+	}
+	return 0, false
+}
+
+func (s *Server) getApiList(params url.Values) interface{} {
+	return data(sortedKeys(s.handlers))
+}
+
+func (s *Server) getThreadsLoad(params url.Values) interface{} {
+	return data(append([]zlmedia.ThreadLoad(nil), s.threads...))
+}
+
+func (s *Server) getWorkThreadsLoad(params url.Values) interface{} {
+	return data(append([]zlmedia.ThreadLoad(nil), s.workThreads...))
+}
+
+func (s *Server) getStatistic(params url.Values) interface{} {
+	return data(zlmedia.Statistic{
+		MediaSource:           len(s.medias),
+		MultiMediaSourceMuxer: len(s.medias),
+		TcpSession:            len(s.sessions),
+		UdpServer:             len(s.rtpServers),
+	})
+}
+
+func (s *Server) getServerConfig(params url.Values) interface{} {
+	config := make(map[string]string, len(s.config))
+	for key, value := range s.config {
+		config[key] = value
+	}
+	return data([]map[string]string{config})
+}
+
+func (s *Server) setServerConfig(params url.Values) interface{} {
+	changed := 0
+	for key := range params {
+		if key == "secret" {
+			continue
+		}
+		// 与ZLMediaKit一致，只接受已存在的配置项
+		old, ok := s.config[key]
+		if !ok || old == params.Get(key) {
+			continue
+		}
+		s.config[key] = params.Get(key)
+		changed++
+	}
+	return zlmedia.SetServerConfigResponse{Changed: changed}
+}
+
+func (s *Server) restartServer(params url.Values) interface{} {
+	s.resetRuntime()
+	return failure(zlmedia.CodeSuccess, "服务器将在一秒后自动重启")
+}
+
+// resetRuntime 清空运行时状态，模拟服务器重启，调用方需持有锁
+func (s *Server) resetRuntime() {
+	s.medias = make(map[string]*zlmedia.MediaInfo)
+	s.proxies = make(map[string]*zlmedia.ProxyInfo)
+	s.pushers = make(map[string]*zlmedia.PusherProxyInfo)
+	s.rtpServers = make(map[string]*zlmedia.RtpServerInfo)
+	s.rtpSSRCs = make(map[string]string)
+	s.sendRtps = make(map[string]int)
+	s.sessions = make(map[string]*zlmedia.SessionInfo)
+	s.rtcSessions = make(map[string]*rtcSession)
+	s.records = make(map[string]map[string]bool)
+}
+
+func (s *Server) getMediaList(params url.Values) interface{} {
+	medias := []zlmedia.MediaInfo{}
+	for _, key := range sortedKeys(s.medias) {
+		media := s.medias[key]
+		if !matchMedia(media, params) {
+			continue
+		}
+		copied := *media
+		copied.AliveSecond = time.Now().Unix() - media.CreateStamp
+		copied.Tracks = append([]zlmedia.Track(nil), media.Tracks...)
+		record := s.records[tupleKey(media.VHost, media.App, media.Stream)]
+		copied.IsRecordingMP4 = record["mp4"]
+		copied.IsRecordingHLS = record["hls"]
+		medias = append(medias, copied)
+	}
+	return data(medias)
+}
+
+// matchMedia 判断流是否满足schema/vhost/app/stream筛选条件
+func matchMedia(media *zlmedia.MediaInfo, params url.Values) bool {
+	for name, value := range map[string]string{
+		"schema": media.Schema,
+		"vhost":  media.VHost,
+		"app":    media.App,
+		"stream": media.Stream,
+	} {
+		if filter := params.Get(name); filter != "" && filter != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) closeStream(params url.Values) interface{} {
+	if status, ok := requireParams(params, "schema", "vhost", "app", "stream"); !ok {
+		return status
+	}
+
+	key := params.Get("schema") + "/" + tupleKey(params.Get("vhost"), params.Get("app"), params.Get("stream"))
+	if _, ok := s.medias[key]; !ok {
+		return zlmedia.CloseStreamResponse{Status: failure(zlmedia.CodeSuccess, "can not find the stream"), Result: -2}
+	}
+	delete(s.medias, key)
+	return zlmedia.CloseStreamResponse{Status: failure(zlmedia.CodeSuccess, "success"), Result: 0}
+}
+
+func (s *Server) closeStreams(params url.Values) interface{} {
+	hit := 0
+	for key, media := range s.medias {
+		if matchMedia(media, params) {
+			delete(s.medias, key)
+			hit++
+		}
+	}
+	return zlmedia.CloseStreamsResponse{CountHit: hit, CountClosed: hit}
+}
+
+func (s *Server) addStreamProxy(params url.Values) interface{} {
+	if status, ok := requireParams(params, "vhost", "app", "stream", "url"); !ok {
+		return status
+	}
+
+	key := zlmedia.StreamKey{VHost: params.Get("vhost"), App: params.Get("app"), Stream: params.Get("stream")}
+	if _, ok := s.proxies[key.String()]; ok || s.hasStream(key) {
+		return failure(zlmedia.CodeOtherFailed, "This stream already exists")
+	}
+
+	s.proxies[key.String()] = &zlmedia.ProxyInfo{
+		Key: key.String(),
+		URL: params.Get("url"),
+		Src: zlmedia.StreamTuple{VHost: key.NormalizedVHost(), App: key.App, Stream: key.Stream},
+	}
+	s.addStream(key, OriginTypePull, params.Get("url"), s.defaultSchemas(params))
+	return data(zlmedia.ProxyKey{Key: key.String()})
+}
+
+func (s *Server) delStreamProxy(params url.Values) interface{} {
+	if status, ok := requireParams(params, "key"); !ok {
+		return status
+	}
+
+	proxy, ok := s.proxies[params.Get("key")]
+	if !ok {
+		return data(zlmedia.DelProxyResult{Flag: false})
+	}
+	delete(s.proxies, params.Get("key"))
+	s.removeStream(zlmedia.StreamKey{VHost: proxy.Src.VHost, App: proxy.Src.App, Stream: proxy.Src.Stream})
+	return data(zlmedia.DelProxyResult{Flag: true})
+}
+
+func (s *Server) listStreamProxy(params url.Values) interface{} {
+	proxies := []zlmedia.ProxyInfo{}
+	for _, key := range sortedKeys(s.proxies) {
+		proxies = append(proxies, *s.proxies[key])
+	}
+	return data(proxies)
+}
+
+func (s *Server) addStreamPusherProxy(params url.Values) interface{} {
+	if status, ok := requireParams(params, "schema", "vhost", "app", "stream", "dst_url"); !ok {
+		return status
+	}
+
+	src := zlmedia.StreamKey{VHost: params.Get("vhost"), App: params.Get("app"), Stream: params.Get("stream")}
+	if !s.hasStream(src) {
+		return failure(zlmedia.CodeNotFound, "can not find the source stream")
+	}
+
+	key := params.Get("schema") + "/" + src.String() + "/" + params.Get("dst_url")
+	if _, ok := s.pushers[key]; ok {
+		return failure(zlmedia.CodeOtherFailed, "This stream already exists")
+	}
+	s.pushers[key] = &zlmedia.PusherProxyInfo{
+		Key: key,
+		URL: params.Get("dst_url"),
+		Src: zlmedia.StreamTuple{VHost: src.NormalizedVHost(), App: src.App, Stream: src.Stream},
+	}
+	return data(zlmedia.ProxyKey{Key: key})
+}
+
+func (s *Server) delStreamPusherProxy(params url.Values) interface{} {
+	if status, ok := requireParams(params, "key"); !ok {
+		return status
+	}
+
+	_, ok := s.pushers[params.Get("key")]
+	delete(s.pushers, params.Get("key"))
+	return data(zlmedia.DelProxyResult{Flag: ok})
+}
+
+func (s *Server) listStreamPusherProxy(params url.Values) interface{} {
+	pushers := []zlmedia.PusherProxyInfo{}
+	for _, key := range sortedKeys(s.pushers) {
+		pushers = append(pushers, *s.pushers[key])
+	}
+	return data(pushers)
+}
+
+// recordType 录制类型参数转换为名称，0为hls，1为mp4
+func recordType(params url.Values) string {
+	if params.Get("type") == "0" {
+		return "hls"
+	}
+	return "mp4"
+}
+
+func (s *Server) isRecording(params url.Values) interface{} {
+	if status, ok := requireParams(params, "type", "vhost", "app", "stream"); !ok {
+		return status
+	}
+
+	key := zlmedia.StreamKey{VHost: params.Get("vhost"), App: params.Get("app"), Stream: params.Get("stream")}
+	if !s.hasStream(key) {
+		return failure(zlmedia.CodeNotFound, "can not find the stream")
+	}
+	return zlmedia.IsRecordingResponse{Recording: s.records[key.String()][recordType(params)]}
+}
+
+func (s *Server) startRecord(params url.Values) interface{} {
+	if status, ok := requireParams(params, "type", "vhost", "app", "stream"); !ok {
+		return status
+	}
+
+	key := zlmedia.StreamKey{VHost: params.Get("vhost"), App: params.Get("app"), Stream: params.Get("stream")}
+	if !s.hasStream(key) {
+		return failure(zlmedia.CodeNotFound, "can not find the stream")
+	}
+	if s.records[key.String()] == nil {
+		s.records[key.String()] = make(map[string]bool)
+	}
+	s.records[key.String()][recordType(params)] = true
+	return zlmedia.RecordResultResponse{Result: true}
+}
+
+func (s *Server) stopRecord(params url.Values) interface{} {
+	if status, ok := requireParams(params, "type", "vhost", "app", "stream"); !ok {
+		return status
+	}
+
+	key := zlmedia.StreamKey{VHost: params.Get("vhost"), App: params.Get("app"), Stream: params.Get("stream")}
+	if !s.hasStream(key) {
+		return failure(zlmedia.CodeNotFound, "can not find the stream")
+	}
+	recording := s.records[key.String()][recordType(params)]
+	delete(s.records[key.String()], recordType(params))
+	return zlmedia.RecordResultResponse{Result: recording}
+}
+
+// recordRoot 录制文件根目录
+func recordRoot(key zlmedia.StreamKey) string {
+	return fmt.Sprintf("./www/record/%s/%s/", key.App, key.Stream)
+}
+
+func (s *Server) getMp4RecordFile(params url.Values) interface{} {
+	if status, ok := requireParams(params, "vhost", "app", "stream", "period"); !ok {
+		return status
+	}
+
+	key := zlmedia.StreamKey{VHost: params.Get("vhost"), App: params.Get("app"), Stream: params.Get("stream")}
+	period := params.Get("period")
+	days := s.recordFiles[key.String()]
+
+	paths := []string{}
+	root := recordRoot(key)
+	if files, ok := days[period]; ok && len(period) == len("2006-01-02") {
+		// 完整的日期，返回当天的mp4文件列表
+		paths = append(paths, files...)
+		sort.Strings(paths)
+		root += period + "/"
+	} else {
+		// 不完整的日期，返回匹配的文件夹列表
+		for _, day := range sortedKeys(days) {
+			if strings.HasPrefix(day, period) {
+				paths = append(paths, day)
+			}
+		}
+	}
+	return data(zlmedia.Mp4RecordFiles{Paths: paths, RootPath: root})
+}
+
+func (s *Server) deleteRecordDirectory(params url.Values) interface{} {
+	if status, ok := requireParams(params, "vhost", "app", "stream", "period"); !ok {
+		return status
+	}
+
+	key := zlmedia.StreamKey{VHost: params.Get("vhost"), App: params.Get("app"), Stream: params.Get("stream")}
+	period := params.Get("period")
+	for day := range s.recordFiles[key.String()] {
+		if strings.HasPrefix(day, period) {
+			delete(s.recordFiles[key.String()], day)
+		}
+	}
+	return zlmedia.DeleteRecordDirectoryResponse{Path: recordRoot(key) + period}
+}
+
+func (s *Server) getSnap(params url.Values) interface{} {
+	if status, ok := requireParams(params, "url"); !ok {
+		return status
+	}
+	return s.snap
+}
+
+func (s *Server) openRtpServer(params url.Values) interface{} {
+	if status, ok := requireParams(params, "port", "stream_id"); !ok {
+		return status
+	}
+
+	streamID := params.Get("stream_id")
+	if _, ok := s.rtpServers[streamID]; ok {
+		return failure(zlmedia.CodeOtherFailed, "This stream already exists")
+	}
+
+	port := intParam(params, "port", 0)
+	if port == 0 {
+		var ok bool
+		if port, ok = s.allocPort(); !ok {
+			return failure(zlmedia.CodeOtherFailed, "can not alloc rtp port")
+		}
+	} else {
+		for _, server := range s.rtpServers {
+			if server.Port == port {
+				return failure(zlmedia.CodeOtherFailed, fmt.Sprintf("bind udp socket failed, port: %d", port))
+			}
+		}
+	}
+
+	s.rtpServers[streamID] = &zlmedia.RtpServerInfo{Port: port, StreamID: streamID}
+	s.rtpSSRCs[streamID] = params.Get("ssrc")
+	return zlmedia.OpenRtpServerResponse{Port: port}
+}
+
+func (s *Server) closeRtpServer(params url.Values) interface{} {
+	if status, ok := requireParams(params, "stream_id"); !ok {
+		return status
+	}
+
+	_, ok := s.rtpServers[params.Get("stream_id")]
+	delete(s.rtpServers, params.Get("stream_id"))
+	delete(s.rtpSSRCs, params.Get("stream_id"))
+	if !ok {
+		return zlmedia.CloseRtpServerResponse{Hit: 0}
+	}
+	return zlmedia.CloseRtpServerResponse{Hit: 1}
+}
+
+func (s *Server) listRtpServer(params url.Values) interface{} {
+	servers := []zlmedia.RtpServerInfo{}
+	for _, streamID := range sortedKeys(s.rtpServers) {
+		servers = append(servers, *s.rtpServers[streamID])
+	}
+	return data(servers)
+}
+
+func (s *Server) startSendRtp(params url.Values) interface{} {
+	if status, ok := requireParams(params, "vhost", "app", "stream", "ssrc", "dst_url", "dst_port"); !ok {
+		return status
+	}
+
+	key := zlmedia.StreamKey{VHost: params.Get("vhost"), App: params.Get("app"), Stream: params.Get("stream")}
+	if !s.hasStream(key) {
+		return failure(zlmedia.CodeNotFound, "can not find the source stream")
+	}
+
+	sendKey := key.String() + "/" + params.Get("ssrc")
+	port := intParam(params, "src_port", 0)
+	if port == 0 {
+		var ok bool
+		if port, ok = s.allocPort(); !ok {
+			return failure(zlmedia.CodeOtherFailed, "can not alloc rtp port")
+		}
+	}
+	s.sendRtps[sendKey] = port
+	return zlmedia.StartSendRtpResponse{LocalPort: port}
+}
+
+func (s *Server) stopSendRtp(params url.Values) interface{} {
+	if status, ok := requireParams(params, "vhost", "app", "stream"); !ok {
+		return status
+	}
+
+	prefix := tupleKey(params.Get("vhost"), params.Get("app"), params.Get("stream")) + "/"
+	hit := false
+	for key := range s.sendRtps {
+		// ssrc为空时停止该流的所有推流
+		if key == prefix+params.Get("ssrc") || (params.Get("ssrc") == "" && strings.HasPrefix(key, prefix)) {
+			delete(s.sendRtps, key)
+			hit = true
+		}
+	}
+	if !hit {
+		return failure(zlmedia.CodeOtherFailed, "stopSendRtp failed")
+	}
+	return success()
+}
+
+func (s *Server) getRtpInfo(params url.Values) interface{} {
+	if status, ok := requireParams(params, "stream_id"); !ok {
+		return status
+	}
+
+	key := zlmedia.StreamKey{App: "rtp", Stream: params.Get("stream_id")}
+	if !s.hasStream(key) {
+		return zlmedia.RtpInfoResponse{Exist: false}
+	}
+	resp := zlmedia.RtpInfoResponse{Exist: true, LocalIP: "127.0.0.1", PeerIP: "127.0.0.1", PeerPort: 5060}
+	if server, ok := s.rtpServers[params.Get("stream_id")]; ok {
+		resp.LocalPort = server.Port
+	}
+	return resp
+}
+
+func (s *Server) getAllSession(params url.Values) interface{} {
+	sessions := []zlmedia.SessionInfo{}
+	for _, id := range sortedKeys(s.sessions) {
+		session := s.sessions[id]
+		if matchSession(session, params) {
+			sessions = append(sessions, *session)
+		}
+	}
+	return data(sessions)
+}
+
+// matchSession 判断会话是否满足local_port/peer_ip筛选条件
+func matchSession(session *zlmedia.SessionInfo, params url.Values) bool {
+	if port := params.Get("local_port"); port != "" && port != strconv.Itoa(session.LocalPort) {
+		return false
+	}
+	if ip := params.Get("peer_ip"); ip != "" && ip != session.PeerIP {
+		return false
+	}
+	return true
+}
+
+func (s *Server) kickSession(params url.Values) interface{} {
+	if status, ok := requireParams(params, "id"); !ok {
+		return status
+	}
+
+	if _, ok := s.sessions[params.Get("id")]; !ok {
+		return failure(zlmedia.CodeOtherFailed, "can not find the target")
+	}
+	delete(s.sessions, params.Get("id"))
+	return success()
+}
+
+func (s *Server) kickSessions(params url.Values) interface{} {
+	hit := 0
+	for id, session := range s.sessions {
+		if matchSession(session, params) {
+			delete(s.sessions, id)
+			hit++
+		}
+	}
+	return zlmedia.KickSessionsResponse{CountHit: hit}
+}
+
+func (s *Server) webrtc(method string, params url.Values, offer string) interface{} {
+	if status, ok := requireParams(params, "type"); !ok {
+		return status
+	}
+
+	id, _, status := s.openRtcSession(params.Get("type"), params, offer)
+	if status != nil {
+		return *status
+	}
+	return zlmedia.WebRTCResponse{ID: id, SDP: answerSDP(params.Get("type")), Type: "answer"}
+}
+
+func (s *Server) whip(method string, params url.Values, offer string) interface{} {
+	return s.whipWhep("push", params, offer)
+}
+
+func (s *Server) whep(method string, params url.Values, offer string) interface{} {
+	return s.whipWhep("play", params, offer)
+}
+
+// whipWhep 创建WHIP/WHEP会话，返回201、answer sdp以及delete_webrtc地址
+func (s *Server) whipWhep(sessionType string, params url.Values, offer string) interface{} {
+	id, token, status := s.openRtcSession(sessionType, params, offer)
+	if status != nil {
+		return *status
+	}
+	location := s.URL() + "/index/api/delete_webrtc?" + url.Values{"id": {id}, "token": {token}}.Encode()
+	return rawResponse{status: http.StatusCreated, contentType: "application/sdp", location: location, body: []byte(answerSDP(sessionType))}
+}
+
+func (s *Server) deleteWebRTC(method string, params url.Values, offer string) interface{} {
+	if status, ok := requireParams(params, "id", "token"); !ok {
+		return status
+	}
+	if method != http.MethodDelete {
+		return failure(zlmedia.CodeOtherFailed, "http method is not DELETE: "+method)
+	}
+
+	session, ok := s.rtcSessions[params.Get("id")]
+	switch {
+	case !ok:
+		return rawResponse{status: http.StatusUnauthorized, contentType: "text/plain", body: []byte("id not found")}
+	case session.token != params.Get("token"):
+		return rawResponse{status: http.StatusUnauthorized, contentType: "text/plain", body: []byte("token incorrect")}
+	}
+	delete(s.rtcSessions, params.Get("id"))
+	if session.sessionType == "push" {
+		s.removeStream(session.key)
+	}
+	return rawResponse{contentType: "text/plain"}
+}
+
+// rtcSession WebRTC会话
+type rtcSession struct {
+	sessionType string
+	key         zlmedia.StreamKey
+	token       string
+}
+
+// openRtcSession 创建WebRTC会话，推流时注册流，调用方需持有锁
+// 返回: 会话id和删除会话的令牌，失败时返回错误响应
+func (s *Server) openRtcSession(sessionType string, params url.Values, offer string) (string, string, *zlmedia.Status) {
+	if status, ok := requireParams(params, "app", "stream"); !ok {
+		return "", "", &status
+	}
+	if offer == "" {
+		status := failure(zlmedia.CodeOtherFailed, "http body(webrtc offer sdp) is empty")
+		return "", "", &status
+	}
+
+	key := zlmedia.StreamKey{VHost: params.Get("vhost"), App: params.Get("app"), Stream: params.Get("stream")}
+	switch sessionType {
+	case "play":
+		if !s.hasStream(key) {
+			status := failure(zlmedia.CodeNotFound, "stream not found")
+			return "", "", &status
+		}
+	case "push":
+		if s.hasStream(key) {
+			status := failure(zlmedia.CodeOtherFailed, "This stream already exists")
+			return "", "", &status
+		}
+		s.addStream(key, OriginTypeRtcPush, "", s.defaultSchemas(url.Values{}))
+	case "echo":
+	default:
+		status := failure(zlmedia.CodeException, "the type can not supported: "+sessionType)
+		return "", "", &status
+	}
+
+	id := fmt.Sprintf("zlmtest-rtc-%d", s.nextID)
+	token := fmt.Sprintf("zlmtest-token-%d", s.nextID)
+	s.nextID++
+	s.rtcSessions[id] = &rtcSession{sessionType: sessionType, key: key, token: token}
+	return id, token, nil
+}
+
+// answerSDP 生成一个固定的answer sdp
+func answerSDP(sessionType string) string {
+	direction := "sendonly"
+	switch sessionType {
+	case "push":
+		direction = "recvonly"
+	case "echo":
+		direction = "sendrecv"
+	}
+	return strings.Join([]string{
+		"v=0",
+		"o=- 0 0 IN IP4 127.0.0.1",
+		"s=zlmtest",
+		"t=0 0",
+		"a=group:BUNDLE 0",
+		"m=video 9 UDP/TLS/RTP/SAVPF 96",
+		"c=IN IP4 0.0.0.0",
+		"a=mid:0",
+		"a=" + direction,
+		"a=rtcp-mux",
+		"a=ice-ufrag:zlmtest",
+		"a=ice-pwd:zlmtestzlmtestzlmtestzl",
+		"a=fingerprint:sha-256 00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00:00",
+		"a=setup:passive",
+		"a=rtpmap:96 H264/90000",
+		"a=candidate:1 1 udp 2130706431 127.0.0.1 8000 typ host",
+		"",
+	}, "\r\n")
+}
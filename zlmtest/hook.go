@@ -0,0 +1,85 @@
+package zlmtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// webrtcHookName 获取WebRTC接口需要调用的鉴权hook，推流为on_publish，播放为on_play，其它为空
+func webrtcHookName(path string, params url.Values) string {
+	sessionType := params.Get("type")
+	switch path {
+	case "/index/api/whip":
+		sessionType = "push"
+	case "/index/api/whep":
+		sessionType = "play"
+	case "/index/api/webrtc":
+	default:
+		return ""
+	}
+
+	switch sessionType {
+	case "push":
+		return "on_publish"
+	case "play":
+		return "on_play"
+	}
+	return ""
+}
+
+// authorizeWebRTC 与ZLMediaKit一样在创建WebRTC会话前调用on_publish/on_play鉴权
+// hook.enable为1且配置了hook地址时调用，url参数原样放在事件的params中；鉴权失败时返回错误响应
+func (s *Server) authorizeWebRTC(path string, params url.Values) *zlmedia.Status {
+	name := webrtcHookName(path, params)
+	s.mu.Lock()
+	enabled := s.config["hook.enable"] == "1"
+	hookURL := s.config["hook."+name]
+	mediaServerID := s.config["general.mediaServerId"]
+	s.mu.Unlock()
+	if name == "" || !enabled || hookURL == "" {
+		return nil
+	}
+
+	media := zlmedia.HookMedia{
+		Schema: "rtc",
+		VHost:  params.Get("vhost"),
+		App:    params.Get("app"),
+		Stream: params.Get("stream"),
+		Params: params.Encode(),
+	}
+	if media.VHost == "" {
+		media.VHost = zlmedia.DefaultVHost
+	}
+	base := zlmedia.HookBase{MediaServerID: mediaServerID}
+	var event interface{} = &zlmedia.OnPlayHook{HookBase: base, HookMedia: media}
+	if name == "on_publish" {
+		event = &zlmedia.OnPublishHook{HookBase: base, HookMedia: media, OriginType: OriginTypeRtcPush}
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		status := failure(zlmedia.CodeException, err.Error())
+		return &status
+	}
+	resp, err := http.Post(hookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		status := failure(zlmedia.CodeOtherFailed, err.Error())
+		return &status
+	}
+	defer resp.Body.Close()
+
+	var result zlmedia.HookResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		status := failure(zlmedia.CodeOtherFailed, err.Error())
+		return &status
+	}
+	if result.Code != zlmedia.CodeSuccess {
+		status := failure(zlmedia.CodeAuthFailed, result.Msg)
+		return &status
+	}
+	return nil
+}
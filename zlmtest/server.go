@@ -0,0 +1,372 @@
+// Package zlmtest 提供进程内的ZLMediaKit模拟服务器，用于离线测试
+// 模拟服务器基于httptest.Server，以有状态的方式实现/index/api/*接口(流、拉流代理、推流代理、RTP服务器、会话、录制)，
+// 校验secret，返回与ZLMediaKit一致的JSON结构，并支持故障注入；
+// 配置hook.enable和hook.on_publish/hook.on_play后，创建WebRTC会话前会调用对应的hook鉴权
+package zlmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// DefaultSecret 模拟服务器默认的API密钥
+const DefaultSecret = "zlmtest-secret"
+
+// Fault 注入的故障
+// HTTPStatus不为0时返回该HTTP状态码；否则Code不为0时返回该ZLMediaKit错误代码；Delay为响应前的等待时间
+type Fault struct {
+	HTTPStatus int           // 返回的HTTP状态码
+	Code       int           // 返回的ZLMediaKit错误代码
+	Msg        string        // 返回的错误消息
+	Delay      time.Duration // 响应前等待的时间，可用于模拟超时
+	Times      int           // 故障生效的次数，<=0表示一直生效
+}
+
+// Option 模拟服务器选项
+type Option func(s *Server)
+
+// WithSecret 设置API密钥
+func WithSecret(secret string) Option {
+	return func(s *Server) {
+		s.secret = secret
+	}
+}
+
+// WithRtpPortRange 设置openRtpServer随机分配端口的范围，默认为30000~30500
+func WithRtpPortRange(min, max int) Option {
+	return func(s *Server) {
+		s.rtpPortMin, s.rtpPortMax = min, max
+	}
+}
+
+// handlerFunc 接口处理函数，返回的值会被序列化为JSON响应
+type handlerFunc func(params url.Values) interface{}
+
+// webrtcHandlerFunc WebRTC接口处理函数，params为url参数，offer为请求体
+type webrtcHandlerFunc func(method string, params url.Values, offer string) interface{}
+
+// Server ZLMediaKit模拟服务器
+type Server struct {
+	httpServer *httptest.Server
+	secret     string
+	handlers   map[string]handlerFunc
+
+	webrtcHandlers map[string]webrtcHandlerFunc
+
+	rtpPortMin int
+	rtpPortMax int
+
+	mu          sync.Mutex
+	medias      map[string]*zlmedia.MediaInfo // key为schema/vhost/app/stream
+	proxies     map[string]*zlmedia.ProxyInfo
+	pushers     map[string]*zlmedia.PusherProxyInfo
+	rtpServers  map[string]*zlmedia.RtpServerInfo // key为stream_id
+	rtpSSRCs    map[string]string                 // key为stream_id，值为openRtpServer传入的ssrc
+	sendRtps    map[string]int                    // key为vhost/app/stream/ssrc，值为本地端口
+	sessions    map[string]*zlmedia.SessionInfo
+	rtcSessions map[string]*rtcSession     // key为WebRTC会话id
+	records     map[string]map[string]bool // key为vhost/app/stream，值为录制类型(hls/mp4)集合
+	recordFiles map[string]map[string][]string
+	config      map[string]string
+	threads     []zlmedia.ThreadLoad
+	snap        rawResponse // getSnap返回的图片
+	workThreads []zlmedia.ThreadLoad
+	faults      map[string]*Fault
+	requests    map[string]int
+	headers     map[string]http.Header // 每个接口最近一次请求的请求头
+	nextPort    int
+	nextID      int
+}
+
+// NewServer 创建并启动模拟服务器，使用完毕后需要调用Close
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		secret:      DefaultSecret,
+		rtpPortMin:  30000,
+		rtpPortMax:  30500,
+		medias:      make(map[string]*zlmedia.MediaInfo),
+		proxies:     make(map[string]*zlmedia.ProxyInfo),
+		pushers:     make(map[string]*zlmedia.PusherProxyInfo),
+		rtpServers:  make(map[string]*zlmedia.RtpServerInfo),
+		rtpSSRCs:    make(map[string]string),
+		sendRtps:    make(map[string]int),
+		sessions:    make(map[string]*zlmedia.SessionInfo),
+		rtcSessions: make(map[string]*rtcSession),
+		records:     make(map[string]map[string]bool),
+		recordFiles: make(map[string]map[string][]string),
+		config:      defaultServerConfig(),
+		threads:     []zlmedia.ThreadLoad{{Delay: 0, Load: 0}},
+		workThreads: []zlmedia.ThreadLoad{{Delay: 0, Load: 0}},
+		snap:        rawResponse{contentType: "image/jpeg", body: snapJPEG},
+		faults:      make(map[string]*Fault),
+		requests:    make(map[string]int),
+		headers:     make(map[string]http.Header),
+		nextID:      1,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.nextPort = s.rtpPortMin
+	s.config["api.secret"] = s.secret
+	s.registerHandlers()
+	s.httpServer = httptest.NewServer(s)
+	return s
+}
+
+// URL 模拟服务器的基础URL
+func (s *Server) URL() string {
+	return s.httpServer.URL
+}
+
+// Secret 模拟服务器的API密钥
+func (s *Server) Secret() string {
+	return s.secret
+}
+
+// Config 连接模拟服务器的客户端配置
+func (s *Server) Config() zlmedia.Config {
+	return zlmedia.Config{
+		BaseURL: s.URL(),
+		Secret:  s.secret,
+	}
+}
+
+// Client 创建连接模拟服务器的客户端
+func (s *Server) Client() *zlmedia.Client {
+	return zlmedia.NewClient(s.Config())
+}
+
+// Close 关闭模拟服务器
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+// InjectFault 为接口注入故障，path为接口路径，例如/index/api/getMediaList
+func (s *Server) InjectFault(path string, fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults[path] = &fault
+}
+
+// ClearFaults 清除所有注入的故障
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = make(map[string]*Fault)
+}
+
+// RequestCount 获取接口被请求的次数，包括鉴权失败和故障注入的请求
+func (s *Server) RequestCount(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[path]
+}
+
+// LastRequestHeader 获取接口最近一次请求的请求头，没有请求过时返回nil
+func (s *Server) LastRequestHeader(path string) http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.headers[path].Clone()
+}
+
+// ServeHTTP 实现http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	s.headers[r.URL.Path] = r.Header.Clone()
+	fault := s.takeFault(r.URL.Path)
+	handler, ok := s.handlers[r.URL.Path]
+	webrtcHandler, isWebRTC := s.webrtcHandlers[r.URL.Path]
+	s.mu.Unlock()
+
+	if fault != nil {
+		if fault.Delay > 0 {
+			select {
+			case <-time.After(fault.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if fault.HTTPStatus != 0 {
+			http.Error(w, fault.Msg, fault.HTTPStatus)
+			return
+		}
+		if fault.Code != 0 {
+			writeJSON(w, zlmedia.Status{Code: fault.Code, Msg: fault.Msg})
+			return
+		}
+	}
+
+	// WebRTC接口与ZLMediaKit一样不校验secret，参数在url中，请求体为sdp
+	if isWebRTC {
+		offer, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if status := s.authorizeWebRTC(r.URL.Path, r.URL.Query()); status != nil {
+			writeJSON(w, *status)
+			return
+		}
+		s.mu.Lock()
+		result := webrtcHandler(r.Method, r.URL.Query(), string(offer))
+		s.mu.Unlock()
+		writeResult(w, result)
+		return
+	}
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	params, err := requestParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if params.Get("secret") != s.secret {
+		writeJSON(w, zlmedia.Status{Code: zlmedia.CodeAuthFailed, Msg: "Incorrect secret"})
+		return
+	}
+
+	// 接口处理函数在持有锁时执行，可以直接读写状态
+	s.mu.Lock()
+	result := handler(params)
+	s.mu.Unlock()
+	writeResult(w, result)
+}
+
+// writeResult 写入接口处理函数的返回值，rawResponse原样写入，其它值序列化为JSON
+func writeResult(w http.ResponseWriter, result interface{}) {
+	if raw, ok := result.(rawResponse); ok {
+		w.Header().Set("Content-Type", raw.contentType)
+		if raw.location != "" {
+			w.Header().Set("Location", raw.location)
+		}
+		if raw.status != 0 {
+			w.WriteHeader(raw.status)
+		}
+		w.Write(raw.body)
+		return
+	}
+	writeJSON(w, result)
+}
+
+// takeFault 取出接口当前生效的故障，调用方需持有锁
+func (s *Server) takeFault(path string) *Fault {
+	fault, ok := s.faults[path]
+	if !ok {
+		return nil
+	}
+	if fault.Times > 0 {
+		fault.Times--
+		if fault.Times == 0 {
+			delete(s.faults, path)
+		}
+	}
+	copied := *fault
+	return &copied
+}
+
+// rawResponse 非JSON响应，例如截图
+type rawResponse struct {
+	status      int // HTTP状态码，为0时为200
+	contentType string
+	location    string
+	body        []byte
+}
+
+// requestParams 解析请求参数，GET请求从URL中解析，POST请求从JSON或表单body中解析
+func requestParams(r *http.Request) (url.Values, error) {
+	params := r.URL.Query()
+	if r.Method != http.MethodPost {
+		return params, nil
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("解析请求体失败: %w", err)
+		}
+		for key, value := range body {
+			if value != nil {
+				params.Set(key, fmt.Sprintf("%v", value))
+			}
+		}
+		return params, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, fmt.Errorf("解析请求体失败: %w", err)
+	}
+	for key, values := range r.PostForm {
+		params[key] = values
+	}
+	return params, nil
+}
+
+// writeJSON 写入JSON响应
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// sortedKeys 获取map的key并排序，用于生成稳定的列表
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// defaultServerConfig 默认的服务器配置，端口与ZLMediaKit默认配置一致
+func defaultServerConfig() map[string]string {
+	return map[string]string{
+		"api.apiDebug":                    "1",
+		"api.secret":                      DefaultSecret,
+		"api.snapRoot":                    "./www/snap/",
+		"general.mediaServerId":           "zlmtest",
+		"general.streamNoneReaderDelayMS": "20000",
+		"hls.segDur":                      "2",
+		"hook.enable":                     "0",
+		"hook.on_publish":                 "",
+		"hook.on_play":                    "",
+		"hook.on_stream_changed":          "",
+		"http.port":                       "80",
+		"http.sslport":                    "443",
+		"protocol.enable_hls":             "1",
+		"protocol.enable_mp4":             "0",
+		"protocol.enable_rtsp":            "1",
+		"protocol.enable_rtmp":            "1",
+		"protocol.enable_ts":              "1",
+		"protocol.enable_fmp4":            "1",
+		"record.appName":                  "record",
+		"record.fileSecond":               "3600",
+		"rtc.port":                        "8000",
+		"rtc.tcpPort":                     "8000",
+		"rtmp.port":                       "1935",
+		"rtmp.sslport":                    "0",
+		"rtp_proxy.port":                  "10000",
+		"rtp_proxy.port_range":            "30000-35000",
+		"rtsp.port":                       "554",
+		"rtsp.sslport":                    "0",
+		"shell.port":                      "0",
+		"srt.port":                        "9000",
+	}
+}
@@ -0,0 +1,149 @@
+package zlmtest
+
+import (
+	"net/url"
+
+	zlmedia "github.com/edwardpan/zlmedia_restapi_go"
+)
+
+// PublishStream 模拟推流，注册流的各个协议
+// schemas为空时按服务器的protocol.enable_*配置生成协议列表
+func (s *Server) PublishStream(key zlmedia.StreamKey, originType int, schemas ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(schemas) == 0 {
+		schemas = s.defaultSchemas(url.Values{})
+	}
+	s.addStream(key, originType, "", schemas)
+}
+
+// UnpublishStream 模拟断流，注销流的所有协议，返回流是否存在
+func (s *Server) UnpublishStream(key zlmedia.StreamKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.removeStream(key)
+}
+
+// SetReaderCount 设置流在某个协议下的观看人数，观看总人数为所有协议之和
+func (s *Server) SetReaderCount(key zlmedia.StreamKey, schema string, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	media, ok := s.medias[schema+"/"+key.String()]
+	if !ok {
+		return
+	}
+	media.ReaderCount = count
+
+	total := 0
+	for _, m := range s.medias {
+		if m.VHost == media.VHost && m.App == media.App && m.Stream == media.Stream {
+			total += m.ReaderCount
+		}
+	}
+	for _, m := range s.medias {
+		if m.VHost == media.VHost && m.App == media.App && m.Stream == media.Stream {
+			m.TotalReaderCount = total
+		}
+	}
+}
+
+// PushRtp 模拟RTP数据到达openRtpServer创建的端口，在rtp应用下注册流
+// 端口不存在时返回false
+func (s *Server) PushRtp(streamID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rtpServers[streamID]; !ok {
+		return false
+	}
+	s.addStream(zlmedia.StreamKey{App: "rtp", Stream: streamID}, OriginTypeRtpPush, "", s.defaultSchemas(url.Values{}))
+	return true
+}
+
+// AddSession 添加tcp会话
+func (s *Server) AddSession(session zlmedia.SessionInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = &session
+}
+
+// AddRecordFile 添加录制文件，day格式为2006-01-02，file为mp4文件名
+func (s *Server) AddRecordFile(key zlmedia.StreamKey, day, file string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.recordFiles[key.String()] == nil {
+		s.recordFiles[key.String()] = make(map[string][]string)
+	}
+	s.recordFiles[key.String()][day] = append(s.recordFiles[key.String()][day], file)
+}
+
+// SetThreadsLoad 设置网络线程和后台线程的负载
+func (s *Server) SetThreadsLoad(threads, workThreads []zlmedia.ThreadLoad) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.threads = append([]zlmedia.ThreadLoad(nil), threads...)
+	s.workThreads = append([]zlmedia.ThreadLoad(nil), workThreads...)
+}
+
+// SetSnap 设置getSnap返回的图片，默认为image/jpeg格式的截图
+// 可以设置为png等其它格式的图片，模拟截图失败时ZLMediaKit返回的默认图片
+func (s *Server) SetSnap(contentType string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snap = rawResponse{contentType: contentType, body: append([]byte(nil), data...)}
+}
+
+// Restart 模拟服务器重启，清空流、代理、RTP服务器和会话，保留配置和录制文件
+func (s *Server) Restart() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetRuntime()
+}
+
+// Proxies 获取当前的拉流代理
+func (s *Server) Proxies() []zlmedia.ProxyInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.listStreamProxy(nil).(zlmedia.Response[[]zlmedia.ProxyInfo]).Data
+}
+
+// RtpServers 获取当前的RTP服务器
+func (s *Server) RtpServers() []zlmedia.RtpServerInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.listRtpServer(nil).(zlmedia.Response[[]zlmedia.RtpServerInfo]).Data
+}
+
+// RtpServerSSRC 获取创建RTP服务器时传入的ssrc，未传入或服务器不存在时返回空字符串
+func (s *Server) RtpServerSSRC(streamID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rtpSSRCs[streamID]
+}
+
+// Streams 获取当前的所有流
+func (s *Server) Streams() []zlmedia.MediaInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.getMediaList(url.Values{}).(zlmedia.Response[[]zlmedia.MediaInfo]).Data
+}
+
+// WebRTCSessions 获取当前所有WebRTC会话的id
+func (s *Server) WebRTCSessions() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return sortedKeys(s.rtcSessions)
+}